@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/database"
+	"github.com/candidate-organizer/backend/internal/jobs"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/migrations"
+)
+
+// runMigrate applies the migrations built into the binary
+func runMigrate(ctx context.Context, args []string) error {
+	flags := newFlags("migrate")
+	dryRun := flags.Bool("dry-run", false, "list pending migrations only")
+	baseline := flags.Int("baseline", 0, "record migrations up to this version as applied")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	_, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *dryRun {
+		pending, err := database.PendingMigrations(ctx, db.DB, migrations.FS)
+		if err != nil {
+			return err
+		}
+		for _, m := range pending {
+			fmt.Println(m.Name)
+		}
+		fmt.Printf("%d pending migrations\n", len(pending))
+		return nil
+	}
+
+	applied, err := database.Migrate(ctx, db.DB, migrations.FS, *baseline)
+	fmt.Printf("Applied %d migrations\n", len(applied))
+	return err
+}
+
+// runUsers creates users and changes their roles
+func runUsers(ctx context.Context, args []string) error {
+	action, args, err := subcommand(args, "create", "promote")
+	if err != nil {
+		return err
+	}
+	flags := newFlags("users " + action)
+	email := flags.String("email", "", "email address")
+	name := flags.String("name", "", "display name")
+	role := flags.String("role", "", "role name")
+	serviceAccount := flags.Bool("service-account", false, "create a service account")
+	force := flags.Bool("force", false, "change the role even if no other active user can manage users")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	*email = strings.ToLower(strings.TrimSpace(*email))
+	if *email == "" {
+		return fmt.Errorf("--email is required")
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	userRepo := repository.NewPostgresUserRepository(db.DB)
+	roleRepo := repository.NewPostgresRoleRepository(db.DB)
+	auditRepo := repository.NewPostgresAuditRepository(db.DB)
+
+	if *role == "" {
+		*role = cfg.DefaultRole
+		if action == "promote" {
+			*role = auth.RoleAdmin
+		}
+	}
+	found, err := roleRepo.GetByName(ctx, *role)
+	if err != nil {
+		return err
+	}
+	if found == nil {
+		return fmt.Errorf("unknown role %q", *role)
+	}
+
+	existing, err := userRepo.GetByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+
+	if action == "create" {
+		if existing != nil {
+			return fmt.Errorf("a user with email %s already exists", *email)
+		}
+		if *name == "" {
+			return fmt.Errorf("--name is required")
+		}
+		_, domain, _ := strings.Cut(*email, "@")
+		user := &models.User{
+			Email:           *email,
+			Name:            *name,
+			Role:            found.Name,
+			WorkspaceDomain: domain,
+			ServiceAccount:  *serviceAccount,
+		}
+		if err := userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		auditCLI(ctx, auditRepo, "user.created", "user", user.ID, map[string]interface{}{"role": user.Role})
+		fmt.Printf("Created user %s (%s) with role %s\n", user.Email, user.ID, user.Role)
+		return nil
+	}
+
+	if existing == nil || existing.DeletedAt != nil {
+		return fmt.Errorf("no user with email %s", *email)
+	}
+	if *force {
+		err = userRepo.SetRole(ctx, existing.ID, found.Name)
+	} else {
+		err = userRepo.SetRoleKeeping(ctx, existing.ID, found.Name, auth.PermUsersManage)
+	}
+	if err == repository.ErrLastHolder {
+		return fmt.Errorf("%s is the last active user who can manage users; promote someone else first or pass --force", existing.Email)
+	}
+	if err != nil {
+		return err
+	}
+	auditCLI(ctx, auditRepo, "user.role_changed", "user", existing.ID, map[string]interface{}{
+		"from": existing.Role,
+		"to":   found.Name,
+	})
+	fmt.Printf("Changed the role of %s from %s to %s\n", existing.Email, existing.Role, found.Name)
+	return nil
+}
+
+// runKeys rotates the access token signing key
+func runKeys(ctx context.Context, args []string) error {
+	_, args, err := subcommand(args, "rotate")
+	if err != nil {
+		return err
+	}
+	if err := parse(newFlags("keys rotate"), args); err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	keys, err := auth.NewKeyManager(repository.NewPostgresSigningKeyRepository(db.DB), cfg.JWTSigningAlg, cfg.JWTKeyRotation, cfg.JWTKeyGrace)
+	if err != nil {
+		return err
+	}
+	key, err := keys.Rotate(ctx)
+	if err != nil {
+		return err
+	}
+	auditCLI(ctx, repository.NewPostgresAuditRepository(db.DB), "signing_key.rotated", "signing_key", key.ID, map[string]interface{}{
+		"algorithm": key.Algorithm,
+	})
+	fmt.Printf("Created %s signing key %s; it signs tokens from %s\n", key.Algorithm, key.ID, key.ActivatesAt.Format(time.RFC3339))
+	return nil
+}
+
+// defaultRetention is how long purge keeps records after they stop having any effect
+const defaultRetention = 30 * 24 * time.Hour
+
+// runPurge deletes records that no longer have any effect
+func runPurge(ctx context.Context, args []string) error {
+	flags := newFlags("purge")
+	olderThan := flags.Duration("older-than", defaultRetention, "how long expired records are kept")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if *olderThan < 0 {
+		return fmt.Errorf("--older-than must not be negative")
+	}
+
+	_, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	lines, err := purge(ctx, db.DB, time.Now().Add(-*olderThan))
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	return err
+}
+
+// purge deletes records of every kind that stopped having any effect before the
+// given time, returning a line per kind saying how many were deleted
+func purge(ctx context.Context, db *sql.DB, before time.Time) ([]string, error) {
+	purges := []struct {
+		name   string
+		delete func(context.Context, time.Time) (int64, error)
+	}{
+		{"sessions", repository.NewPostgresSessionRepository(db).DeleteExpired},
+		{"invitations", repository.NewPostgresInvitationRepository(db).DeleteExpired},
+		{"API keys", repository.NewPostgresAPIKeyRepository(db).DeleteExpired},
+		{"signing keys", repository.NewPostgresSigningKeyRepository(db).DeleteExpired},
+		{"background jobs", jobs.NewQueue(db).DeleteFinished},
+		{"task runs", repository.NewPostgresTaskRunRepository(db).DeleteExpired},
+	}
+	var lines []string
+	for _, p := range purges {
+		deleted, err := p.delete(ctx, before)
+		if err != nil {
+			return lines, fmt.Errorf("failed to purge %s: %w", p.name, err)
+		}
+		lines = append(lines, fmt.Sprintf("Deleted %d expired %s", deleted, p.name))
+	}
+	return lines, nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/candidate-organizer/backend/internal/database"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+const usage = `Usage:
+  server                          Run the API server
+  server worker                   Run background jobs without serving the API
+  server config print             Print the resolved configuration and where each value came from
+      --redacted=false            Show secrets instead of [REDACTED]
+  server migrate                  Apply pending database migrations
+      --dry-run                   List the pending migrations without applying them
+      --baseline=N                Record migrations up to N as applied without running them
+  server users create             Create a user without a browser login
+      --email=E --name=N          Required
+      --role=R                    Defaults to DEFAULT_ROLE
+      --service-account           Create a service account, which signs in with API keys only
+  server users promote            Change a user's role
+      --email=E                   Required
+      --role=R                    Defaults to admin
+  server seed                     Add demo jobs, candidates and comments
+      --owner=E                   Existing user who owns the demo data; defaults to the oldest admin
+  server keys rotate              Create a new access token signing key now
+  server encryption status        List the keys that encrypt sensitive candidate columns
+  server encryption rotate        Create a new data key; candidates are re-encrypted in the background
+  server encryption rewrap        Wrap every data key with the first of ENCRYPTION_MASTER_KEYS
+  server encryption reencrypt     Re-encrypt every candidate with the current data key now
+  server export                   Write users, jobs and candidates as JSON
+      --out=FILE                  Defaults to standard output
+  server import                   Read a file written by export; records get new IDs
+      --in=FILE                   Defaults to standard input
+      --skip-duplicates           Skip candidates whose email matches an existing candidate
+  server privacy export           Write a zip of everything held about a candidate
+      --candidate=ID              Required
+      --out=FILE                  Defaults to standard output
+  server privacy erase            Irreversibly erase a candidate's personal data
+      --candidate=ID --reason=R   Required; the reason is kept in the audit trail
+  server retention apply          Apply the data retention policies now
+      --dry-run                   List the candidates each policy applies to without changing them
+  server purge                    Delete expired sessions, invitations, API keys, signing keys,
+                                  finished background jobs and old task runs
+      --older-than=DURATION       Keep records that expired more recently, e.g. 720h (default)
+`
+
+// commands are run instead of the server. Their error is printed and exits with 1.
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"worker":     runWorker,
+	"config":     runConfig,
+	"migrate":    runMigrate,
+	"users":      runUsers,
+	"seed":       runSeed,
+	"keys":       runKeys,
+	"encryption": runEncryption,
+	"export":     runExport,
+	"import":     runImport,
+	"privacy":    runPrivacy,
+	"purge":      runPurge,
+	"retention":  runRetention,
+}
+
+// errUsage reports a malformed command line; the usage has already been printed
+var errUsage = errors.New("invalid usage")
+
+// runCommand runs a subcommand instead of the server and returns the exit code
+func runCommand(args []string) int {
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Print(usage)
+		return 0
+	}
+	run, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	// Progress goes to stderr so that output such as an export can be piped
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// newFlags returns a flag set that prints the command usage on errors
+func newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	return flags
+}
+
+// parse parses args, turning flag errors into errUsage. Positional arguments are
+// not accepted.
+func parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected argument %q\n\n%s", flags.Arg(0), usage)
+		return errUsage
+	}
+	return nil
+}
+
+// subcommand splits "users create ..." style arguments into the action and the rest
+func subcommand(args []string, actions ...string) (string, []string, error) {
+	if len(args) > 0 {
+		for _, action := range actions {
+			if args[0] == action {
+				return action, args[1:], nil
+			}
+		}
+	}
+	fmt.Fprint(os.Stderr, usage)
+	return "", nil, errUsage
+}
+
+// connect loads the configuration and opens the database. The caller closes it.
+func connect() (*config.Config, *database.DB, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	db, err := database.New(cfg.DatabaseURL, cfg.DBMaxOpenConns, cfg.DBMaxIdleConns)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, db, nil
+}
+
+// auditCLI records an action taken from the command line, which has no actor
+func auditCLI(ctx context.Context, auditRepo repository.AuditRepository, action, entityType, entityID string, details map[string]interface{}) {
+	auditSystem(ctx, auditRepo, "cli", action, entityType, entityID, details)
+}
+
+// auditSystem records an action with no actor, noting where it came from
+func auditSystem(ctx context.Context, auditRepo repository.AuditRepository, source, action, entityType, entityID string, details map[string]interface{}) {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details["source"] = source
+	event := &models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Details:    details,
+	}
+	if err := auditRepo.Record(ctx, event); err != nil {
+		slog.Error("Failed to record audit event", "action", action, "error", err)
+	}
+}
+
+// runConfig prints the configuration, or every problem with it
+func runConfig(ctx context.Context, args []string) error {
+	_, args, err := subcommand(args, "print")
+	if err != nil {
+		return err
+	}
+	flags := newFlags("config print")
+	redacted := flags.Bool("redacted", true, "hide secrets")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg.Print(os.Stdout, *redacted)
+}
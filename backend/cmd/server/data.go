@@ -0,0 +1,484 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// exportPageSize is how many jobs or candidates are read per query during export
+const exportPageSize = 500
+
+// demoJobPrefix marks the job postings created by seed
+const demoJobPrefix = "[Demo] "
+
+// exportVersion is the current version of the export file format
+const exportVersion = 1
+
+// exportFile is the format written by export and read by import
+type exportFile struct {
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Users      []*models.User     `json:"users"`
+	Jobs       []*exportJob       `json:"jobs"`
+	Candidates []*exportCandidate `json:"candidates"`
+}
+
+type exportJob struct {
+	*models.JobPosting
+	Team []*models.JobTeamMember `json:"team"`
+}
+
+type exportCandidate struct {
+	*models.Candidate
+	Comments   []*models.Comment            `json:"comments"`
+	Attributes []*models.CandidateAttribute `json:"attributes"`
+}
+
+// runExport writes every user, job and candidate, including confidential ones
+func runExport(ctx context.Context, args []string) error {
+	flags := newFlags("export")
+	out := flags.String("out", "", "output file")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx = repository.WithSystemAccess(ctx)
+	userRepo := repository.NewPostgresUserRepository(db.DB)
+	jobRepo := repository.NewPostgresJobRepository(db.DB)
+	keyring, err := newKeyring(cfg, db.DB)
+	if err != nil {
+		return err
+	}
+	candidateRepo := newCandidateRepository(db.DB, keyring)
+	commentRepo := repository.NewPostgresCommentRepository(db.DB)
+	attributeRepo := repository.NewPostgresAttributeRepository(db.DB)
+
+	file := &exportFile{Version: exportVersion, ExportedAt: time.Now().UTC()}
+	if file.Users, err = userRepo.List(ctx); err != nil {
+		return err
+	}
+
+	for offset := 0; ; offset += exportPageSize {
+		jobs, err := jobRepo.List(ctx, exportPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			team, err := jobRepo.ListTeam(ctx, job.ID)
+			if err != nil {
+				return err
+			}
+			file.Jobs = append(file.Jobs, &exportJob{JobPosting: job, Team: team})
+		}
+		if len(jobs) < exportPageSize {
+			break
+		}
+	}
+
+	for offset := 0; ; offset += exportPageSize {
+		candidates, err := candidateRepo.List(ctx, exportPageSize, offset, nil)
+		if err != nil {
+			return err
+		}
+		for _, candidate := range candidates {
+			exported := &exportCandidate{Candidate: candidate}
+			if exported.Comments, err = commentRepo.ListByCandidate(ctx, candidate.ID); err != nil {
+				return err
+			}
+			if exported.Attributes, err = attributeRepo.ListByCandidate(ctx, candidate.ID); err != nil {
+				return err
+			}
+			file.Candidates = append(file.Candidates, exported)
+		}
+		if len(candidates) < exportPageSize {
+			break
+		}
+	}
+
+	// Deleted users are not listed but may still own jobs, candidates and comments
+	exported := map[string]bool{}
+	for _, user := range file.Users {
+		exported[user.ID] = true
+	}
+	var referenced []string
+	for _, job := range file.Jobs {
+		referenced = append(referenced, job.CreatedBy)
+		for _, member := range job.Team {
+			referenced = append(referenced, member.UserID)
+		}
+	}
+	for _, candidate := range file.Candidates {
+		referenced = append(referenced, candidate.CreatedBy)
+		for _, comment := range candidate.Comments {
+			referenced = append(referenced, comment.UserID)
+		}
+	}
+	for _, id := range referenced {
+		if id == "" || exported[id] {
+			continue
+		}
+		user, err := userRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if user != nil {
+			file.Users = append(file.Users, user)
+		}
+		exported[id] = true
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) // Holds candidate PII
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d users, %d jobs and %d candidates\n", len(file.Users), len(file.Jobs), len(file.Candidates))
+	return nil
+}
+
+// runImport adds the records of an export file. Users are matched by email and
+// created when missing; jobs, candidates, comments and attributes are created,
+// with new IDs and timestamps. Candidates whose email matches an existing
+// candidate are reported, or skipped with --skip-duplicates. Import stops at the
+// first error, keeping what was already imported.
+func runImport(ctx context.Context, args []string) error {
+	flags := newFlags("import")
+	in := flags.String("in", "", "input file")
+	skipDuplicates := flags.Bool("skip-duplicates", false, "skip candidates whose email matches an existing candidate")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	r := io.Reader(os.Stdin)
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var file exportFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return fmt.Errorf("invalid export file: %w", err)
+	}
+	if file.Version != exportVersion {
+		return fmt.Errorf("unsupported export version %d", file.Version)
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx = repository.WithSystemAccess(ctx)
+	userRepo := repository.NewPostgresUserRepository(db.DB)
+	roleRepo := repository.NewPostgresRoleRepository(db.DB)
+	jobRepo := repository.NewPostgresJobRepository(db.DB)
+	keyring, err := newKeyring(cfg, db.DB)
+	if err != nil {
+		return err
+	}
+	candidateRepo := newCandidateRepository(db.DB, keyring)
+	commentRepo := repository.NewPostgresCommentRepository(db.DB)
+	attributeRepo := repository.NewPostgresAttributeRepository(db.DB)
+
+	// Exported IDs are mapped to the IDs of the imported records
+	userIDs := map[string]string{}
+	jobIDs := map[string]string{}
+	mapID := func(ids map[string]string, kind, id string) (string, error) {
+		if id == "" {
+			return "", nil
+		}
+		if mapped, ok := ids[id]; ok {
+			return mapped, nil
+		}
+		return "", fmt.Errorf("%s %s is not in the export", kind, id)
+	}
+
+	createdUsers := 0
+	for _, exported := range file.Users {
+		user, err := userRepo.GetByEmail(ctx, exported.Email)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			if user, err = importUser(ctx, userRepo, roleRepo, exported, cfg.DefaultRole); err != nil {
+				return fmt.Errorf("failed to import user %s: %w", exported.Email, err)
+			}
+			createdUsers++
+		}
+		userIDs[exported.ID] = user.ID
+	}
+
+	for _, exported := range file.Jobs {
+		job := *exported.JobPosting
+		if job.CreatedBy, err = mapID(userIDs, "user", job.CreatedBy); err != nil {
+			return err
+		}
+		if err := jobRepo.Create(ctx, &job); err != nil {
+			return fmt.Errorf("failed to import job %q: %w", job.Title, err)
+		}
+		jobIDs[exported.ID] = job.ID
+
+		for _, member := range exported.Team {
+			userID, err := mapID(userIDs, "user", member.UserID)
+			if err != nil {
+				return err
+			}
+			if userID == job.CreatedBy {
+				continue // Already the owner
+			}
+			if err := jobRepo.SetTeamMember(ctx, &models.JobTeamMember{JobPostingID: job.ID, UserID: userID, TeamRole: member.TeamRole}); err != nil {
+				return fmt.Errorf("failed to import the team of job %q: %w", job.Title, err)
+			}
+		}
+	}
+
+	importedCandidates, duplicates := 0, 0
+	for _, exported := range file.Candidates {
+		candidate := *exported.Candidate
+		existing, err := candidateRepo.ListByEmail(ctx, candidate.Email)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			duplicates++
+			if *skipDuplicates {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Candidate %q has the same email as existing candidate %s\n", candidate.Name, existing[0].ID)
+		}
+		if candidate.CreatedBy, err = mapID(userIDs, "user", candidate.CreatedBy); err != nil {
+			return err
+		}
+		if candidate.JobPostingID, err = mapID(jobIDs, "job", candidate.JobPostingID); err != nil {
+			return err
+		}
+		if err := candidateRepo.Create(ctx, &candidate); err != nil {
+			return fmt.Errorf("failed to import candidate %q: %w", candidate.Name, err)
+		}
+		importedCandidates++
+
+		for _, exportedComment := range exported.Comments {
+			comment := &models.Comment{CandidateID: candidate.ID, Content: exportedComment.Content}
+			if comment.UserID, err = mapID(userIDs, "user", exportedComment.UserID); err != nil {
+				return err
+			}
+			if err := commentRepo.Create(ctx, comment); err != nil {
+				return fmt.Errorf("failed to import a comment on candidate %q: %w", candidate.Name, err)
+			}
+		}
+		for _, exportedAttribute := range exported.Attributes {
+			attribute := &models.CandidateAttribute{
+				CandidateID:    candidate.ID,
+				AttributeKey:   exportedAttribute.AttributeKey,
+				AttributeValue: exportedAttribute.AttributeValue,
+			}
+			if err := attributeRepo.Create(ctx, attribute); err != nil {
+				return fmt.Errorf("failed to import attribute %q of candidate %q: %w", attribute.AttributeKey, candidate.Name, err)
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Imported %d jobs and %d candidates, creating %d of %d users\n",
+		len(file.Jobs), importedCandidates, createdUsers, len(file.Users))
+	if duplicates > 0 {
+		fmt.Fprintf(os.Stderr, "%d candidates had the email of an existing candidate\n", duplicates)
+	}
+	return nil
+}
+
+// importUser creates an exported user. Users who could not sign in when exported
+// are created deactivated, and unknown roles fall back to defaultRole.
+func importUser(ctx context.Context, userRepo repository.UserRepository, roleRepo repository.RoleRepository, exported *models.User, defaultRole string) (*models.User, error) {
+	role, err := roleRepo.GetByName(ctx, exported.Role)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{
+		Email:           exported.Email,
+		Name:            exported.Name,
+		Role:            defaultRole,
+		WorkspaceDomain: exported.WorkspaceDomain,
+		ServiceAccount:  exported.ServiceAccount,
+	}
+	if role != nil {
+		user.Role = role.Name
+	}
+	if err := userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if !exported.IsActive() {
+		if err := userRepo.SetStatus(ctx, user.ID, repository.UserStatusDeactivated); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// runSeed adds demo jobs and candidates for trying the application out
+func runSeed(ctx context.Context, args []string) error {
+	flags := newFlags("seed")
+	ownerEmail := flags.String("owner", "", "email of the user who owns the demo data")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx = repository.WithSystemAccess(ctx)
+	userRepo := repository.NewPostgresUserRepository(db.DB)
+	jobRepo := repository.NewPostgresJobRepository(db.DB)
+	keyring, err := newKeyring(cfg, db.DB)
+	if err != nil {
+		return err
+	}
+	candidateRepo := newCandidateRepository(db.DB, keyring)
+	commentRepo := repository.NewPostgresCommentRepository(db.DB)
+	attributeRepo := repository.NewPostgresAttributeRepository(db.DB)
+
+	owner, err := seedOwner(ctx, userRepo, *ownerEmail)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := jobRepo.List(ctx, 1000, 0)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if strings.HasPrefix(job.Title, demoJobPrefix) {
+			return fmt.Errorf("demo data is already present")
+		}
+	}
+
+	demoJobs := []*models.JobPosting{
+		{
+			Title:        demoJobPrefix + "Senior Backend Engineer",
+			Description:  "Build and operate the services behind our hiring platform.",
+			Requirements: "5+ years of Go or a similar language, PostgreSQL, distributed systems.",
+			Location:     "Remote (EU)",
+			SalaryRange:  "€80,000 - €100,000",
+			Status:       "open",
+		},
+		{
+			Title:        demoJobPrefix + "Product Designer",
+			Description:  "Own the end-to-end design of recruiter workflows.",
+			Requirements: "A portfolio of shipped product work and experience with user research.",
+			Location:     "Berlin",
+			SalaryRange:  "€65,000 - €80,000",
+			Status:       "open",
+		},
+		{
+			Title:        demoJobPrefix + "Head of Finance",
+			Description:  "Lead finance and reporting. Confidential search.",
+			Requirements: "10+ years in finance leadership.",
+			Location:     "London",
+			SalaryRange:  "£130,000 - £150,000",
+			Status:       "draft",
+			Confidential: true,
+		},
+	}
+	for _, job := range demoJobs {
+		job.CreatedBy = owner.ID
+		if err := jobRepo.Create(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	demoCandidates := []struct {
+		job        *models.JobPosting
+		name       string
+		status     string
+		comment    string
+		attributes map[string]string
+	}{
+		{demoJobs[0], "Ada Example", "interviewing", "Strong system design interview.", map[string]string{"source": "referral", "years_experience": "8"}},
+		{demoJobs[0], "Grace Sample", "screened", "", map[string]string{"source": "linkedin"}},
+		{demoJobs[0], "Linus Placeholder", "rejected", "Looking for a frontend role.", nil},
+		{demoJobs[1], "Dieter Demo", "applied", "", map[string]string{"portfolio": "https://example.com/portfolio"}},
+		{demoJobs[1], "Mira Mock", "offered", "Great culture fit; offer drafted.", nil},
+		{demoJobs[2], "Chris Confidential", "interviewing", "Second round with the CEO next week.", nil},
+	}
+	for i, demo := range demoCandidates {
+		candidate := &models.Candidate{
+			Name:         demo.name,
+			Email:        fmt.Sprintf("candidate%d@example.com", i+1),
+			Phone:        fmt.Sprintf("+44 20 7946 %04d", i+1), // Ofcom reserves this range for drama
+			Status:       demo.status,
+			JobPostingID: demo.job.ID,
+			CreatedBy:    owner.ID,
+		}
+		if err := candidateRepo.Create(ctx, candidate); err != nil {
+			return err
+		}
+		if demo.comment != "" {
+			if err := commentRepo.Create(ctx, &models.Comment{CandidateID: candidate.ID, UserID: owner.ID, Content: demo.comment}); err != nil {
+				return err
+			}
+		}
+		for key, value := range demo.attributes {
+			if err := attributeRepo.Create(ctx, &models.CandidateAttribute{CandidateID: candidate.ID, AttributeKey: key, AttributeValue: value}); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Printf("Created %d demo jobs and %d demo candidates owned by %s\n", len(demoJobs), len(demoCandidates), owner.Email)
+	return nil
+}
+
+// seedOwner returns the user with the email, or the longest-standing active admin
+func seedOwner(ctx context.Context, userRepo repository.UserRepository, email string) (*models.User, error) {
+	if email != "" {
+		user, err := userRepo.GetByEmail(ctx, strings.ToLower(email))
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || !user.IsActive() {
+			return nil, fmt.Errorf("no active user with email %s", email)
+		}
+		return user, nil
+	}
+
+	users, err := userRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(users) - 1; i >= 0; i-- { // Newest first
+		if users[i].Role == auth.RoleAdmin && users[i].IsActive() {
+			return users[i], nil
+		}
+	}
+	return nil, fmt.Errorf("there is no active admin to own the demo data; create one with users create or pass --owner")
+}
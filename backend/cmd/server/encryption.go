@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/candidate-organizer/backend/internal/encryption"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// newKeyring returns the keyring that encrypts sensitive candidate columns, or
+// nil when no master key is configured
+func newKeyring(cfg *config.Config, db *sql.DB) (*encryption.Keyring, error) {
+	if len(cfg.EncryptionMasterKeys) == 0 {
+		return nil, nil
+	}
+	masterKeys := make(map[string][]byte, len(cfg.EncryptionMasterKeys))
+	for _, key := range cfg.EncryptionMasterKeys {
+		masterKeys[key.ID] = key.Key
+	}
+	kms, err := encryption.NewLocalKMS(cfg.EncryptionMasterKeys[0].ID, masterKeys)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.NewKeyring(repository.NewPostgresDataKeyRepository(db), kms, cfg.EncryptedColumns), nil
+}
+
+// newCandidateRepository returns the candidate repository, encrypting sensitive
+// columns when there is a keyring
+func newCandidateRepository(db *sql.DB, keyring *encryption.Keyring) *repository.PostgresCandidateRepository {
+	if keyring == nil {
+		return repository.NewPostgresCandidateRepository(db, nil)
+	}
+	return repository.NewPostgresCandidateRepository(db, keyring)
+}
+
+// runEncryption shows, rotates and rewraps the keys that encrypt sensitive columns
+func runEncryption(ctx context.Context, args []string) error {
+	action, args, err := subcommand(args, "status", "rotate", "rewrap", "reencrypt")
+	if err != nil {
+		return err
+	}
+	if err := parse(newFlags("encryption "+action), args); err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	keyring, err := newKeyring(cfg, db.DB)
+	if err != nil {
+		return err
+	}
+	if keyring == nil {
+		return fmt.Errorf("encryption is disabled; set ENCRYPTION_MASTER_KEYS to enable it")
+	}
+	if err := keyring.Init(ctx); err != nil {
+		return err
+	}
+	auditRepo := repository.NewPostgresAuditRepository(db.DB)
+
+	switch action {
+	case "rotate":
+		key, err := keyring.Rotate(ctx)
+		if err != nil {
+			return err
+		}
+		auditCLI(ctx, auditRepo, "data_key.rotated", "data_key", key.ID, map[string]interface{}{
+			"master_key_id": key.MasterKeyID,
+		})
+		fmt.Printf("Created data key %s; candidates are re-encrypted with it by the encryption.reencrypt task\n", key.ID)
+
+	case "rewrap":
+		rewrapped, err := keyring.Rewrap(ctx)
+		for _, key := range rewrapped {
+			auditCLI(ctx, auditRepo, "data_key.rewrapped", "data_key", key.ID, map[string]interface{}{
+				"master_key_id": key.MasterKeyID,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rewrapped %d keys with master key %s\n", len(rewrapped), cfg.EncryptionMasterKeys[0].ID)
+
+	case "reencrypt":
+		reencrypted, err := keyring.Reencrypt(ctx, newCandidateRepository(db.DB, keyring))
+		fmt.Printf("Re-encrypted %d candidates\n", reencrypted)
+		if err != nil {
+			return err
+		}
+	}
+
+	keys, err := repository.NewPostgresDataKeyRepository(db.DB).List(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Encrypted columns: %s\n", strings.Join(cfg.EncryptedColumns, ", "))
+	for _, key := range keys {
+		state := "active"
+		if key.RetiredAt != nil {
+			state = "retired " + key.RetiredAt.Format(time.DateOnly)
+		}
+		fmt.Printf("  %s  %-11s  master key %-12s  %6d candidates  created %s  %s\n",
+			key.ID, key.Purpose, key.MasterKeyID, key.Candidates, key.CreatedAt.Format(time.DateOnly), state)
+	}
+	return nil
+}
+
+// reencryptSummary describes a re-encryption run
+func reencryptSummary(reencrypted int) string {
+	if reencrypted == 0 {
+		return "Every candidate is encrypted with the current data key"
+	}
+	return fmt.Sprintf("Re-encrypted %d candidates", reencrypted)
+}
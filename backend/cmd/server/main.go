@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	_ "time/tzdata" // SCHEDULER_TIMEZONE must load on hosts without a zone database
+
+	"github.com/candidate-organizer/backend/internal/api"
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/candidate-organizer/backend/internal/database"
+	"github.com/candidate-organizer/backend/internal/jobs"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/metrics"
+	"github.com/candidate-organizer/backend/internal/ratelimit"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+func main() {
+	// Subcommands such as "config print" run instead of the server
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Set up structured logging; the standard log package writes through it too
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	logger, err := logging.New(os.Stdout, cfg.LogFormat, level)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	// Export traces of requests, database statements and outbound calls
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TraceExporter, "candidate-organizer-backend")
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+
+	// Initialize database connection
+	dbWrapper, err := database.New(cfg.DatabaseURL, cfg.DBMaxOpenConns, cfg.DBMaxIdleConns)
+	if err != nil {
+		fatal("Failed to connect to database", err)
+	}
+
+	slog.Info("Successfully connected to database")
+
+	// Get the underlying sql.DB
+	db := dbWrapper.DB
+
+	// Initialize repositories
+	userRepo := repository.NewPostgresUserRepository(db)
+	jobRepo := repository.NewPostgresJobRepository(db)
+	commentRepo := repository.NewPostgresCommentRepository(db)
+	attributeRepo := repository.NewPostgresAttributeRepository(db)
+	offerRepo := repository.NewPostgresOfferRepository(db)
+	auditRepo := repository.NewPostgresAuditRepository(db)
+	roleRepo := repository.NewPostgresRoleRepository(db)
+	invitationRepo := repository.NewPostgresInvitationRepository(db)
+	groupMappingRepo := repository.NewPostgresGroupMappingRepository(db)
+	sessionRepo := repository.NewPostgresSessionRepository(db)
+	apiKeyRepo := repository.NewPostgresAPIKeyRepository(db)
+	signingKeyRepo := repository.NewPostgresSigningKeyRepository(db)
+	consentRepo := repository.NewPostgresConsentRepository(db)
+	retentionRepo := repository.NewPostgresRetentionRepository(db)
+
+	// Load the keys that encrypt sensitive candidate columns, creating the first ones if needed
+	keyring, err := newKeyring(cfg, db)
+	if err != nil {
+		fatal("Failed to configure encryption", err)
+	}
+	if keyring != nil {
+		if err := keyring.Init(context.Background()); err != nil {
+			fatal("Failed to initialize encryption keys", err)
+		}
+	}
+	candidateRepo := newCandidateRepository(db, keyring)
+
+	// Report connection pool statistics and candidate and job counts on /metrics
+	metrics.Registry.MustRegister(
+		collectors.NewDBStatsCollector(db, "postgres"),
+		metrics.NewBusinessCollector(candidateRepo, jobRepo, repository.NewPostgresMetricSnapshotRepository(db)),
+	)
+
+	// Load the access token signing keys, creating the first one if needed, and keep them rotated
+	keys, err := auth.NewKeyManager(signingKeyRepo, cfg.JWTSigningAlg, cfg.JWTKeyRotation, cfg.JWTKeyGrace)
+	if err != nil {
+		fatal("Failed to configure signing keys", err)
+	}
+	if err := keys.Init(context.Background()); err != nil {
+		fatal("Failed to initialize signing keys", err)
+	}
+
+	// Background workers run until shutdown
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		keys.Run(workerCtx)
+	}()
+
+	// Email and other slow work goes through the background job queue. Jobs run
+	// here unless a separate "server worker" process handles them.
+	queue := jobs.NewQueue(db)
+	sched := newScheduler(cfg, db, queue, keyring)
+	if cfg.JobWorkersInProcess {
+		worker := newWorker(cfg, queue, sched)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker.Run(workerCtx)
+		}()
+	}
+
+	// Recurring tasks run on every instance; each occurrence runs on only one of them
+	if cfg.SchedulerEnabled {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			sched.Run(workerCtx)
+		}()
+	}
+
+	// Rate limit buckets are per instance unless they are kept in the database
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == "postgres" {
+		limitStore = ratelimit.NewPostgresStore(db)
+	}
+
+	// Initialize API server
+	server := api.NewServer(cfg, userRepo, jobRepo, candidateRepo, commentRepo, attributeRepo, offerRepo, auditRepo, roleRepo, invitationRepo, groupMappingRepo, sessionRepo, apiKeyRepo, consentRepo, retentionRepo, keys, limitStore, dbWrapper, queue, sched, newPrivacyService(cfg, db, keyring), jobs.NewQueuedMailer(queue))
+
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.Port),
+		Handler:           server.Router(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		// No WriteTimeout: the router sets a write deadline per request, which
+		// streamed chats lift
+	}
+	httpServer.RegisterOnShutdown(server.StopStreams)
+
+	// Run until the platform asks us to stop (Heroku sends SIGTERM) or the server fails
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stopSignals()
+
+	// Start server
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Starting server", "addr", httpServer.Addr)
+		serverErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		fatal("Server failed to start", err)
+	case <-signals.Done():
+	}
+
+	// Fail readiness checks, then let in-flight requests finish
+	slog.Info("Shutting down", "timeout", cfg.ShutdownTimeout.String())
+	server.Drain()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to finish in-flight requests", "error", err)
+	}
+
+	// Stop background workers before closing the connections they use
+	stopWorkers()
+	workers.Wait()
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	if err := dbWrapper.Close(); err != nil {
+		slog.Error("Failed to close database", "error", err)
+	}
+	slog.Info("Shutdown complete")
+}
+
+// fatal logs an error that prevents the server from running and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
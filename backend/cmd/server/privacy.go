@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/candidate-organizer/backend/internal/encryption"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/privacy"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/internal/storage"
+)
+
+// newPrivacyService returns the service that answers data subject requests
+func newPrivacyService(cfg *config.Config, db *sql.DB, keyring *encryption.Keyring) *privacy.Service {
+	var store storage.Store
+	if cfg.ResumeStorageDir != "" {
+		store = storage.NewDir(cfg.ResumeStorageDir)
+	}
+	return privacy.NewService(
+		newCandidateRepository(db, keyring),
+		repository.NewPostgresAttributeRepository(db),
+		repository.NewPostgresCommentRepository(db),
+		repository.NewPostgresOfferRepository(db),
+		repository.NewPostgresAuditRepository(db),
+		repository.NewPostgresDataSubjectRepository(db),
+		repository.NewPostgresConsentRepository(db),
+		repository.NewPostgresRetentionRepository(db),
+		store,
+	)
+}
+
+// runRetention applies the retention policies, or reports what they would do
+func runRetention(ctx context.Context, args []string) error {
+	_, args, err := subcommand(args, "apply")
+	if err != nil {
+		return err
+	}
+	flags := newFlags("retention apply")
+	dryRun := flags.Bool("dry-run", false, "report without changing anything")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	keyring, err := newKeyring(cfg, db.DB)
+	if err != nil {
+		return err
+	}
+	reports, err := newPrivacyService(cfg, db.DB, keyring).ApplyRetention(ctx, *dryRun)
+	for _, report := range reports {
+		if report.DryRun {
+			fmt.Printf("%s would %s %d candidates\n", report.PolicyName, strings.ReplaceAll(report.Action, "_", " "), len(report.Candidates))
+			for _, c := range report.Candidates {
+				fmt.Printf("  %s  %s  %s (%s)\n", c.ID, c.LastActivity.Format("2006-01-02"), c.Name, c.Status)
+			}
+			continue
+		}
+		fmt.Printf("%s: applied %s to %d candidates, %d failed\n", report.PolicyName, report.Action, report.Applied, report.Failed)
+	}
+	return err
+}
+
+// retentionSummary describes the outcome of each policy in one line
+func retentionSummary(reports []*models.RetentionReport) string {
+	if len(reports) == 0 {
+		return "No retention policies to apply"
+	}
+	parts := make([]string, len(reports))
+	for i, report := range reports {
+		if report.DryRun {
+			parts[i] = fmt.Sprintf("%s: %d candidates due (dry run)", report.PolicyName, len(report.Candidates))
+		} else {
+			parts[i] = fmt.Sprintf("%s: applied to %d, %d failed", report.PolicyName, report.Applied, report.Failed)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// runPrivacy exports or erases a candidate's personal data
+func runPrivacy(ctx context.Context, args []string) error {
+	action, args, err := subcommand(args, "export", "erase")
+	if err != nil {
+		return err
+	}
+	flags := newFlags("privacy " + action)
+	candidateID := flags.String("candidate", "", "candidate ID")
+	out := flags.String("out", "", "output file")
+	reason := flags.String("reason", "", "why the data is erased")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if *candidateID == "" {
+		return fmt.Errorf("--candidate is required")
+	}
+	*reason = strings.TrimSpace(*reason)
+	if action == "erase" && *reason == "" {
+		return fmt.Errorf("--reason is required")
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	keyring, err := newKeyring(cfg, db.DB)
+	if err != nil {
+		return err
+	}
+	service := newPrivacyService(cfg, db.DB, keyring)
+	auditRepo := repository.NewPostgresAuditRepository(db.DB)
+
+	if action == "export" {
+		w := io.Writer(os.Stdout)
+		if *out != "" {
+			f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) // Holds candidate PII
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := service.Export(ctx, *candidateID, w); err != nil {
+			return err
+		}
+		auditCLI(ctx, auditRepo, "candidate.exported", "candidate", *candidateID, nil)
+		return nil
+	}
+
+	erasure, err := service.Erase(ctx, *candidateID)
+	if err != nil {
+		return err
+	}
+	auditCLI(ctx, auditRepo, "candidate.erased", "candidate", *candidateID, privacy.AuditDetails(erasure, *reason))
+	fmt.Printf("Erased candidate %s: deleted %d comments, %d attributes and %d summaries; anonymized %d offers; scrubbed %d audit events\n",
+		*candidateID, erasure.Comments, erasure.Attributes, erasure.Summaries, erasure.Offers, erasure.AuditEvents)
+	if erasure.ResumeDeleted {
+		fmt.Println("Deleted the resume file")
+	}
+	return nil
+}
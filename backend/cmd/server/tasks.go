@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/candidate-organizer/backend/internal/digest"
+	"github.com/candidate-organizer/backend/internal/encryption"
+	"github.com/candidate-organizer/backend/internal/jobs"
+	"github.com/candidate-organizer/backend/internal/metrics"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/internal/scheduler"
+)
+
+// newScheduler returns a scheduler with every recurring task. Email is sent
+// through the job queue so that failed sends are retried.
+func newScheduler(cfg *config.Config, db *sql.DB, queue *jobs.Queue, keyring *encryption.Keyring) *scheduler.Scheduler {
+	sched := scheduler.New(db, repository.NewPostgresTaskRunRepository(db), cfg.SchedulerLocation)
+	offerRepo := repository.NewPostgresOfferRepository(db)
+	auditRepo := repository.NewPostgresAuditRepository(db)
+	candidateRepo := newCandidateRepository(db, keyring)
+	business := metrics.NewBusinessCollector(
+		candidateRepo,
+		repository.NewPostgresJobRepository(db),
+		repository.NewPostgresMetricSnapshotRepository(db),
+	)
+	privacyService := newPrivacyService(cfg, db, keyring)
+	sender := digest.NewSender(repository.NewPostgresDigestRepository(db), jobs.NewQueuedMailer(queue), cfg.FrontendURL)
+
+	tasks := []scheduler.Task{
+		{
+			Name:        "offers.expire",
+			Description: "Move sent offers that passed their expiry date unanswered to expired",
+			Schedule:    "*/15 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				expired, err := offerRepo.ExpireOverdue(repository.WithSystemAccess(ctx), time.Now())
+				if err != nil {
+					return "", err
+				}
+				for _, offer := range expired {
+					auditSystem(ctx, auditRepo, "scheduler", "offer.expired", "offer", offer.ID, map[string]interface{}{
+						"candidate_id": offer.CandidateID,
+						"expires_at":   offer.ExpiresAt,
+					})
+				}
+				return fmt.Sprintf("Expired %d offers", len(expired)), nil
+			},
+		},
+		{
+			Name:        "retention.purge",
+			Description: "Delete sessions, invitations, keys, background jobs and task runs that expired over 30 days ago",
+			Schedule:    "30 3 * * *",
+			Timeout:     30 * time.Minute,
+			Run: func(ctx context.Context) (string, error) {
+				lines, err := purge(ctx, db, time.Now().Add(-defaultRetention))
+				return strings.Join(lines, "; "), err
+			},
+		},
+		{
+			Name:        "retention.apply",
+			Description: "Apply data retention policies to inactive candidates, or report what they would do in dry run mode",
+			Schedule:    "0 4 * * *",
+			Timeout:     30 * time.Minute,
+			Run: func(ctx context.Context) (string, error) {
+				reports, err := privacyService.ApplyRetention(ctx, false)
+				return retentionSummary(reports), err
+			},
+		},
+		{
+			Name:        "encryption.reencrypt",
+			Description: "Re-encrypt candidates written with an older data key or before their columns were encrypted",
+			Schedule:    "*/30 * * * *",
+			Timeout:     25 * time.Minute,
+			Run: func(ctx context.Context) (string, error) {
+				if keyring == nil {
+					return "Encryption is disabled", nil
+				}
+				reencrypted, err := keyring.Reencrypt(repository.WithSystemAccess(ctx), candidateRepo)
+				return reencryptSummary(reencrypted), err
+			},
+		},
+		{
+			Name:        "metrics.refresh",
+			Description: "Count candidates and jobs by status for /metrics",
+			Schedule:    "*/5 * * * *",
+			Timeout:     time.Minute,
+			Run: func(ctx context.Context) (string, error) {
+				if err := business.Refresh(ctx); err != nil {
+					return "", err
+				}
+				return "Refreshed candidate and job counts", nil
+			},
+		},
+		{
+			Name:        "digest.daily",
+			Description: "Email hiring team members a summary of the last day's activity on their open jobs",
+			Schedule:    "0 8 * * *",
+			Run: func(ctx context.Context) (string, error) {
+				now := time.Now().In(cfg.SchedulerLocation)
+				sent, err := sender.SendDaily(ctx, now.Add(-24*time.Hour), now.Add(-cfg.StaleCandidateAfter))
+				return fmt.Sprintf("Sent %d digests", sent), err
+			},
+		},
+		{
+			Name:        "candidates.stale_reminders",
+			Description: "Email job owners about candidates with no updates or comments recently",
+			Schedule:    "0 9 * * 1",
+			Run: func(ctx context.Context) (string, error) {
+				sent, err := sender.SendStaleReminders(ctx, time.Now().In(cfg.SchedulerLocation).Add(-cfg.StaleCandidateAfter))
+				return fmt.Sprintf("Sent %d reminders", sent), err
+			},
+		},
+	}
+	for _, task := range tasks {
+		if err := sched.Add(task); err != nil {
+			// The schedules above are fixed, so this is a programming error
+			panic(err)
+		}
+	}
+	return sched
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/candidate-organizer/backend/internal/jobs"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/mailer"
+	"github.com/candidate-organizer/backend/internal/scheduler"
+	"github.com/candidate-organizer/backend/internal/tracing"
+)
+
+// newMailer returns the mailer that actually delivers email. It logs messages
+// when SMTP is not configured.
+func newMailer(cfg *config.Config) mailer.Mailer {
+	return mailer.New(mailer.Config{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	})
+}
+
+// newWorker returns a background job worker with a handler for every kind of job
+func newWorker(cfg *config.Config, queue *jobs.Queue, sched *scheduler.Scheduler) *jobs.Worker {
+	worker := jobs.NewWorker(queue, cfg.JobWorkerConcurrency)
+	jobs.RegisterEmail(worker, newMailer(cfg))
+	sched.Register(worker)
+	return worker
+}
+
+// runWorker runs background jobs without serving the API, until SIGTERM
+func runWorker(ctx context.Context, args []string) error {
+	if err := parse(newFlags("worker"), args); err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// The worker logs like the API server rather than to stderr
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+	logger, err := logging.New(os.Stdout, cfg.LogFormat, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TraceExporter, "candidate-organizer-worker")
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("Failed to flush traces", "error", err)
+		}
+	}()
+
+	keyring, err := newKeyring(cfg, db.DB)
+	if err != nil {
+		return err
+	}
+	queue := jobs.NewQueue(db.DB)
+	newWorker(cfg, queue, newScheduler(cfg, db.DB, queue, keyring)).Run(ctx)
+	return nil
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// serviceAccountEmailDomain is used for the placeholder email addresses of service
+// accounts. The .invalid TLD is reserved, so no identity provider can sign in as one.
+const serviceAccountEmailDomain = "service-accounts.invalid"
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// APIKeyHandler handles personal API keys and service accounts
+type APIKeyHandler struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+	roleRepo   repository.RoleRepository
+	auditRepo  repository.AuditRepository
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler(
+	apiKeyRepo repository.APIKeyRepository,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	auditRepo repository.AuditRepository,
+) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		roleRepo:   roleRepo,
+		auditRepo:  auditRepo,
+	}
+}
+
+// apiKeyRequest is the request body for creating API keys
+type apiKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`          // Permissions the key may use
+	ExpiresInDays int      `json:"expires_in_days"` // 0 for a key that does not expire
+}
+
+// ListMyKeys returns the current user's API keys
+func (h *APIKeyHandler) ListMyKeys(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	h.listKeys(w, r, currentUser.ID)
+}
+
+// CreateMyKey creates an API key for the current user. Its scopes must be
+// permissions the user currently has.
+func (h *APIKeyHandler) CreateMyKey(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	h.createKey(w, r, currentUser)
+}
+
+// RevokeMyKey revokes one of the current user's API keys
+func (h *APIKeyHandler) RevokeMyKey(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	h.revokeKey(w, r, currentUser.ID)
+}
+
+// ListServiceAccounts returns all service accounts that have not been deleted
+func (h *APIKeyHandler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	users, err := h.userRepo.List(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch service accounts", err))
+		return
+	}
+
+	accounts := []*models.User{}
+	for _, user := range users {
+		if user.ServiceAccount {
+			accounts = append(accounts, user)
+		}
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"service_accounts": accounts,
+	})
+}
+
+// CreateServiceAccount creates a service account with a role. Service accounts are
+// deactivated and deleted through the user endpoints like any other user.
+func (h *APIKeyHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	var req struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(req.Name), "-"), "-")
+	if slug == "" {
+		errors.WriteError(w, errors.NewValidationError("name", "must contain letters or digits"))
+		return
+	}
+
+	role, err := h.roleRepo.GetByName(r.Context(), req.Role)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch role", err))
+		return
+	}
+	if role == nil {
+		errors.WriteError(w, errors.NewValidationError("role", "unknown role"))
+		return
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to create service account", err))
+		return
+	}
+
+	account := &models.User{
+		Email:           slug + "-" + hex.EncodeToString(suffix) + "@" + serviceAccountEmailDomain,
+		Name:            req.Name,
+		Role:            role.Name,
+		WorkspaceDomain: serviceAccountEmailDomain,
+		ServiceAccount:  true,
+	}
+	if err := h.userRepo.Create(r.Context(), account); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to create service account", err))
+		return
+	}
+
+	created, err := h.userRepo.GetByID(r.Context(), account.ID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch service account", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "service_account.created", "user", created.ID, map[string]interface{}{
+		"name": created.Name,
+		"role": created.Role,
+	})
+	errors.WriteJSON(w, http.StatusCreated, created)
+}
+
+// ListServiceAccountKeys returns a service account's API keys
+func (h *APIKeyHandler) ListServiceAccountKeys(w http.ResponseWriter, r *http.Request) {
+	account, ok := h.fetchServiceAccount(w, r)
+	if !ok {
+		return
+	}
+	h.listKeys(w, r, account.ID)
+}
+
+// CreateServiceAccountKey creates an API key for a service account
+func (h *APIKeyHandler) CreateServiceAccountKey(w http.ResponseWriter, r *http.Request) {
+	account, ok := h.fetchServiceAccount(w, r)
+	if !ok {
+		return
+	}
+	h.createKey(w, r, account)
+}
+
+// RevokeServiceAccountKey revokes one of a service account's API keys
+func (h *APIKeyHandler) RevokeServiceAccountKey(w http.ResponseWriter, r *http.Request) {
+	account, ok := h.fetchServiceAccount(w, r)
+	if !ok {
+		return
+	}
+	h.revokeKey(w, r, account.ID)
+}
+
+func (h *APIKeyHandler) listKeys(w http.ResponseWriter, r *http.Request, userID string) {
+	keys, err := h.apiKeyRepo.ListByUser(r.Context(), userID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch API keys", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"api_keys": keys,
+	})
+}
+
+// createKey creates an API key owned by owner. The plaintext key is only returned here.
+func (h *APIKeyHandler) createKey(w http.ResponseWriter, r *http.Request, owner *models.User) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	// A key could otherwise mint a longer-lived or unexpiring copy of itself
+	if repository.APIKeyFromContext(r.Context()) != "" {
+		errors.WriteError(w, errors.NewForbiddenError("API keys cannot be used to create API keys"))
+		return
+	}
+
+	var req apiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		errors.WriteError(w, errors.NewValidationError("name", "is required"))
+		return
+	}
+	if len(req.Scopes) == 0 {
+		errors.WriteError(w, errors.NewValidationError("scopes", "at least one scope is required"))
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !auth.IsValidPermission(scope) {
+			errors.WriteError(w, errors.NewValidationError("scopes", "unknown permission: "+scope))
+			return
+		}
+		if !owner.HasPermission(scope) {
+			errors.WriteError(w, errors.NewValidationError("scopes", "the key's user does not have permission: "+scope))
+			return
+		}
+	}
+	if req.ExpiresInDays < 0 {
+		errors.WriteError(w, errors.NewValidationError("expires_in_days", "must not be negative"))
+		return
+	}
+
+	plaintext, keyHash, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to generate API key", err))
+		return
+	}
+
+	key := &models.APIKey{
+		UserID:    owner.ID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		Scopes:    req.Scopes,
+		CreatedBy: currentUser.ID,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := h.apiKeyRepo.Create(r.Context(), key, keyHash); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to create API key", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "api_key.created", "api_key", key.ID, map[string]interface{}{
+		"user_id": owner.ID,
+		"name":    key.Name,
+		"prefix":  key.Prefix,
+		"scopes":  key.Scopes,
+	})
+	errors.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"api_key": key,
+		"key":     plaintext, // Shown once; only its hash is stored
+	})
+}
+
+func (h *APIKeyHandler) revokeKey(w http.ResponseWriter, r *http.Request, userID string) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	key, err := h.apiKeyRepo.GetByID(r.Context(), chi.URLParam(r, "keyId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch API key", err))
+		return
+	}
+	if key == nil || key.UserID != userID {
+		errors.WriteError(w, errors.NewNotFoundError("API key"))
+		return
+	}
+
+	if err := h.apiKeyRepo.Revoke(r.Context(), key.ID); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to revoke API key", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "api_key.revoked", "api_key", key.ID, map[string]interface{}{
+		"user_id": key.UserID,
+		"name":    key.Name,
+		"prefix":  key.Prefix,
+	})
+	errors.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "API key revoked successfully",
+	})
+}
+
+// fetchServiceAccount loads the service account in the URL, writing a 404 or 500
+// response if it cannot
+func (h *APIKeyHandler) fetchServiceAccount(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	account, err := h.userRepo.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err == repository.ErrUserNotFound || (err == nil && (!account.ServiceAccount || account.DeletedAt != nil)) {
+		errors.WriteError(w, errors.NewNotFoundError("Service account"))
+		return nil, false
+	}
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch service account", err))
+		return nil, false
+	}
+	return account, true
+}
+
+// audit records an API key or service account change
+func (h *APIKeyHandler) audit(ctx context.Context, user *models.User, action, entityType, entityID string, details map[string]interface{}) {
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Details:    details,
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "entity_type", entityType, "entity_id", entityID, "error", err)
+	}
+}
@@ -2,31 +2,57 @@ package handlers
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/candidate-organizer/backend/internal/auth"
 	"github.com/candidate-organizer/backend/internal/config"
 	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
 	"github.com/candidate-organizer/backend/internal/models"
 	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/internal/tracing"
+	"github.com/go-chi/chi/v5"
+)
+
+// Cookie names and paths
+const (
+	loginFlowCookie   = "oauth_login"
+	refreshCookiePath = "/api/v1/auth"
 )
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
-	userRepo    repository.UserRepository
-	oauthConfig *auth.OAuthConfig
-	jwtManager  *auth.JWTManager
-	frontendURL string
+	userRepo         repository.UserRepository
+	invitationRepo   repository.InvitationRepository
+	groupMappingRepo repository.GroupMappingRepository
+	roleRepo         repository.RoleRepository
+	oauthConfig      *auth.OAuthConfig
+	sessions         *auth.SessionManager
+	frontendURL      string
+	defaultRole      string
+	inviteOnly       bool
+	cookieSecure     bool
+	cookieDomain     string
+
+	oidcProviders     map[string]*auth.OIDCProvider
+	oidcProviderNames []string // In configuration order
 }
 
 // NewAuthHandler creates a new AuthHandler
 func NewAuthHandler(
 	userRepo repository.UserRepository,
+	invitationRepo repository.InvitationRepository,
+	groupMappingRepo repository.GroupMappingRepository,
+	roleRepo repository.RoleRepository,
+	sessions *auth.SessionManager,
 	cfg *config.Config,
 ) *AuthHandler {
 	oauthConfig := auth.NewGoogleOAuthConfig(
@@ -34,77 +60,85 @@ func NewAuthHandler(
 		cfg.GoogleClientSecret,
 		cfg.GoogleRedirectURL,
 		cfg.WorkspaceDomain,
+		cfg.GoogleFetchGroups,
 	)
-
-	jwtManager := auth.NewJWTManager(cfg.JWTSecret, 24*time.Hour)
+	// Outbound calls to identity providers are traced
+	oauthConfig.HTTPClient = tracing.HTTPClient(10 * time.Second)
+
+	oidcProviders := make(map[string]*auth.OIDCProvider)
+	var oidcProviderNames []string
+	for _, providerConfig := range cfg.OIDCProviders {
+		oidcProviders[providerConfig.Name] = auth.NewOIDCProvider(providerConfig, tracing.HTTPClient(10*time.Second))
+		oidcProviderNames = append(oidcProviderNames, providerConfig.Name)
+	}
 
 	return &AuthHandler{
-		userRepo:    userRepo,
-		oauthConfig: oauthConfig,
-		jwtManager:  jwtManager,
-		frontendURL: cfg.FrontendURL,
+		userRepo:         userRepo,
+		invitationRepo:   invitationRepo,
+		groupMappingRepo: groupMappingRepo,
+		roleRepo:         roleRepo,
+		oauthConfig:      oauthConfig,
+		sessions:         sessions,
+		frontendURL:      cfg.FrontendURL,
+		defaultRole:      cfg.DefaultRole,
+		inviteOnly:       cfg.InviteOnly,
+		cookieSecure:     cfg.CookieSecure,
+		cookieDomain:     cfg.CookieDomain,
+
+		oidcProviders:     oidcProviders,
+		oidcProviderNames: oidcProviderNames,
 	}
 }
 
-// GoogleLogin initiates the Google OAuth flow
-func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
-	// Generate state token for CSRF protection
-	state, err := generateStateToken()
-	if err != nil {
-		errors.WriteError(w, errors.NewInternalServerError("Failed to generate state token", err))
-		return
+// ListProviders returns the sign-in providers that are configured, for login buttons
+func (h *AuthHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	type provider struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"display_name"`
+		LoginURL    string `json:"login_url"`
 	}
 
-	// Store state in cookie for validation in callback
-	http.SetCookie(w, &http.Cookie{
-		Name:     "oauth_state",
-		Value:    state,
-		Path:     "/",
-		MaxAge:   600, // 10 minutes
-		HttpOnly: true,
-		Secure:   false, // Set to true in production with HTTPS
-		SameSite: http.SameSiteLaxMode,
-	})
+	providers := []provider{}
+	if h.oauthConfig.Config.ClientID != "" {
+		providers = append(providers, provider{Name: "google", DisplayName: "Google", LoginURL: "/api/v1/auth/google"})
+	}
+	for _, name := range h.oidcProviderNames {
+		p := h.oidcProviders[name]
+		providers = append(providers, provider{
+			Name:        p.Name(),
+			DisplayName: p.DisplayName(),
+			LoginURL:    "/api/v1/auth/oidc/" + p.Name(),
+		})
+	}
 
-	// Redirect to Google OAuth
-	url := h.oauthConfig.GetAuthURL(state)
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"providers": providers,
+	})
 }
 
-// GoogleCallback handles the OAuth callback from Google
-func (h *AuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
-	// Verify state token
-	stateCookie, err := r.Cookie("oauth_state")
+// GoogleLogin initiates the Google OAuth flow. An optional return_to parameter
+// names the frontend page to continue at after signing in.
+func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
+	flow, err := h.startLogin(w, r)
 	if err != nil {
-		h.redirectToFrontendWithError(w, r, "Missing state cookie")
-		return
-	}
-
-	state := r.URL.Query().Get("state")
-	if state != stateCookie.Value {
-		h.redirectToFrontendWithError(w, r, "Invalid state token")
+		errors.WriteError(w, errors.NewInternalServerError("Failed to start login", err))
 		return
 	}
 
-	// Clear state cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "oauth_state",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-	})
+	// Redirect to Google OAuth
+	http.Redirect(w, r, h.oauthConfig.GetAuthURL(flow), http.StatusTemporaryRedirect)
+}
 
-	// Get authorization code
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		h.redirectToFrontendWithError(w, r, "Missing authorization code")
+// GoogleCallback handles the OAuth callback from Google
+func (h *AuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
+	code, flow, ok := h.verifyCallback(w, r)
+	if !ok {
 		return
 	}
 
 	// Exchange code for token
 	ctx := r.Context()
-	token, err := h.oauthConfig.ExchangeCode(ctx, code)
+	token, err := h.oauthConfig.ExchangeCode(ctx, code, flow)
 	if err != nil {
 		h.redirectToFrontendWithError(w, r, "Failed to exchange code for token")
 		return
@@ -117,40 +151,213 @@ func (h *AuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Accounts are linked by email, so it must be one Google has verified
+	if !userInfo.VerifiedEmail {
+		h.redirectToFrontendWithError(w, r, "Unauthorized: email address is not verified")
+		return
+	}
+
 	// Validate workspace domain
 	if err := h.oauthConfig.ValidateWorkspaceDomain(userInfo); err != nil {
 		h.redirectToFrontendWithError(w, r, "Unauthorized: "+err.Error())
 		return
 	}
 
+	identity := userInfo.Identity()
+	if h.oauthConfig.FetchGroups {
+		groups, err := h.oauthConfig.GetGroups(ctx, token, userInfo.Email)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to fetch Google groups", "error", err)
+			identity.GroupsUnknown = true
+		}
+		identity.Groups = groups
+	} else {
+		identity.GroupsUnknown = true
+	}
+
+	h.completeLogin(w, r, identity, flow)
+}
+
+// OIDCLogin initiates the login flow for a configured OpenID Connect provider
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oidcProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		errors.WriteError(w, errors.NewNotFoundError("Login provider"))
+		return
+	}
+
+	flow, err := h.startLogin(w, r)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to start login", err))
+		return
+	}
+
+	url, err := provider.GetAuthURL(r.Context(), flow)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("OIDC provider unavailable", "provider", provider.Name(), "error", err)
+		h.redirectToFrontendWithError(w, r, "Login provider is unavailable")
+		return
+	}
+	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+}
+
+// OIDCCallback handles the callback from an OpenID Connect provider
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oidcProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		errors.WriteError(w, errors.NewNotFoundError("Login provider"))
+		return
+	}
+
+	code, flow, ok := h.verifyCallback(w, r)
+	if !ok {
+		return
+	}
+
+	// Exchange the code and verify the ID token
+	identity, err := provider.Exchange(r.Context(), code, flow)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("OIDC login failed", "provider", provider.Name(), "error", err)
+		h.redirectToFrontendWithError(w, r, "Failed to verify identity")
+		return
+	}
+
+	// Validate allowed domains and groups
+	if err := provider.ValidateIdentity(identity); err != nil {
+		h.redirectToFrontendWithError(w, r, "Unauthorized: "+err.Error())
+		return
+	}
+
+	h.completeLogin(w, r, identity, flow)
+}
+
+// startLogin begins a login flow with fresh state, PKCE verifier and nonce, and
+// stores it in a short-lived cookie for the callback
+func (h *AuthHandler) startLogin(w http.ResponseWriter, r *http.Request) (*auth.LoginFlow, error) {
+	flow, err := auth.NewLoginFlow(h.safeReturnTo(r.URL.Query().Get("return_to")))
+	if err != nil {
+		return nil, err
+	}
+	value, err := flow.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	// Lax so that the cookie is sent on the provider's redirect back to us
+	h.setCookie(w, loginFlowCookie, value, "/", 600, http.SameSiteLaxMode)
+	return flow, nil
+}
+
+// verifyCallback checks the state token against the login flow cookie and returns
+// the authorization code and flow, redirecting to the frontend with an error if
+// either is missing or invalid
+func (h *AuthHandler) verifyCallback(w http.ResponseWriter, r *http.Request) (string, *auth.LoginFlow, bool) {
+	cookie, err := r.Cookie(loginFlowCookie)
+	if err != nil {
+		h.redirectToFrontendWithError(w, r, "Login session expired, please try again")
+		return "", nil, false
+	}
+	flow, err := auth.DecodeLoginFlow(cookie.Value)
+	if err != nil {
+		h.redirectToFrontendWithError(w, r, "Invalid login session")
+		return "", nil, false
+	}
+
+	// The flow is single use
+	h.setCookie(w, loginFlowCookie, "", "/", -1, http.SameSiteLaxMode)
+
+	// Verify state token
+	state := r.URL.Query().Get("state")
+	if subtle.ConstantTimeCompare([]byte(state), []byte(flow.State)) != 1 {
+		h.redirectToFrontendWithError(w, r, "Invalid state token")
+		return "", nil, false
+	}
+
+	// Providers report a refused consent or other failure in the error parameter
+	if providerErr := r.URL.Query().Get("error"); providerErr != "" {
+		h.redirectToFrontendWithError(w, r, "Login was not completed: "+providerErr)
+		return "", nil, false
+	}
+
+	// Get authorization code
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		h.redirectToFrontendWithError(w, r, "Missing authorization code")
+		return "", nil, false
+	}
+	return code, flow, true
+}
+
+// safeReturnTo validates a return_to parameter and returns the frontend path to
+// continue at, or "" if it is missing or points anywhere other than the frontend
+func (h *AuthHandler) safeReturnTo(raw string) string {
+	if raw == "" || len(raw) > 2048 || strings.ContainsAny(raw, "\\\r\n\t") {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.User != nil || u.Opaque != "" {
+		return ""
+	}
+
+	// Absolute URLs must be on the frontend's origin; relative ones must be absolute paths
+	if u.Scheme != "" || u.Host != "" {
+		frontend, err := url.Parse(h.frontendURL)
+		if err != nil || !strings.EqualFold(u.Scheme, frontend.Scheme) || !strings.EqualFold(u.Host, frontend.Host) {
+			return ""
+		}
+	} else if !strings.HasPrefix(raw, "/") {
+		return ""
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// "//host" would be read by browsers as another origin
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		return ""
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		path += "#" + u.EscapedFragment()
+	}
+	return path
+}
+
+// completeLogin signs in the user for a verified identity and redirects to the frontend
+func (h *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, identity *auth.Identity, flow *auth.LoginFlow) {
 	// Get or create user
-	user, err := h.getOrCreateUser(ctx, userInfo)
+	user, err := h.getOrCreateUser(r.Context(), identity)
+	if appErr, ok := err.(*errors.AppError); ok {
+		h.redirectToFrontendWithError(w, r, appErr.Message)
+		return
+	}
 	if err != nil {
 		h.redirectToFrontendWithError(w, r, "Failed to create user")
 		return
 	}
 
-	// Generate JWT token
-	jwtToken, err := h.jwtManager.GenerateToken(user)
+	// Re-evaluate group role mappings on every login. Failures keep the current role.
+	if err := h.syncGroupRole(r.Context(), user, identity); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to apply group role mappings", "target_user_id", user.ID, "error", err)
+	}
+
+	// Start a server-side session
+	tokens, err := h.sessions.Create(r.Context(), user, r.UserAgent(), clientIP(r))
 	if err != nil {
-		h.redirectToFrontendWithError(w, r, "Failed to generate token")
+		h.redirectToFrontendWithError(w, r, "Failed to create session")
 		return
 	}
+	h.setSessionCookies(w, tokens)
 
-	// Set JWT token in cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    jwtToken,
-		Path:     "/",
-		MaxAge:   86400, // 24 hours
-		HttpOnly: true,
-		Secure:   false, // Set to true in production with HTTPS
-		SameSite: http.SameSiteStrictMode,
-	})
-
-	// Redirect to frontend with success
-	redirectURL := fmt.Sprintf("%s/auth/callback?success=true", h.frontendURL)
-	http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
+	// Redirect to frontend with success, passing on where the user started
+	params := url.Values{"success": {"true"}}
+	if flow.ReturnTo != "" {
+		params.Set("return_to", flow.ReturnTo)
+	}
+	h.redirectToFrontend(w, r, params)
 }
 
 // GetProfile returns the current user's profile
@@ -165,33 +372,140 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	errors.WriteJSON(w, http.StatusOK, user)
 }
 
-// Logout logs out the user by clearing the auth token cookie
+// Logout revokes the current session and clears the session cookies
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// Clear auth token cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-	})
+	// API key callers have no session to end; keys are revoked separately
+	sessionID, _ := r.Context().Value("session_id").(string)
+	if sessionID == "" {
+		errors.WriteError(w, errors.NewBadRequestError("Logging out requires a session; revoke API keys instead"))
+		return
+	}
+
+	if err := h.sessions.Revoke(r.Context(), sessionID, repository.SessionRevokedLogout); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to revoke session", err))
+		return
+	}
 
+	h.clearSessionCookies(w)
 	errors.WriteJSON(w, http.StatusOK, map[string]string{
 		"message": "Logged out successfully",
 	})
 }
 
+// LogoutAll revokes every session of the current user ("sign out everywhere")
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	count, err := h.sessions.RevokeAllForUser(r.Context(), user.ID, repository.SessionRevokedLogoutAll)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to revoke sessions", err))
+		return
+	}
+
+	h.clearSessionCookies(w)
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "Signed out of all sessions",
+		"sessions": count,
+	})
+}
+
+// ListSessions returns the current user's active sessions
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+	sessionID, _ := r.Context().Value("session_id").(string)
+
+	sessions, err := h.sessions.ListActive(r.Context(), user.ID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch sessions", err))
+		return
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == sessionID
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession revokes one of the current user's sessions, e.g. a lost device
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	session, err := h.sessions.Get(r.Context(), chi.URLParam(r, "sessionId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch session", err))
+		return
+	}
+	if session == nil || session.UserID != user.ID {
+		errors.WriteError(w, errors.NewNotFoundError("Session"))
+		return
+	}
+
+	if err := h.sessions.Revoke(r.Context(), session.ID, repository.SessionRevokedLogout); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to revoke session", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Session revoked successfully",
+	})
+}
+
+// setSessionCookies stores the access and refresh tokens in HTTP-only cookies.
+// The refresh token is only sent to the auth endpoints.
+func (h *AuthHandler) setSessionCookies(w http.ResponseWriter, tokens *auth.TokenPair) {
+	h.setCookie(w, "auth_token", tokens.AccessToken, "/",
+		int(h.sessions.AccessTokenTTL().Seconds()), http.SameSiteStrictMode)
+	h.setCookie(w, "refresh_token", tokens.RefreshToken, refreshCookiePath,
+		int(time.Until(tokens.Session.ExpiresAt).Seconds()), http.SameSiteStrictMode)
+}
+
+func (h *AuthHandler) clearSessionCookies(w http.ResponseWriter) {
+	h.setCookie(w, "auth_token", "", "/", -1, http.SameSiteStrictMode)
+	h.setCookie(w, "refresh_token", "", refreshCookiePath, -1, http.SameSiteStrictMode)
+}
+
+// Sign-in refusals returned by getOrCreateUser
+var (
+	errAccountDeactivated = errors.NewForbiddenError("Account is deactivated")
+	errNotInvited         = errors.NewForbiddenError("An invitation is required to sign in")
+	errServiceAccount     = errors.NewForbiddenError("Service accounts cannot sign in")
+	errInvitationExpired  = errors.NewForbiddenError("Your invitation has expired or was revoked")
+	errIdentityConflict   = errors.NewForbiddenError("This account signs in with another identity provider")
+)
+
 // getOrCreateUser gets an existing user or creates a new one
-func (h *AuthHandler) getOrCreateUser(ctx context.Context, userInfo *auth.GoogleUserInfo) (*models.User, error) {
-	// Try to get existing user
-	user, err := h.userRepo.GetByEmail(ctx, userInfo.Email)
+func (h *AuthHandler) getOrCreateUser(ctx context.Context, identity *auth.Identity) (*models.User, error) {
+	// Users are matched on the provider's stable subject, not the asserted email
+	user, err := h.userRepo.GetByIdentity(ctx, identity.Provider, identity.Subject)
 	if err != nil {
 		return nil, err
 	}
+	if user != nil {
+		return h.existingUser(ctx, user, identity)
+	}
 
-	// If user exists, return it
+	// Fall back to the email address only to link the identity the first time
+	user, err = h.userRepo.GetByEmail(ctx, identity.Email)
+	if err != nil {
+		return nil, err
+	}
 	if user != nil {
-		return user, nil
+		// Check the account before linking, so refused sign-ins leave no link behind
+		if user.Status != repository.UserStatusInvited && !user.IsActive() {
+			return nil, errAccountDeactivated
+		}
+		if user.ServiceAccount {
+			return nil, errServiceAccount
+		}
+		if err := h.userRepo.LinkIdentity(ctx, user.ID, identity.Provider, identity.Subject); err != nil {
+			if err == repository.ErrIdentityConflict {
+				return nil, errIdentityConflict
+			}
+			return nil, err
+		}
+		return h.existingUser(ctx, user, identity)
 	}
 
 	// Check if this is the first user
@@ -200,28 +514,27 @@ func (h *AuthHandler) getOrCreateUser(ctx context.Context, userInfo *auth.Google
 		return nil, err
 	}
 
+	// In invite-only mode, unknown emails are refused even inside the workspace domain
+	if h.inviteOnly && !isFirst {
+		return nil, errNotInvited
+	}
+
 	// Create new user
-	role := "user"
+	role := h.defaultRole
 	if isFirst {
-		role = "admin" // First user becomes admin
+		role = auth.RoleAdmin // First user becomes admin
 	}
 
 	// Extract workspace domain from email or use HD field
-	workspaceDomain := userInfo.HD
+	workspaceDomain := identity.HostedDomain
 	if workspaceDomain == "" {
 		// Fallback to email domain
-		emailParts := []rune(userInfo.Email)
-		for i, c := range emailParts {
-			if c == '@' && i < len(emailParts)-1 {
-				workspaceDomain = string(emailParts[i+1:])
-				break
-			}
-		}
+		workspaceDomain = identity.EmailDomain()
 	}
 
 	newUser := &models.User{
-		Email:           userInfo.Email,
-		Name:            userInfo.Name,
+		Email:           identity.Email,
+		Name:            identity.Name,
 		Role:            role,
 		WorkspaceDomain: workspaceDomain,
 	}
@@ -229,45 +542,208 @@ func (h *AuthHandler) getOrCreateUser(ctx context.Context, userInfo *auth.Google
 	if err := h.userRepo.Create(ctx, newUser); err != nil {
 		return nil, err
 	}
+	if err := h.userRepo.LinkIdentity(ctx, newUser.ID, identity.Provider, identity.Subject); err != nil {
+		return nil, err
+	}
 
 	return newUser, nil
 }
 
+// existingUser returns a known user unless they have been deactivated or deleted,
+// accepting their invitation on the first sign-in
+func (h *AuthHandler) existingUser(ctx context.Context, user *models.User, identity *auth.Identity) (*models.User, error) {
+	if user.Status == repository.UserStatusInvited {
+		return h.acceptInvitation(ctx, user, identity)
+	}
+	if !user.IsActive() {
+		return nil, errAccountDeactivated
+	}
+	if user.ServiceAccount {
+		return nil, errServiceAccount
+	}
+	return user, nil
+}
+
+// acceptInvitation activates a pre-created user signing in for the first time
+func (h *AuthHandler) acceptInvitation(ctx context.Context, user *models.User, identity *auth.Identity) (*models.User, error) {
+	invitation, err := h.invitationRepo.GetPendingForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil {
+		return nil, errInvitationExpired
+	}
+
+	if err := h.invitationRepo.Accept(ctx, invitation.ID); err != nil {
+		return nil, err
+	}
+	user.Status = repository.UserStatusActive
+
+	// Admins may invite users by email alone
+	if user.Name == "" {
+		user.Name = identity.Name
+		if err := h.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// syncGroupRole assigns the role mapped from the identity's groups. Users who match no
+// mapping fall back to the default role if their role came from a group, so leaving
+// a group removes the access it granted; manually assigned roles are kept.
+func (h *AuthHandler) syncGroupRole(ctx context.Context, user *models.User, identity *auth.Identity) error {
+	if identity.GroupsUnknown {
+		return nil
+	}
+
+	mappings, err := h.groupMappingRepo.ListForProvider(ctx, identity.Provider)
+	if err != nil || len(mappings) == 0 {
+		return err
+	}
+
+	role, matched := auth.ResolveGroupRole(mappings, identity.Groups)
+	if !matched {
+		if user.RoleSource != "group" {
+			return nil
+		}
+		role = h.defaultRole
+	}
+	if role == user.Role && matched == (user.RoleSource == "group") {
+		return nil
+	}
+
+	newRole, err := h.roleRepo.GetByName(ctx, role)
+	if err != nil {
+		return err
+	}
+	if newRole == nil {
+		return fmt.Errorf("mapped role %s does not exist", role)
+	}
+
+	// Never remove the last active administrator through group membership
+	if user.HasPermission(auth.PermUsersManage) && !slices.Contains(newRole.Permissions, auth.PermUsersManage) {
+		count, err := h.userRepo.CountActiveWithPermission(ctx, auth.PermUsersManage)
+		if err != nil {
+			return err
+		}
+		if count <= 1 {
+			return fmt.Errorf("keeping role %s for the last active administrator", user.Role)
+		}
+	}
+
+	if matched {
+		err = h.userRepo.SetGroupRole(ctx, user.ID, newRole.Name)
+	} else {
+		err = h.userRepo.SetRole(ctx, user.ID, newRole.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	user.Role = newRole.Name
+	user.Permissions = newRole.Permissions
+	user.RoleSource = "manual"
+	if matched {
+		user.RoleSource = "group"
+	}
+	return nil
+}
+
 // redirectToFrontendWithError redirects to the frontend with an error message
 func (h *AuthHandler) redirectToFrontendWithError(w http.ResponseWriter, r *http.Request, errMsg string) {
-	redirectURL := fmt.Sprintf("%s/auth/callback?error=%s", h.frontendURL, errMsg)
-	http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
+	h.redirectToFrontend(w, r, url.Values{"error": {errMsg}})
+}
+
+// redirectToFrontend redirects to the frontend's auth callback page with the given parameters
+func (h *AuthHandler) redirectToFrontend(w http.ResponseWriter, r *http.Request, params url.Values) {
+	http.Redirect(w, r, h.frontendURL+"/auth/callback?"+params.Encode(), http.StatusTemporaryRedirect)
+}
+
+// setCookie sets an HTTP-only cookie with the configured Secure flag and domain.
+// A negative maxAge deletes the cookie.
+func (h *AuthHandler) setCookie(w http.ResponseWriter, name, value, path string, maxAge int, sameSite http.SameSite) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.cookieDomain,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.cookieSecure,
+		SameSite: sameSite,
+	})
 }
 
-// generateStateToken generates a random state token for CSRF protection
-func generateStateToken() (string, error) {
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+// clientIP returns the client's address without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
-	return base64.URLEncoding.EncodeToString(b), nil
+	return host
 }
 
 // RefreshToken refreshes the JWT token
 func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	// Get user from context (set by auth middleware)
-	user, ok := r.Context().Value("user").(*models.User)
-	if !ok {
-		errors.WriteError(w, errors.NewUnauthorizedError("User not found in context"))
+	// Browsers send the refresh token cookie; API clients may send it in the body
+	refreshToken, fromBody := "", false
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		refreshToken = cookie.Value
+	}
+	if refreshToken == "" {
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		refreshToken, fromBody = req.RefreshToken, true
+	}
+	if refreshToken == "" {
+		errors.WriteError(w, errors.NewUnauthorizedError("No refresh token provided"))
 		return
 	}
 
-	// Generate new JWT token
-	jwtToken, err := h.jwtManager.GenerateToken(user)
-	if err != nil {
-		errors.WriteError(w, errors.NewInternalServerError("Failed to generate token", err))
+	tokens, _, err := h.sessions.Refresh(r.Context(), refreshToken, h.loadActiveUser)
+	switch {
+	case err == repository.ErrRefreshTokenReused:
+		h.clearSessionCookies(w)
+		errors.WriteError(w, errors.NewUnauthorizedError("Refresh token was already used; the session has been revoked"))
+		return
+	case err == repository.ErrRefreshTokenInvalid || err == errAccountDeactivated:
+		h.clearSessionCookies(w)
+		errors.WriteError(w, errors.NewUnauthorizedError("Invalid or expired refresh token"))
+		return
+	case err != nil:
+		errors.WriteError(w, errors.NewInternalServerError("Failed to refresh session", err))
 		return
 	}
 
-	// Return token in response
-	errors.WriteJSON(w, http.StatusOK, map[string]string{
-		"token": jwtToken,
-	})
+	h.setSessionCookies(w, tokens)
+	response := map[string]interface{}{
+		"token":      tokens.AccessToken,
+		"expires_in": int(h.sessions.AccessTokenTTL().Seconds()),
+	}
+	// A token read from the HttpOnly cookie must not become readable by scripts
+	if fromBody {
+		response["refresh_token"] = tokens.RefreshToken
+	}
+	errors.WriteJSON(w, http.StatusOK, response)
+}
+
+// loadActiveUser loads a user for a session refresh, refusing inactive users
+func (h *AuthHandler) loadActiveUser(ctx context.Context, userID string) (*models.User, error) {
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err == repository.ErrUserNotFound {
+		return nil, errAccountDeactivated
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, errAccountDeactivated
+	}
+	return user, nil
 }
 
 // TokenResponse represents the response for token-related endpoints
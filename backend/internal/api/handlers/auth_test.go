@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// identityKey identifies a linked sign-in identity
+type identityKey struct{ provider, subject string }
+
+// fakeUserRepo keeps users and their linked identities in memory. Methods the
+// sign-in flow does not use panic through the embedded nil interface.
+type fakeUserRepo struct {
+	repository.UserRepository
+	users      map[string]*models.User // By ID
+	identities map[identityKey]string  // User IDs
+}
+
+func newFakeUserRepo(users ...*models.User) *fakeUserRepo {
+	repo := &fakeUserRepo{users: map[string]*models.User{}, identities: map[identityKey]string{}}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) GetByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	if id, ok := r.identities[identityKey{provider, subject}]; ok {
+		return r.users[id], nil
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) LinkIdentity(ctx context.Context, userID, provider, subject string) error {
+	if _, ok := r.identities[identityKey{provider, subject}]; ok {
+		return repository.ErrIdentityConflict
+	}
+	for _, id := range r.identities {
+		if id == userID {
+			return repository.ErrIdentityConflict
+		}
+	}
+	r.identities[identityKey{provider, subject}] = userID
+	return nil
+}
+
+func (r *fakeUserRepo) IsFirstUser(ctx context.Context) (bool, error) {
+	return len(r.users) == 0, nil
+}
+
+func (r *fakeUserRepo) Create(ctx context.Context, user *models.User) error {
+	user.ID = "new-user"
+	user.Status = repository.UserStatusActive
+	r.users[user.ID] = user
+	return nil
+}
+
+func TestGetOrCreateUserMatchesIdentity(t *testing.T) {
+	ada := &models.User{ID: "ada", Email: "ada@example.com", Status: repository.UserStatusActive}
+	repo := newFakeUserRepo(ada)
+	h := &AuthHandler{userRepo: repo, defaultRole: "viewer"}
+	ctx := context.Background()
+
+	// The first sign-in links the identity by email
+	user, err := h.getOrCreateUser(ctx, &auth.Identity{Provider: "google", Subject: "g-1", Email: "ada@example.com"})
+	if err != nil || user.ID != "ada" {
+		t.Fatalf("first sign-in = %v, %v", user, err)
+	}
+
+	// Later sign-ins match the subject, even after the email changed at the provider
+	user, err = h.getOrCreateUser(ctx, &auth.Identity{Provider: "google", Subject: "g-1", Email: "ada.lovelace@example.com"})
+	if err != nil || user.ID != "ada" {
+		t.Fatalf("second sign-in = %v, %v", user, err)
+	}
+
+	// Another provider asserting the same email cannot take over the account
+	_, err = h.getOrCreateUser(ctx, &auth.Identity{Provider: "okta", Subject: "o-1", Email: "ada@example.com"})
+	if err != errIdentityConflict {
+		t.Errorf("other provider error = %v, want errIdentityConflict", err)
+	}
+
+	// Nor can another subject at the same provider
+	_, err = h.getOrCreateUser(ctx, &auth.Identity{Provider: "google", Subject: "g-2", Email: "ada@example.com"})
+	if err != errIdentityConflict {
+		t.Errorf("other subject error = %v, want errIdentityConflict", err)
+	}
+}
+
+func TestGetOrCreateUserLinksNewUsers(t *testing.T) {
+	repo := newFakeUserRepo()
+	h := &AuthHandler{userRepo: repo, defaultRole: "viewer"}
+
+	user, err := h.getOrCreateUser(context.Background(), &auth.Identity{Provider: "okta", Subject: "o-1", Email: "ada@example.com", Name: "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != auth.RoleAdmin {
+		t.Errorf("first user role = %q, want admin", user.Role)
+	}
+	if repo.identities[identityKey{"okta", "o-1"}] != user.ID {
+		t.Error("identity was not linked to the new user")
+	}
+}
+
+func TestGetOrCreateUserRefusesWithoutLinking(t *testing.T) {
+	tests := []struct {
+		name string
+		user *models.User
+		want error
+	}{
+		{"deactivated", &models.User{ID: "u", Email: "u@example.com", Status: repository.UserStatusDeactivated}, errAccountDeactivated},
+		{"service account", &models.User{ID: "u", Email: "u@example.com", Status: repository.UserStatusActive, ServiceAccount: true}, errServiceAccount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeUserRepo(tt.user)
+			h := &AuthHandler{userRepo: repo}
+
+			_, err := h.getOrCreateUser(context.Background(), &auth.Identity{Provider: "okta", Subject: "o-1", Email: "u@example.com"})
+			if err != tt.want {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			if len(repo.identities) != 0 {
+				t.Error("a refused sign-in linked the identity")
+			}
+		})
+	}
+}
+
+func TestLogoutWithoutSession(t *testing.T) {
+	h := &AuthHandler{}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "user", &models.User{ID: "api-key-user"}))
+	rec := httptest.NewRecorder()
+
+	// Would panic on the nil session manager if it tried to revoke
+	h.Logout(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+// fakeSigningKeyRepo stores signing keys in memory; every key is active at once
+type fakeSigningKeyRepo struct {
+	keys []*models.SigningKey
+}
+
+func (r *fakeSigningKeyRepo) ListValid(ctx context.Context) ([]*models.SigningKey, error) {
+	return r.keys, nil
+}
+
+func (r *fakeSigningKeyRepo) RotateIfDue(ctx context.Context, key *models.SigningKey, rotateAfter, publishLead, grace time.Duration) (bool, error) {
+	if len(r.keys) > 0 {
+		return false, nil
+	}
+	key.ActivatesAt, key.CreatedAt = time.Now(), time.Now()
+	r.keys = append(r.keys, key)
+	return true, nil
+}
+
+func (r *fakeSigningKeyRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// fakeSessionRepo keeps sessions in memory and accepts each refresh token once
+type fakeSessionRepo struct {
+	repository.SessionRepository
+	session *models.Session
+	tokens  map[string]bool // Hashes of unused refresh tokens
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, session *models.Session, refreshTokenHash string) error {
+	session.ID = "s-1"
+	r.session = session
+	r.tokens = map[string]bool{refreshTokenHash: true}
+	return nil
+}
+
+func (r *fakeSessionRepo) Rotate(ctx context.Context, refreshTokenHash, newRefreshTokenHash string) (*models.Session, error) {
+	if !r.tokens[refreshTokenHash] {
+		return nil, repository.ErrRefreshTokenInvalid
+	}
+	delete(r.tokens, refreshTokenHash)
+	r.tokens[newRefreshTokenHash] = true
+	return r.session, nil
+}
+
+func newTestSessions(t *testing.T) *auth.SessionManager {
+	t.Helper()
+	keys, err := auth.NewKeyManager(&fakeSigningKeyRepo{}, auth.SigningAlgES256, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return auth.NewSessionManager(auth.NewJWTManager(keys, 15*time.Minute), &fakeSessionRepo{}, time.Hour)
+}
+
+func TestRefreshTokenReturnedAsSent(t *testing.T) {
+	tests := []struct {
+		name     string
+		send     func(req *http.Request, token string)
+		wantBody bool
+	}{
+		{"cookie", func(req *http.Request, token string) {
+			req.AddCookie(&http.Cookie{Name: "refresh_token", Value: token})
+		}, false},
+		{"body", func(req *http.Request, token string) {
+			req.Body = io.NopCloser(strings.NewReader(`{"refresh_token": "` + token + `"}`))
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{ID: "u-1", Status: repository.UserStatusActive}
+			sessions := newTestSessions(t)
+			h := &AuthHandler{userRepo: newFakeUserRepo(user), sessions: sessions}
+			pair, err := sessions.Create(context.Background(), user, "test", "127.0.0.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+			tt.send(req, pair.RefreshToken)
+			rec := httptest.NewRecorder()
+			h.RefreshToken(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+
+			var body map[string]interface{}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body["token"] == "" {
+				t.Error("no access token in the response")
+			}
+			if _, ok := body["refresh_token"]; ok != tt.wantBody {
+				t.Errorf("refresh_token in body = %v, want %v", ok, tt.wantBody)
+			}
+			cookie := ""
+			for _, c := range rec.Result().Cookies() {
+				if c.Name == "refresh_token" {
+					cookie = c.Value
+				}
+			}
+			if cookie == "" || cookie == pair.RefreshToken {
+				t.Errorf("refresh_token cookie = %q, want the rotated token", cookie)
+			}
+		})
+	}
+}
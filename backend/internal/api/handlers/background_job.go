@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/jobs"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// BackgroundJobHandler lets admins inspect the background job queue and retry
+// dead-lettered jobs
+type BackgroundJobHandler struct {
+	queue     *jobs.Queue
+	auditRepo repository.AuditRepository
+}
+
+// NewBackgroundJobHandler creates a new BackgroundJobHandler
+func NewBackgroundJobHandler(queue *jobs.Queue, auditRepo repository.AuditRepository) *BackgroundJobHandler {
+	return &BackgroundJobHandler{
+		queue:     queue,
+		auditRepo: auditRepo,
+	}
+}
+
+// ListJobs returns jobs, newest first, filtered by the status and kind query
+// parameters, along with the number of jobs in each status
+func (h *BackgroundJobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := query.Get("status")
+	switch status {
+	case "", jobs.StatusQueued, jobs.StatusRunning, jobs.StatusSucceeded, jobs.StatusDead:
+	default:
+		errors.WriteError(w, errors.NewValidationError("status", "Status must be queued, running, succeeded or dead"))
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	offset := 0
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	list, err := h.queue.List(r.Context(), status, query.Get("kind"), limit, offset)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch background jobs", err))
+		return
+	}
+	counts, err := h.queue.CountByStatus(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to count background jobs", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs":   list,
+		"counts": counts,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetJob returns a job, including its payload and last error
+func (h *BackgroundJobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.queue.Get(r.Context(), chi.URLParam(r, "jobId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch background job", err))
+		return
+	}
+	if job == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Background job"))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, job)
+}
+
+// RetryJob queues a dead-lettered job to run again with a fresh set of attempts
+func (h *BackgroundJobHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	jobID := chi.URLParam(r, "jobId")
+
+	job, err := h.queue.Retry(r.Context(), jobID)
+	if err == jobs.ErrDuplicate {
+		errors.WriteError(w, errors.NewConflictError("A job with the same unique key is already queued or running"))
+		return
+	}
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to retry background job", err))
+		return
+	}
+	if job == nil {
+		existing, err := h.queue.Get(r.Context(), jobID)
+		if err != nil {
+			errors.WriteError(w, errors.NewInternalServerError("Failed to fetch background job", err))
+			return
+		}
+		if existing == nil {
+			errors.WriteError(w, errors.NewNotFoundError("Background job"))
+			return
+		}
+		errors.WriteError(w, errors.NewConflictError("Only dead jobs can be retried; this job is "+existing.Status))
+		return
+	}
+
+	event := &models.AuditEvent{
+		ActorID:    currentUser.ID,
+		Action:     "background_job.retried",
+		EntityType: "background_job",
+		EntityID:   job.ID,
+		Details:    map[string]interface{}{"kind": job.Kind},
+	}
+	if err := h.auditRepo.Record(r.Context(), event); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to record audit event", "action", event.Action, "job_id", job.ID, "error", err)
+	}
+
+	errors.WriteJSON(w, http.StatusOK, job)
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/mergepatch"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/privacy"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// Candidate fields a merge patch may change. The resume is replaced by
+// uploading a new one.
+var (
+	candidatePatchFields = []string{"name", "email", "phone", "parsed_data", "status", "salary_expectation", "job_posting_id"}
+	attributePatchFields = []string{"attribute_key", "attribute_value"}
+)
+
+// CandidateHandler applies partial updates to candidates and their attributes
+type CandidateHandler struct {
+	candidateRepo repository.CandidateRepository
+	attributeRepo repository.AttributeRepository
+	jobRepo       repository.JobRepository
+	auditRepo     repository.AuditRepository
+}
+
+// NewCandidateHandler creates a new CandidateHandler
+func NewCandidateHandler(candidateRepo repository.CandidateRepository, attributeRepo repository.AttributeRepository, jobRepo repository.JobRepository, auditRepo repository.AuditRepository) *CandidateHandler {
+	return &CandidateHandler{
+		candidateRepo: candidateRepo,
+		attributeRepo: attributeRepo,
+		jobRepo:       jobRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// PatchCandidate applies a JSON merge patch to a candidate if it is still at the
+// version in If-Match. Only users with salary.read may change the salary
+// expectation.
+func (h *CandidateHandler) PatchCandidate(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	version, err := IfMatchVersion(r)
+	if err != nil {
+		errors.WriteError(w, err)
+		return
+	}
+	patch, err := mergepatch.Read(r)
+	if err != nil {
+		errors.WriteError(w, PatchError(err))
+		return
+	}
+	canSeeSalary := currentUser.HasPermission(auth.PermSalaryRead)
+	if patch.Has("salary_expectation") && !canSeeSalary {
+		errors.WriteError(w, errors.NewForbiddenError("Only users with salary.read can change salary_expectation"))
+		return
+	}
+
+	candidate, ok := h.getCandidate(w, r)
+	if !ok {
+		return
+	}
+	if candidate.ErasedAt != nil {
+		errors.WriteError(w, errors.NewConflictError("The candidate's personal data has been erased"))
+		return
+	}
+	if candidate.Version != version {
+		WriteVersionConflict(w, candidate.Version)
+		return
+	}
+
+	changed, err := mergepatch.Merge(candidate, patch, candidatePatchFields...)
+	if err != nil {
+		errors.WriteError(w, PatchError(err))
+		return
+	}
+	if err := h.validateCandidate(r.Context(), candidate, changed); err != nil {
+		errors.WriteError(w, err)
+		return
+	}
+
+	if len(changed) > 0 {
+		if err := h.candidateRepo.UpdateIfVersion(r.Context(), candidate, version); err != nil {
+			switch err {
+			case repository.ErrVersionConflict:
+				current := 0
+				if latest, _ := h.candidateRepo.GetByID(r.Context(), candidate.ID); latest != nil {
+					current = latest.Version
+				}
+				WriteVersionConflict(w, current)
+			case repository.ErrAccessDenied:
+				errors.WriteError(w, errors.NewForbiddenError("Only the job's owners and recruiters can edit its candidates"))
+			default:
+				errors.WriteError(w, errors.NewInternalServerError("Failed to update candidate", err))
+			}
+			return
+		}
+		// Only the names of the changed fields are recorded, as their values are personal data
+		h.audit(r.Context(), currentUser, "candidate.updated", candidate.ID, map[string]interface{}{
+			"fields": changed,
+		})
+	}
+
+	if !canSeeSalary {
+		candidate.SalaryExpectation = ""
+	}
+	w.Header().Set("ETag", ETag(candidate.Version))
+	errors.WriteJSON(w, http.StatusOK, candidate)
+}
+
+// PatchAttribute applies a JSON merge patch to a candidate attribute if it is
+// still at the version in If-Match
+func (h *CandidateHandler) PatchAttribute(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	version, err := IfMatchVersion(r)
+	if err != nil {
+		errors.WriteError(w, err)
+		return
+	}
+	patch, err := mergepatch.Read(r)
+	if err != nil {
+		errors.WriteError(w, PatchError(err))
+		return
+	}
+
+	candidate, ok := h.getCandidate(w, r)
+	if !ok {
+		return
+	}
+	attribute, err := h.attributeRepo.GetByID(r.Context(), chi.URLParam(r, "attrId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch attribute", err))
+		return
+	}
+	if attribute == nil || attribute.CandidateID != candidate.ID {
+		errors.WriteError(w, errors.NewNotFoundError("Attribute"))
+		return
+	}
+	if attribute.Version != version {
+		WriteVersionConflict(w, attribute.Version)
+		return
+	}
+
+	changed, err := mergepatch.Merge(attribute, patch, attributePatchFields...)
+	if err != nil {
+		errors.WriteError(w, PatchError(err))
+		return
+	}
+	attribute.AttributeKey = strings.TrimSpace(attribute.AttributeKey)
+	if slices.Contains(changed, "attribute_key") && attribute.AttributeKey == "" {
+		errors.WriteError(w, errors.NewValidationError("attribute_key", "is required"))
+		return
+	}
+
+	if len(changed) > 0 {
+		if err := h.attributeRepo.UpdateIfVersion(r.Context(), attribute, version); err != nil {
+			switch {
+			case err == repository.ErrVersionConflict:
+				current := 0
+				if latest, _ := h.attributeRepo.GetByID(r.Context(), attribute.ID); latest != nil {
+					current = latest.Version
+				}
+				WriteVersionConflict(w, current)
+			case err == repository.ErrAccessDenied:
+				errors.WriteError(w, errors.NewForbiddenError("Only the job's owners and recruiters can edit its candidates"))
+			case isUniqueViolation(err):
+				errors.WriteError(w, errors.NewConflictError("The candidate already has an attribute named "+attribute.AttributeKey))
+			default:
+				errors.WriteError(w, errors.NewInternalServerError("Failed to update attribute", err))
+			}
+			return
+		}
+		h.audit(r.Context(), currentUser, "candidate.attribute_updated", candidate.ID, map[string]interface{}{
+			"attribute_id": attribute.ID,
+			"fields":       changed,
+		})
+	}
+
+	w.Header().Set("ETag", ETag(attribute.Version))
+	errors.WriteJSON(w, http.StatusOK, attribute)
+}
+
+// validateCandidate checks the fields a patch changed
+func (h *CandidateHandler) validateCandidate(ctx context.Context, candidate *models.Candidate, changed []string) error {
+	for _, field := range changed {
+		switch field {
+		case "name":
+			candidate.Name = strings.TrimSpace(candidate.Name)
+			if candidate.Name == "" {
+				return errors.NewValidationError("name", "is required")
+			}
+		case "email":
+			if candidate.Email == "" {
+				continue
+			}
+			address, err := mail.ParseAddress(candidate.Email)
+			if err != nil || address.Address != strings.TrimSpace(candidate.Email) {
+				return errors.NewValidationError("email", "must be a valid email address")
+			}
+			candidate.Email = address.Address
+		case "status":
+			if !slices.Contains(privacy.CandidateStatuses, candidate.Status) {
+				return errors.NewValidationError("status", "must be one of "+strings.Join(privacy.CandidateStatuses, ", "))
+			}
+		case "job_posting_id":
+			if candidate.JobPostingID == "" {
+				continue
+			}
+			job, err := h.jobRepo.GetByID(ctx, candidate.JobPostingID)
+			if err != nil {
+				return errors.NewInternalServerError("Failed to fetch job posting", err)
+			}
+			if job == nil {
+				return errors.NewValidationError("job_posting_id", "must be a job posting you can see")
+			}
+		}
+	}
+	return nil
+}
+
+// getCandidate fetches the candidate named in the URL, if the user may see it,
+// writing an error response and returning false otherwise
+func (h *CandidateHandler) getCandidate(w http.ResponseWriter, r *http.Request) (*models.Candidate, bool) {
+	candidate, err := h.candidateRepo.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch candidate", err))
+		return nil, false
+	}
+	if candidate == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Candidate"))
+		return nil, false
+	}
+	return candidate, true
+}
+
+// audit records a change to a candidate
+func (h *CandidateHandler) audit(ctx context.Context, user *models.User, action, candidateID string, details map[string]interface{}) {
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: "candidate",
+		EntityID:   candidateID,
+		Details:    details,
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "candidate_id", candidateID, "error", err)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// fakeCandidateRepo holds one candidate. Methods the patch handler does not use
+// panic through the embedded nil interface.
+type fakeCandidateRepo struct {
+	repository.CandidateRepository
+	candidate models.Candidate
+}
+
+func (r *fakeCandidateRepo) GetByID(ctx context.Context, id string) (*models.Candidate, error) {
+	if id != r.candidate.ID {
+		return nil, nil
+	}
+	candidate := r.candidate
+	return &candidate, nil
+}
+
+func (r *fakeCandidateRepo) UpdateIfVersion(ctx context.Context, candidate *models.Candidate, version int) error {
+	if version != r.candidate.Version {
+		return repository.ErrVersionConflict
+	}
+	candidate.Version++
+	r.candidate = *candidate
+	return nil
+}
+
+// fakeAuditRepo records audit events in memory
+type fakeAuditRepo struct {
+	repository.AuditRepository
+	events []*models.AuditEvent
+}
+
+func (r *fakeAuditRepo) Record(ctx context.Context, event *models.AuditEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+// patchCandidate sends a merge patch for the candidate at version 1 as a user
+// with permissions
+func patchCandidate(h *CandidateHandler, body string, permissions ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/candidates/c-1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", "c-1")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+	ctx = context.WithValue(ctx, "user", &models.User{ID: "u-1", Permissions: permissions})
+	rec := httptest.NewRecorder()
+	h.PatchCandidate(rec, req.WithContext(ctx))
+	return rec
+}
+
+func newPatchTestHandler() (*CandidateHandler, *fakeCandidateRepo, *fakeAuditRepo) {
+	candidates := &fakeCandidateRepo{candidate: models.Candidate{
+		ID: "c-1", Name: "Ada", Status: "applied", SalaryExpectation: "100000", Version: 1,
+	}}
+	audits := &fakeAuditRepo{}
+	return &CandidateHandler{candidateRepo: candidates, auditRepo: audits}, candidates, audits
+}
+
+func TestPatchCandidateSalaryNeedsSalaryRead(t *testing.T) {
+	for _, body := range []string{`{"salary_expectation": "1"}`, `{"salary_expectation": null}`} {
+		h, candidates, audits := newPatchTestHandler()
+
+		rec := patchCandidate(h, body, auth.PermCandidatesRead, auth.PermCandidatesWrite)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want %d", body, rec.Code, http.StatusForbidden)
+		}
+		if candidates.candidate.SalaryExpectation != "100000" || candidates.candidate.Version != 1 || len(audits.events) != 0 {
+			t.Errorf("%s: candidate changed to %+v", body, candidates.candidate)
+		}
+	}
+}
+
+func TestPatchCandidateWithoutSalaryRead(t *testing.T) {
+	h, candidates, audits := newPatchTestHandler()
+
+	rec := patchCandidate(h, `{"name": "Ada Lovelace"}`, auth.PermCandidatesWrite)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if candidates.candidate.Name != "Ada Lovelace" || candidates.candidate.SalaryExpectation != "100000" {
+		t.Errorf("stored candidate = %+v", candidates.candidate)
+	}
+	var got models.Candidate
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SalaryExpectation != "" {
+		t.Errorf("response shows salary %q to a user without salary.read", got.SalaryExpectation)
+	}
+	if rec.Header().Get("ETag") != `"2"` {
+		t.Errorf("ETag = %s, want \"2\"", rec.Header().Get("ETag"))
+	}
+	if len(audits.events) != 1 || audits.events[0].Action != "candidate.updated" {
+		t.Errorf("audit events = %+v", audits.events)
+	}
+}
+
+func TestPatchCandidateSalary(t *testing.T) {
+	h, candidates, _ := newPatchTestHandler()
+
+	rec := patchCandidate(h, `{"salary_expectation": "120000"}`, auth.PermCandidatesWrite, auth.PermSalaryRead)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if candidates.candidate.SalaryExpectation != "120000" {
+		t.Errorf("salary = %q, want 120000", candidates.candidate.SalaryExpectation)
+	}
+}
+
+func TestPatchCandidateStaleVersion(t *testing.T) {
+	h, candidates, _ := newPatchTestHandler()
+	candidates.candidate.Version = 2
+
+	rec := patchCandidate(h, `{"name": "Ada Lovelace"}`, auth.PermCandidatesWrite)
+	if rec.Code != http.StatusPreconditionFailed || rec.Header().Get("ETag") != `"2"` {
+		t.Errorf("status = %d, ETag = %s; want 412 with \"2\"", rec.Code, rec.Header().Get("ETag"))
+	}
+	if candidates.candidate.Name != "Ada" {
+		t.Error("a stale patch was applied")
+	}
+}
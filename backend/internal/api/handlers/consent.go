@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// ConsentHandler records candidates' consent to be kept in the talent pool,
+// which exempts them from retention policies until it expires
+type ConsentHandler struct {
+	consentRepo   repository.ConsentRepository
+	candidateRepo repository.CandidateRepository
+	auditRepo     repository.AuditRepository
+}
+
+// NewConsentHandler creates a new ConsentHandler
+func NewConsentHandler(consentRepo repository.ConsentRepository, candidateRepo repository.CandidateRepository, auditRepo repository.AuditRepository) *ConsentHandler {
+	return &ConsentHandler{
+		consentRepo:   consentRepo,
+		candidateRepo: candidateRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// consentRequest is the request body for recording a consent
+type consentRequest struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Note      string    `json:"note"` // How the candidate agreed
+}
+
+// ListConsents returns a candidate's consents, newest first
+func (h *ConsentHandler) ListConsents(w http.ResponseWriter, r *http.Request) {
+	candidate, ok := h.getCandidate(w, r)
+	if !ok {
+		return
+	}
+
+	consents, err := h.consentRepo.ListByCandidate(r.Context(), candidate.ID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch consents", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{"consents": consents})
+}
+
+// RecordConsent records that a candidate agreed to be kept until a date. A new
+// consent extends an earlier one.
+func (h *ConsentHandler) RecordConsent(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	candidate, ok := h.getCandidate(w, r)
+	if !ok {
+		return
+	}
+	if candidate.ErasedAt != nil {
+		errors.WriteError(w, errors.NewConflictError("The candidate's personal data has been erased"))
+		return
+	}
+
+	var req consentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+	if !req.ExpiresAt.After(time.Now()) {
+		errors.WriteError(w, errors.NewValidationError("expires_at", "must be in the future"))
+		return
+	}
+
+	consent := &models.CandidateConsent{
+		CandidateID: candidate.ID,
+		ExpiresAt:   req.ExpiresAt,
+		Note:        strings.TrimSpace(req.Note),
+		RecordedBy:  currentUser.ID,
+	}
+	if err := h.consentRepo.Create(r.Context(), consent); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to record consent", err))
+		return
+	}
+	consent.RecordedByName = currentUser.Name
+
+	h.audit(r.Context(), currentUser, "candidate.consent_recorded", consent)
+	errors.WriteJSON(w, http.StatusCreated, consent)
+}
+
+// RevokeConsent withdraws a consent, for example when the candidate asks to be
+// removed from the talent pool
+func (h *ConsentHandler) RevokeConsent(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	candidate, ok := h.getCandidate(w, r)
+	if !ok {
+		return
+	}
+
+	consent, err := h.consentRepo.Revoke(r.Context(), candidate.ID, chi.URLParam(r, "consentId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to revoke consent", err))
+		return
+	}
+	if consent == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Consent"))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "candidate.consent_revoked", consent)
+	errors.WriteJSON(w, http.StatusOK, consent)
+}
+
+// getCandidate fetches the candidate named in the URL, if the user may see it,
+// writing an error response and returning false otherwise
+func (h *ConsentHandler) getCandidate(w http.ResponseWriter, r *http.Request) (*models.Candidate, bool) {
+	candidate, err := h.candidateRepo.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch candidate", err))
+		return nil, false
+	}
+	if candidate == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Candidate"))
+		return nil, false
+	}
+	return candidate, true
+}
+
+// audit records a consent change against the candidate
+func (h *ConsentHandler) audit(ctx context.Context, user *models.User, action string, consent *models.CandidateConsent) {
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: "candidate",
+		EntityID:   consent.CandidateID,
+		Details: map[string]interface{}{
+			"consent_id": consent.ID,
+			"expires_at": consent.ExpiresAt,
+		},
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "candidate_id", consent.CandidateID, "error", err)
+	}
+}
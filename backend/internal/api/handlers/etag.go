@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/mergepatch"
+)
+
+// ConflictMessage explains a 412 response to a write on a stale version
+const ConflictMessage = "The record was changed by someone else; reload it and try again"
+
+// ETag returns the strong entity tag of a record at version
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// IfMatchVersion returns the record version the client read, from the If-Match
+// header. Writes require it, so that they cannot overwrite changes the client
+// has not seen: it returns a 428 error without the header, and a 412 error for
+// a tag that names no version.
+func IfMatchVersion(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errors.NewPreconditionRequiredError("If-Match header is required; send the ETag from the last read")
+	}
+	// Versions change with every write, so weak and strong tags compare the same
+	tag := strings.TrimPrefix(header, "W/")
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(tag, `"`), `"`))
+	if err != nil || version < 1 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, errors.NewPreconditionFailedError("If-Match must be the ETag from the last read")
+	}
+	return version, nil
+}
+
+// WriteVersionConflict tells the client that the record changed since it read
+// it, with the ETag of the current version unless current is 0
+func WriteVersionConflict(w http.ResponseWriter, current int) {
+	if current > 0 {
+		w.Header().Set("ETag", ETag(current))
+	}
+	errors.WriteError(w, errors.NewPreconditionFailedError(ConflictMessage))
+}
+
+// PatchError converts an error reading or applying a merge patch to a response error
+func PatchError(err error) error {
+	if fieldErr, ok := err.(*mergepatch.FieldError); ok {
+		return errors.NewValidationError(fieldErr.Field, fieldErr.Reason)
+	}
+	switch err {
+	case mergepatch.ErrUnsupportedMediaType:
+		return errors.NewUnsupportedMediaTypeError(err.Error())
+	case mergepatch.ErrNotObject:
+		return errors.NewBadRequestError("Invalid request body: " + err.Error())
+	}
+	return errors.NewBadRequestErrorWithCause("Invalid request body", err)
+}
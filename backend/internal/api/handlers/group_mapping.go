@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+// GroupMappingHandler handles administration of identity provider group to role mappings
+type GroupMappingHandler struct {
+	mappingRepo repository.GroupMappingRepository
+	roleRepo    repository.RoleRepository
+	auditRepo   repository.AuditRepository
+	providers   []string // Names of the configured identity providers
+}
+
+// NewGroupMappingHandler creates a new GroupMappingHandler
+func NewGroupMappingHandler(
+	mappingRepo repository.GroupMappingRepository,
+	roleRepo repository.RoleRepository,
+	auditRepo repository.AuditRepository,
+	providers []string,
+) *GroupMappingHandler {
+	return &GroupMappingHandler{
+		mappingRepo: mappingRepo,
+		roleRepo:    roleRepo,
+		auditRepo:   auditRepo,
+		providers:   providers,
+	}
+}
+
+// groupMappingRequest is the request body for creating and updating group mappings
+type groupMappingRequest struct {
+	Provider  string `json:"provider"` // Defaults to "*", any provider
+	GroupName string `json:"group_name"`
+	Role      string `json:"role"`
+	Priority  int    `json:"priority"`
+}
+
+// ListMappings returns all group mappings, highest priority first
+func (h *GroupMappingHandler) ListMappings(w http.ResponseWriter, r *http.Request) {
+	mappings, err := h.mappingRepo.List(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch group mappings", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"mappings":  mappings,
+		"providers": append([]string{repository.AnyProvider}, h.providers...),
+	})
+}
+
+// CreateMapping creates a group to role mapping
+func (h *GroupMappingHandler) CreateMapping(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	mapping := &models.GroupRoleMapping{CreatedBy: currentUser.ID}
+	if !h.decodeMapping(w, r, mapping) {
+		return
+	}
+
+	if err := h.mappingRepo.Create(r.Context(), mapping); err != nil {
+		if isUniqueViolation(err) {
+			errors.WriteError(w, errors.NewConflictError("That group is already mapped for this provider"))
+			return
+		}
+		errors.WriteError(w, errors.NewInternalServerError("Failed to create group mapping", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "group_mapping.created", mapping)
+	errors.WriteJSON(w, http.StatusCreated, mapping)
+}
+
+// UpdateMapping changes a group mapping's group, provider, role or priority
+func (h *GroupMappingHandler) UpdateMapping(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	mapping, err := h.mappingRepo.GetByID(r.Context(), chi.URLParam(r, "mappingId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch group mapping", err))
+		return
+	}
+	if mapping == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Group mapping"))
+		return
+	}
+
+	if !h.decodeMapping(w, r, mapping) {
+		return
+	}
+
+	if err := h.mappingRepo.Update(r.Context(), mapping); err != nil {
+		if isUniqueViolation(err) {
+			errors.WriteError(w, errors.NewConflictError("That group is already mapped for this provider"))
+			return
+		}
+		errors.WriteError(w, errors.NewInternalServerError("Failed to update group mapping", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "group_mapping.updated", mapping)
+	errors.WriteJSON(w, http.StatusOK, mapping)
+}
+
+// DeleteMapping removes a group mapping. Users keep their role until their next login.
+func (h *GroupMappingHandler) DeleteMapping(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	mapping, err := h.mappingRepo.GetByID(r.Context(), chi.URLParam(r, "mappingId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch group mapping", err))
+		return
+	}
+	if mapping == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Group mapping"))
+		return
+	}
+
+	if err := h.mappingRepo.Delete(r.Context(), mapping.ID); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to delete group mapping", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "group_mapping.deleted", mapping)
+	errors.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Group mapping deleted successfully",
+	})
+}
+
+// decodeMapping reads and validates the request body into the mapping,
+// writing an error response and returning false if it is invalid
+func (h *GroupMappingHandler) decodeMapping(w http.ResponseWriter, r *http.Request, mapping *models.GroupRoleMapping) bool {
+	var req groupMappingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return false
+	}
+
+	req.GroupName = strings.TrimSpace(req.GroupName)
+	if req.GroupName == "" {
+		errors.WriteError(w, errors.NewValidationError("group_name", "is required"))
+		return false
+	}
+	if req.Provider == "" {
+		req.Provider = repository.AnyProvider
+	}
+	if req.Provider != repository.AnyProvider && !slices.Contains(h.providers, req.Provider) {
+		errors.WriteError(w, errors.NewValidationError("provider", "is not a configured identity provider"))
+		return false
+	}
+
+	role, err := h.roleRepo.GetByName(r.Context(), req.Role)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch role", err))
+		return false
+	}
+	if role == nil {
+		errors.WriteError(w, errors.NewValidationError("role", "unknown role"))
+		return false
+	}
+
+	mapping.Provider = req.Provider
+	mapping.GroupName = req.GroupName
+	mapping.Role = role.Name
+	mapping.Priority = req.Priority
+	return true
+}
+
+// audit records a group mapping change
+func (h *GroupMappingHandler) audit(ctx context.Context, user *models.User, action string, mapping *models.GroupRoleMapping) {
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: "group_mapping",
+		EntityID:   mapping.ID,
+		Details: map[string]interface{}{
+			"provider":   mapping.Provider,
+			"group_name": mapping.GroupName,
+			"role":       mapping.Role,
+			"priority":   mapping.Priority,
+		},
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "group_mapping_id", mapping.ID, "error", err)
+	}
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign key violation
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23503"
+}
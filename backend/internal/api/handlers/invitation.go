@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/mailer"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// InvitationHandler handles pre-provisioning users and sending them invitations
+type InvitationHandler struct {
+	invitationRepo  repository.InvitationRepository
+	userRepo        repository.UserRepository
+	roleRepo        repository.RoleRepository
+	auditRepo       repository.AuditRepository
+	mailer          mailer.Mailer
+	frontendURL     string
+	workspaceDomain string
+	defaultRole     string
+	ttl             time.Duration
+}
+
+// NewInvitationHandler creates a new InvitationHandler
+func NewInvitationHandler(
+	invitationRepo repository.InvitationRepository,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	auditRepo repository.AuditRepository,
+	m mailer.Mailer,
+	cfg *config.Config,
+) *InvitationHandler {
+	return &InvitationHandler{
+		invitationRepo:  invitationRepo,
+		userRepo:        userRepo,
+		roleRepo:        roleRepo,
+		auditRepo:       auditRepo,
+		mailer:          m,
+		frontendURL:     cfg.FrontendURL,
+		workspaceDomain: cfg.WorkspaceDomain,
+		defaultRole:     cfg.DefaultRole,
+		ttl:             cfg.InvitationTTL,
+	}
+}
+
+// ListInvitations returns all invitations, newest first
+func (h *InvitationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.invitationRepo.List(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch invitations", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"invitations": invitations,
+	})
+}
+
+// CreateInvitation pre-creates a user with a role and emails them an invitation.
+// Inviting a user who was invited before but never signed in issues a new invitation.
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	var req struct {
+		Email     string `json:"email"`
+		Name      string `json:"name"`
+		Role      string `json:"role"`
+		SendEmail *bool  `json:"send_email"` // Defaults to true
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	address, err := mail.ParseAddress(req.Email)
+	if err != nil || address.Address != strings.TrimSpace(req.Email) {
+		errors.WriteError(w, errors.NewValidationError("email", "must be a valid email address"))
+		return
+	}
+	email := strings.ToLower(address.Address)
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if h.workspaceDomain != "" && domain != strings.ToLower(h.workspaceDomain) {
+		errors.WriteError(w, errors.NewValidationError("email", "must belong to the "+h.workspaceDomain+" workspace"))
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = h.defaultRole
+	}
+	role, err := h.roleRepo.GetByName(r.Context(), req.Role)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch role", err))
+		return
+	}
+	if role == nil {
+		errors.WriteError(w, errors.NewValidationError("role", "unknown role"))
+		return
+	}
+
+	user, err := h.userRepo.GetByEmail(r.Context(), email)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch user", err))
+		return
+	}
+	if user != nil && user.Status != repository.UserStatusInvited {
+		errors.WriteError(w, errors.NewConflictError("A user with that email already exists"))
+		return
+	}
+	if user == nil {
+		user = &models.User{Email: email, WorkspaceDomain: domain}
+	}
+	if req.Name != "" {
+		user.Name = req.Name
+	}
+	user.Role = role.Name
+
+	// Re-invitations may change the pre-created user's name and role
+	if user.ID != "" {
+		if err := h.userRepo.Update(r.Context(), user); err != nil {
+			errors.WriteError(w, errors.NewInternalServerError("Failed to update invited user", err))
+			return
+		}
+	}
+
+	invitation := &models.Invitation{
+		InvitedBy:     currentUser.ID,
+		InvitedByName: currentUser.Name,
+		ExpiresAt:     time.Now().Add(h.ttl),
+	}
+	if err := h.invitationRepo.Invite(r.Context(), user, invitation); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to create invitation", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "user.invited", user.ID, map[string]interface{}{
+		"invitation_id": invitation.ID,
+		"role":          role.Name,
+	})
+
+	emailSent := false
+	if req.SendEmail == nil || *req.SendEmail {
+		emailSent = h.sendInvitation(r.Context(), invitation, currentUser)
+	}
+
+	errors.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"invitation": invitation,
+		"user":       user,
+		"email_sent": emailSent,
+	})
+}
+
+// ResendInvitation extends an unaccepted, unrevoked invitation and emails it again
+func (h *InvitationHandler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	invitation, ok := h.fetchOpenInvitation(w, r)
+	if !ok {
+		return
+	}
+
+	invitation.ExpiresAt = time.Now().Add(h.ttl)
+	if err := h.invitationRepo.Extend(r.Context(), invitation.ID, invitation.ExpiresAt); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to extend invitation", err))
+		return
+	}
+	invitation.Status = "pending"
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"invitation": invitation,
+		"email_sent": h.sendInvitation(r.Context(), invitation, currentUser),
+	})
+}
+
+// RevokeInvitation revokes an unaccepted invitation. The pre-created user stays
+// in the invited state and can be invited again.
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	invitation, ok := h.fetchOpenInvitation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.invitationRepo.Revoke(r.Context(), invitation.ID); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to revoke invitation", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "user.invitation_revoked", invitation.UserID, map[string]interface{}{
+		"invitation_id": invitation.ID,
+	})
+
+	errors.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Invitation revoked successfully",
+	})
+}
+
+// fetchOpenInvitation loads the invitation in the URL, writing an error response
+// unless it is still pending or has merely expired
+func (h *InvitationHandler) fetchOpenInvitation(w http.ResponseWriter, r *http.Request) (*models.Invitation, bool) {
+	invitation, err := h.invitationRepo.GetByID(r.Context(), chi.URLParam(r, "invitationId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch invitation", err))
+		return nil, false
+	}
+	if invitation == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Invitation"))
+		return nil, false
+	}
+	if invitation.Status != "pending" && invitation.Status != "expired" {
+		errors.WriteError(w, errors.NewConflictError("Invitation has already been "+invitation.Status))
+		return nil, false
+	}
+	return invitation, true
+}
+
+// sendInvitation emails the invitation, reporting whether it was sent. Failures are
+// logged rather than returned so the invitation can be resent later.
+func (h *InvitationHandler) sendInvitation(ctx context.Context, invitation *models.Invitation, inviter *models.User) bool {
+	msg := mailer.Message{
+		To:      invitation.Email,
+		Subject: "You have been invited to Candidate Organizer",
+		Body: fmt.Sprintf(
+			"%s has invited you to join Candidate Organizer.\n\nSign in with your Google account at %s/login before %s to accept.\n",
+			inviter.Name, h.frontendURL, invitation.ExpiresAt.Format("January 2, 2006"),
+		),
+	}
+	if err := h.mailer.Send(ctx, msg); err != nil {
+		logging.FromContext(ctx).Error("Failed to send invitation", "invitation_id", invitation.ID, "error", err)
+		return false
+	}
+	return true
+}
+
+// audit records an invitation event against the invited user
+func (h *InvitationHandler) audit(ctx context.Context, user *models.User, action, userID string, details map[string]interface{}) {
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: "user",
+		EntityID:   userID,
+		Details:    details,
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "target_user_id", userID, "error", err)
+	}
+}
@@ -0,0 +1,842 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/offers"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// OfferHandler handles offer management and approval requests
+type OfferHandler struct {
+	offerRepo     repository.OfferRepository
+	candidateRepo repository.CandidateRepository
+	jobRepo       repository.JobRepository
+	userRepo      repository.UserRepository
+	auditRepo     repository.AuditRepository
+}
+
+// NewOfferHandler creates a new OfferHandler
+func NewOfferHandler(
+	offerRepo repository.OfferRepository,
+	candidateRepo repository.CandidateRepository,
+	jobRepo repository.JobRepository,
+	userRepo repository.UserRepository,
+	auditRepo repository.AuditRepository,
+) *OfferHandler {
+	return &OfferHandler{
+		offerRepo:     offerRepo,
+		candidateRepo: candidateRepo,
+		jobRepo:       jobRepo,
+		userRepo:      userRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// offerRequest is the request body for creating and updating offers
+type offerRequest struct {
+	JobPostingID      string     `json:"job_posting_id"`
+	TemplateID        string     `json:"template_id"`
+	JobTitle          string     `json:"job_title"`
+	BaseSalary        string     `json:"base_salary"`
+	Currency          string     `json:"currency"`
+	Bonus             string     `json:"bonus"`
+	Equity            string     `json:"equity"`
+	CompensationNotes string     `json:"compensation_notes"`
+	StartDate         *time.Time `json:"start_date"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+}
+
+// offerResponse is an offer together with its approval chain
+type offerResponse struct {
+	*models.Offer
+	Approvals []*models.OfferApproval `json:"approvals"`
+}
+
+// ListOffers returns all offers for a candidate
+func (h *OfferHandler) ListOffers(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	candidate, ok := h.loadCandidate(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	list, err := h.offerRepo.ListByCandidate(r.Context(), candidate.ID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offers", err))
+		return
+	}
+
+	responses := make([]*offerResponse, 0, len(list))
+	for _, offer := range list {
+		response, err := h.buildResponse(r.Context(), user, offer)
+		if err != nil {
+			errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer approvals", err))
+			return
+		}
+		responses = append(responses, response)
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"offers": responses,
+	})
+}
+
+// CreateOffer creates a draft offer for a candidate (offers.manage)
+func (h *OfferHandler) CreateOffer(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	candidate, ok := h.loadCandidate(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	var req offerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	if req.JobPostingID == "" {
+		req.JobPostingID = candidate.JobPostingID
+	}
+
+	offer := &models.Offer{
+		CandidateID: candidate.ID,
+		Status:      offers.StatusDraft,
+		CreatedBy:   user.ID,
+	}
+	if appErr := h.applyRequest(r.Context(), offer, &req); appErr != nil {
+		errors.WriteError(w, appErr)
+		return
+	}
+
+	if err := h.offerRepo.Create(r.Context(), offer); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to create offer", err))
+		return
+	}
+
+	h.audit(r.Context(), user, "offer.created", offer.ID, map[string]interface{}{
+		"candidate_id": offer.CandidateID,
+	})
+
+	errors.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "Offer created successfully",
+		"offer":   &offerResponse{Offer: offer, Approvals: []*models.OfferApproval{}},
+	})
+}
+
+// GetOffer returns a single offer with its approval chain
+func (h *OfferHandler) GetOffer(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.buildResponse(r.Context(), user, offer)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer approvals", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"offer": response,
+	})
+}
+
+// UpdateOffer updates a draft offer (offers.manage)
+func (h *OfferHandler) UpdateOffer(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	if offer.Status != offers.StatusDraft {
+		errors.WriteError(w, errors.NewConflictError("Only draft offers can be edited"))
+		return
+	}
+
+	var req offerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	if appErr := h.applyRequest(r.Context(), offer, &req); appErr != nil {
+		errors.WriteError(w, appErr)
+		return
+	}
+
+	if err := h.offerRepo.Update(r.Context(), offer); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to update offer", err))
+		return
+	}
+
+	h.audit(r.Context(), user, "offer.updated", offer.ID, nil)
+
+	h.writeOffer(w, r, user, "Offer updated successfully", offer)
+}
+
+// DeleteOffer deletes a draft offer (offers.manage)
+func (h *OfferHandler) DeleteOffer(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	if offer.Status != offers.StatusDraft {
+		errors.WriteError(w, errors.NewConflictError("Only draft offers can be deleted"))
+		return
+	}
+
+	if err := h.offerRepo.Delete(r.Context(), offer.ID); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to delete offer", err))
+		return
+	}
+
+	h.audit(r.Context(), user, "offer.deleted", offer.ID, map[string]interface{}{
+		"candidate_id": offer.CandidateID,
+	})
+
+	errors.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Offer deleted successfully",
+	})
+}
+
+// SubmitOffer submits a draft offer for approval by an ordered chain of approvers (offers.manage)
+func (h *OfferHandler) SubmitOffer(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ApproverIDs []string `json:"approver_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	if len(req.ApproverIDs) == 0 {
+		errors.WriteError(w, errors.NewValidationError("approver_ids", "at least one approver is required"))
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, approverID := range req.ApproverIDs {
+		if seen[approverID] {
+			errors.WriteError(w, errors.NewValidationError("approver_ids", "approvers must be unique"))
+			return
+		}
+		seen[approverID] = true
+
+		if _, err := h.userRepo.GetByID(r.Context(), approverID); err != nil {
+			if err == repository.ErrUserNotFound {
+				errors.WriteError(w, errors.NewValidationError("approver_ids", "unknown approver "+approverID))
+				return
+			}
+			errors.WriteError(w, errors.NewInternalServerError("Failed to fetch approver", err))
+			return
+		}
+	}
+
+	if err := offers.ValidateTransition(offer.Status, offers.StatusPendingApproval); err != nil {
+		errors.WriteError(w, errors.NewConflictError(err.Error()))
+		return
+	}
+
+	if _, err := h.offerRepo.SetApprovalChain(r.Context(), offer.ID, req.ApproverIDs); err != nil {
+		// An approver deleted since the check above
+		if isForeignKeyViolation(err) {
+			errors.WriteError(w, errors.NewValidationError("approver_ids", "unknown approver"))
+			return
+		}
+		errors.WriteError(w, errors.NewInternalServerError("Failed to set approval chain", err))
+		return
+	}
+
+	offer.Status = offers.StatusPendingApproval
+	if err := h.offerRepo.Update(r.Context(), offer); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to submit offer", err))
+		return
+	}
+
+	h.audit(r.Context(), user, "offer.submitted", offer.ID, map[string]interface{}{
+		"approver_ids": req.ApproverIDs,
+	})
+
+	h.writeOffer(w, r, user, "Offer submitted for approval", offer)
+}
+
+// ApproveOffer records the current approver's approval
+func (h *OfferHandler) ApproveOffer(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, offers.DecisionApproved)
+}
+
+// RejectOffer records the current approver's rejection, returning the offer to draft
+func (h *OfferHandler) RejectOffer(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, offers.DecisionRejected)
+}
+
+// decide records a decision by the approver whose step is next in the chain
+func (h *OfferHandler) decide(w http.ResponseWriter, r *http.Request, decision string) {
+	user := r.Context().Value("user").(*models.User)
+
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	if offer.Status != offers.StatusPendingApproval {
+		errors.WriteError(w, errors.NewConflictError("Offer is not awaiting approval"))
+		return
+	}
+
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+			return
+		}
+	}
+
+	if decision == offers.DecisionRejected && req.Comment == "" {
+		errors.WriteError(w, errors.NewValidationError("comment", "a reason is required when rejecting"))
+		return
+	}
+
+	approvals, err := h.offerRepo.ListApprovals(r.Context(), offer.ID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer approvals", err))
+		return
+	}
+
+	// Approvals are decided strictly in step order
+	var current *models.OfferApproval
+	for _, approval := range approvals {
+		if approval.Decision == offers.DecisionPending {
+			current = approval
+			break
+		}
+	}
+	if current == nil {
+		errors.WriteError(w, errors.NewConflictError("Offer has no pending approval step"))
+		return
+	}
+	if current.ApproverID != user.ID {
+		errors.WriteError(w, errors.NewForbiddenError("You are not the current approver for this offer"))
+		return
+	}
+
+	now := time.Now()
+	current.Decision = decision
+	current.Comment = req.Comment
+	current.DecidedAt = &now
+
+	action := "offer.approval_granted"
+	switch {
+	case decision == offers.DecisionRejected:
+		action = "offer.approval_rejected"
+		offer.Status = offers.StatusDraft
+	case current.Step == len(approvals):
+		offer.Status = offers.StatusApproved
+	}
+
+	// The decision and the status it leads to are saved together
+	if err := h.offerRepo.DecideApproval(r.Context(), current, offer); err != nil {
+		if err == repository.ErrApprovalDecided {
+			errors.WriteError(w, errors.NewConflictError("The offer's approval changed since it was read; reload it and try again"))
+			return
+		}
+		errors.WriteError(w, errors.NewInternalServerError("Failed to record decision", err))
+		return
+	}
+
+	h.audit(r.Context(), user, action, offer.ID, map[string]interface{}{
+		"step":    current.Step,
+		"comment": req.Comment,
+		"status":  offer.Status,
+	})
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Decision recorded",
+		"offer":   &offerResponse{Offer: offer, Approvals: approvals},
+	})
+}
+
+// SendOffer marks an approved offer as sent to the candidate (offers.manage)
+func (h *OfferHandler) SendOffer(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	if err := offers.ValidateTransition(offer.Status, offers.StatusSent); err != nil {
+		errors.WriteError(w, errors.NewConflictError(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	if offer.ExpiresAt != nil && offer.ExpiresAt.Before(now) {
+		errors.WriteError(w, errors.NewConflictError("Offer has already expired"))
+		return
+	}
+
+	offer.Status = offers.StatusSent
+	offer.SentAt = &now
+	if err := h.offerRepo.Update(r.Context(), offer); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to send offer", err))
+		return
+	}
+
+	if err := h.candidateRepo.UpdateStatus(r.Context(), offer.CandidateID, "offered"); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Offer sent but failed to update candidate status", err))
+		return
+	}
+
+	h.audit(r.Context(), user, "offer.sent", offer.ID, nil)
+
+	h.writeOffer(w, r, user, "Offer marked as sent", offer)
+}
+
+// AcceptOffer records the candidate's acceptance of a sent offer (offers.manage)
+func (h *OfferHandler) AcceptOffer(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, offers.StatusAccepted)
+}
+
+// DeclineOffer records the candidate's refusal of a sent offer (offers.manage)
+func (h *OfferHandler) DeclineOffer(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, offers.StatusDeclined)
+}
+
+// respond records the candidate's response to a sent offer
+func (h *OfferHandler) respond(w http.ResponseWriter, r *http.Request, status string) {
+	user := r.Context().Value("user").(*models.User)
+
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	if err := offers.ValidateTransition(offer.Status, status); err != nil {
+		errors.WriteError(w, errors.NewConflictError(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	if status == offers.StatusAccepted && offer.ExpiresAt != nil && offer.ExpiresAt.Before(now) {
+		errors.WriteError(w, errors.NewConflictError("Offer has expired and can no longer be accepted"))
+		return
+	}
+
+	offer.Status = status
+	offer.RespondedAt = &now
+	if err := h.offerRepo.Update(r.Context(), offer); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to record candidate response", err))
+		return
+	}
+
+	h.audit(r.Context(), user, "offer."+status, offer.ID, nil)
+
+	h.writeOffer(w, r, user, "Candidate response recorded", offer)
+}
+
+// GetOfferLetter renders the offer letter as HTML or, with ?format=pdf, as a PDF
+func (h *OfferHandler) GetOfferLetter(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	canSee, err := h.canSeeCompensation(r.Context(), user, offer)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer approvals", err))
+		return
+	}
+	if !canSee {
+		errors.WriteError(w, errors.NewForbiddenError("Offer letters are only available to approvers and users with salary.read"))
+		return
+	}
+
+	candidate, ok := h.loadCandidate(w, r, offer.CandidateID)
+	if !ok {
+		return
+	}
+
+	body := ""
+	if offer.TemplateID != "" {
+		template, err := h.offerRepo.GetTemplate(r.Context(), offer.TemplateID)
+		if err != nil {
+			errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer template", err))
+			return
+		}
+		if template != nil {
+			body = template.Body
+		}
+	}
+
+	data := offers.NewLetterData(offer, candidate)
+	if r.URL.Query().Get("format") == "pdf" {
+		pdf, err := offers.RenderPDF(body, data)
+		if err != nil {
+			errors.WriteError(w, errors.NewInternalServerError("Failed to render offer letter", err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="offer-`+offer.ID+`.pdf"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write(pdf)
+		return
+	}
+
+	rendered, err := offers.RenderHTML(body, data)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to render offer letter", err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(rendered)
+}
+
+// GetOfferAudit returns the audit trail for an offer (offers.manage)
+func (h *OfferHandler) GetOfferAudit(w http.ResponseWriter, r *http.Request) {
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := h.auditRepo.ListByEntity(r.Context(), "offer", offer.ID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer audit trail", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+	})
+}
+
+// ListTemplates returns all offer letter templates (offers.manage)
+func (h *OfferHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.offerRepo.ListTemplates(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer templates", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"templates": templates,
+	})
+}
+
+// CreateTemplate creates an offer letter template (offers.manage)
+func (h *OfferHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	var req struct {
+		Name string `json:"name"`
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	if req.Name == "" {
+		errors.WriteError(w, errors.NewValidationError("name", "is required"))
+		return
+	}
+	if _, err := offers.ParseTemplate(req.Body); err != nil {
+		errors.WriteError(w, errors.NewValidationError("body", err.Error()))
+		return
+	}
+
+	template := &models.OfferTemplate{
+		Name:      req.Name,
+		Body:      req.Body,
+		CreatedBy: user.ID,
+	}
+	if err := h.offerRepo.CreateTemplate(r.Context(), template); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to create offer template", err))
+		return
+	}
+	h.auditTemplate(r.Context(), user, "offer_template.created", template.ID, map[string]interface{}{
+		"name": template.Name,
+	})
+
+	errors.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"message":  "Offer template created successfully",
+		"template": template,
+	})
+}
+
+// UpdateTemplate updates an offer letter template (offers.manage)
+func (h *OfferHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	template, err := h.offerRepo.GetTemplate(r.Context(), chi.URLParam(r, "templateId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer template", err))
+		return
+	}
+	if template == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Offer template"))
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	if req.Name == "" {
+		errors.WriteError(w, errors.NewValidationError("name", "is required"))
+		return
+	}
+	if _, err := offers.ParseTemplate(req.Body); err != nil {
+		errors.WriteError(w, errors.NewValidationError("body", err.Error()))
+		return
+	}
+
+	template.Name = req.Name
+	template.Body = req.Body
+	if err := h.offerRepo.UpdateTemplate(r.Context(), template); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to update offer template", err))
+		return
+	}
+	h.auditTemplate(r.Context(), user, "offer_template.updated", template.ID, map[string]interface{}{
+		"name": template.Name,
+	})
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "Offer template updated successfully",
+		"template": template,
+	})
+}
+
+// DeleteTemplate deletes an offer letter template (offers.manage)
+func (h *OfferHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value("user").(*models.User)
+
+	template, err := h.offerRepo.GetTemplate(r.Context(), chi.URLParam(r, "templateId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer template", err))
+		return
+	}
+	if template == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Offer template"))
+		return
+	}
+	if err := h.offerRepo.DeleteTemplate(r.Context(), template.ID); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to delete offer template", err))
+		return
+	}
+	h.auditTemplate(r.Context(), user, "offer_template.deleted", template.ID, map[string]interface{}{
+		"name": template.Name,
+	})
+
+	errors.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Offer template deleted successfully",
+	})
+}
+
+// applyRequest validates an offer request and copies it onto the offer
+func (h *OfferHandler) applyRequest(ctx context.Context, offer *models.Offer, req *offerRequest) *errors.AppError {
+	if req.JobPostingID != "" {
+		job, err := h.jobRepo.GetByID(ctx, req.JobPostingID)
+		if err != nil {
+			return errors.NewInternalServerError("Failed to fetch job posting", err)
+		}
+		if job == nil {
+			return errors.NewValidationError("job_posting_id", "job posting not found")
+		}
+		if req.JobTitle == "" {
+			req.JobTitle = job.Title
+		}
+	}
+
+	if req.TemplateID != "" {
+		template, err := h.offerRepo.GetTemplate(ctx, req.TemplateID)
+		if err != nil {
+			return errors.NewInternalServerError("Failed to fetch offer template", err)
+		}
+		if template == nil {
+			return errors.NewValidationError("template_id", "offer template not found")
+		}
+	}
+
+	if req.JobTitle == "" {
+		return errors.NewValidationError("job_title", "is required")
+	}
+	if req.BaseSalary == "" {
+		return errors.NewValidationError("base_salary", "is required")
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return errors.NewValidationError("expires_at", "must be in the future")
+	}
+
+	offer.JobPostingID = req.JobPostingID
+	offer.TemplateID = req.TemplateID
+	offer.JobTitle = req.JobTitle
+	offer.BaseSalary = req.BaseSalary
+	offer.Currency = req.Currency
+	offer.Bonus = req.Bonus
+	offer.Equity = req.Equity
+	offer.CompensationNotes = req.CompensationNotes
+	offer.StartDate = req.StartDate
+	offer.ExpiresAt = req.ExpiresAt
+	return nil
+}
+
+// writeOffer responds with the offer as buildResponse shows it to the user
+func (h *OfferHandler) writeOffer(w http.ResponseWriter, r *http.Request, user *models.User, message string, offer *models.Offer) {
+	response, err := h.buildResponse(r.Context(), user, offer)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer approvals", err))
+		return
+	}
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": message,
+		"offer":   response,
+	})
+}
+
+// buildResponse attaches the approval chain and hides compensation from users without salary.read
+func (h *OfferHandler) buildResponse(ctx context.Context, user *models.User, offer *models.Offer) (*offerResponse, error) {
+	approvals, err := h.offerRepo.ListApprovals(ctx, offer.ID)
+	if err != nil {
+		return nil, err
+	}
+	if approvals == nil {
+		approvals = []*models.OfferApproval{}
+	}
+
+	if !user.HasPermission(auth.PermSalaryRead) && !isApprover(user, approvals) {
+		redacted := *offer
+		redacted.BaseSalary = ""
+		redacted.Currency = ""
+		redacted.Bonus = ""
+		redacted.Equity = ""
+		redacted.CompensationNotes = ""
+		offer = &redacted
+	}
+
+	return &offerResponse{Offer: offer, Approvals: approvals}, nil
+}
+
+// canSeeCompensation reports whether the user may see the offer's compensation details
+func (h *OfferHandler) canSeeCompensation(ctx context.Context, user *models.User, offer *models.Offer) (bool, error) {
+	if user.HasPermission(auth.PermSalaryRead) {
+		return true, nil
+	}
+	approvals, err := h.offerRepo.ListApprovals(ctx, offer.ID)
+	if err != nil {
+		return false, err
+	}
+	return isApprover(user, approvals), nil
+}
+
+func isApprover(user *models.User, approvals []*models.OfferApproval) bool {
+	for _, approval := range approvals {
+		if approval.ApproverID == user.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// loadOffer fetches the offer named in the URL, writing an error response if it cannot
+func (h *OfferHandler) loadOffer(w http.ResponseWriter, r *http.Request) (*models.Offer, bool) {
+	offerID := chi.URLParam(r, "offerId")
+	if offerID == "" {
+		errors.WriteError(w, errors.NewBadRequestError("Offer ID is required"))
+		return nil, false
+	}
+
+	offer, err := h.offerRepo.GetByID(r.Context(), offerID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch offer", err))
+		return nil, false
+	}
+	if offer == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Offer"))
+		return nil, false
+	}
+	return offer, true
+}
+
+// loadCandidate fetches a candidate, writing an error response if it cannot
+func (h *OfferHandler) loadCandidate(w http.ResponseWriter, r *http.Request, candidateID string) (*models.Candidate, bool) {
+	if candidateID == "" {
+		errors.WriteError(w, errors.NewBadRequestError("Candidate ID is required"))
+		return nil, false
+	}
+
+	candidate, err := h.candidateRepo.GetByID(r.Context(), candidateID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch candidate", err))
+		return nil, false
+	}
+	if candidate == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Candidate"))
+		return nil, false
+	}
+	return candidate, true
+}
+
+// audit records an offer audit event; failures are logged but do not fail the request
+func (h *OfferHandler) audit(ctx context.Context, user *models.User, action, offerID string, details map[string]interface{}) {
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: "offer",
+		EntityID:   offerID,
+		Details:    details,
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "offer_id", offerID, "error", err)
+	}
+}
+
+// auditTemplate records an offer template change; failures are logged but do not fail the request
+func (h *OfferHandler) auditTemplate(ctx context.Context, user *models.User, action, templateID string, details map[string]interface{}) {
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: "offer_template",
+		EntityID:   templateID,
+		Details:    details,
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "offer_template_id", templateID, "error", err)
+	}
+}
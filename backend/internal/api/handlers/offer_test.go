@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/offers"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// fakeOfferRepo holds one offer and its approval chain. Methods the handlers
+// under test do not use panic through the embedded nil interface.
+type fakeOfferRepo struct {
+	repository.OfferRepository
+	offer       models.Offer
+	approvals   []*models.OfferApproval
+	decideError error
+}
+
+func (r *fakeOfferRepo) GetByID(ctx context.Context, id string) (*models.Offer, error) {
+	if id != r.offer.ID {
+		return nil, nil
+	}
+	offer := r.offer
+	return &offer, nil
+}
+
+func (r *fakeOfferRepo) Update(ctx context.Context, offer *models.Offer) error {
+	r.offer = *offer
+	return nil
+}
+
+func (r *fakeOfferRepo) ListApprovals(ctx context.Context, offerID string) ([]*models.OfferApproval, error) {
+	approvals := make([]*models.OfferApproval, 0, len(r.approvals))
+	for _, approval := range r.approvals {
+		copied := *approval
+		approvals = append(approvals, &copied)
+	}
+	return approvals, nil
+}
+
+func (r *fakeOfferRepo) DecideApproval(ctx context.Context, approval *models.OfferApproval, offer *models.Offer) error {
+	if r.decideError != nil {
+		return r.decideError
+	}
+	r.approvals[approval.Step-1] = approval
+	r.offer = *offer
+	return nil
+}
+
+func (r *fakeCandidateRepo) UpdateStatus(ctx context.Context, id, status string) error {
+	r.candidate.Status = status
+	return nil
+}
+
+func newOfferTestHandler(status string) (*OfferHandler, *fakeOfferRepo) {
+	offersRepo := &fakeOfferRepo{
+		offer: models.Offer{
+			ID: "o-1", CandidateID: "c-1", Status: status, JobTitle: "Engineer",
+			BaseSalary: "100000", Currency: "EUR", Bonus: "10%", Equity: "0.1%", CompensationNotes: "Negotiable",
+		},
+		approvals: []*models.OfferApproval{
+			{ID: "a-1", OfferID: "o-1", ApproverID: "approver-1", Step: 1, Decision: offers.DecisionPending},
+			{ID: "a-2", OfferID: "o-1", ApproverID: "approver-2", Step: 2, Decision: offers.DecisionPending},
+		},
+	}
+	return &OfferHandler{
+		offerRepo:     offersRepo,
+		candidateRepo: &fakeCandidateRepo{candidate: models.Candidate{ID: "c-1"}},
+		auditRepo:     &fakeAuditRepo{},
+	}, offersRepo
+}
+
+// callOffer calls an offer handler as the user and decodes the offer in the response
+func callOffer(t *testing.T, handler http.HandlerFunc, user *models.User, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/offers/o-1", strings.NewReader(body))
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("offerId", "o-1")
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+	req = req.WithContext(context.WithValue(ctx, "user", user))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	var response struct {
+		Offer map[string]interface{} `json:"offer"`
+	}
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec, response.Offer
+}
+
+func TestOfferResponsesHideCompensation(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		handler func(h *OfferHandler) http.HandlerFunc
+	}{
+		{"send", offers.StatusApproved, func(h *OfferHandler) http.HandlerFunc { return h.SendOffer }},
+		{"accept", offers.StatusSent, func(h *OfferHandler) http.HandlerFunc { return h.AcceptOffer }},
+		{"decline", offers.StatusSent, func(h *OfferHandler) http.HandlerFunc { return h.DeclineOffer }},
+	}
+	compensation := []string{"base_salary", "currency", "bonus", "equity", "compensation_notes"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// offers.manage without salary.read
+			h, _ := newOfferTestHandler(tt.status)
+			manager := &models.User{ID: "manager", Permissions: []string{auth.PermOffersManage}}
+			rec, offer := callOffer(t, tt.handler(h), manager, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+			for _, field := range compensation {
+				if _, ok := offer[field]; ok {
+					t.Errorf("%s shown without salary.read", field)
+				}
+			}
+			if offer["approvals"] == nil {
+				t.Error("approval chain missing from the response")
+			}
+
+			// With salary.read
+			h, _ = newOfferTestHandler(tt.status)
+			manager.Permissions = append(manager.Permissions, auth.PermSalaryRead)
+			_, offer = callOffer(t, tt.handler(h), manager, "")
+			if offer["base_salary"] != "100000" {
+				t.Errorf("base_salary = %v with salary.read", offer["base_salary"])
+			}
+		})
+	}
+}
+
+func TestDecideOffer(t *testing.T) {
+	approver := func(id string) *models.User { return &models.User{ID: id} }
+
+	h, repo := newOfferTestHandler(offers.StatusPendingApproval)
+	if rec, _ := callOffer(t, h.ApproveOffer, approver("approver-2"), ""); rec.Code != http.StatusForbidden {
+		t.Errorf("out of order approval status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	if rec, _ := callOffer(t, h.ApproveOffer, approver("approver-1"), ""); rec.Code != http.StatusOK {
+		t.Fatalf("first approval status = %d: %s", rec.Code, rec.Body)
+	}
+	if repo.offer.Status != offers.StatusPendingApproval || repo.approvals[0].Decision != offers.DecisionApproved {
+		t.Errorf("after the first step: offer %s, step 1 %s", repo.offer.Status, repo.approvals[0].Decision)
+	}
+
+	// A decision that loses a race is refused rather than half applied
+	repo.decideError = repository.ErrApprovalDecided
+	if rec, _ := callOffer(t, h.ApproveOffer, approver("approver-2"), ""); rec.Code != http.StatusConflict {
+		t.Errorf("raced decision status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	repo.decideError = nil
+
+	rec, offer := callOffer(t, h.ApproveOffer, approver("approver-2"), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("final approval status = %d: %s", rec.Code, rec.Body)
+	}
+	if repo.offer.Status != offers.StatusApproved || offer["status"] != offers.StatusApproved {
+		t.Errorf("offer status = %s, want approved", repo.offer.Status)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/privacy"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// PrivacyHandler answers data subject access and erasure requests about candidates
+type PrivacyHandler struct {
+	service   *privacy.Service
+	auditRepo repository.AuditRepository
+}
+
+// NewPrivacyHandler creates a new PrivacyHandler
+func NewPrivacyHandler(service *privacy.Service, auditRepo repository.AuditRepository) *PrivacyHandler {
+	return &PrivacyHandler{
+		service:   service,
+		auditRepo: auditRepo,
+	}
+}
+
+type eraseCandidateRequest struct {
+	Reason string `json:"reason"` // e.g. the reference of the subject's request
+}
+
+// ExportCandidate downloads a zip archive of everything held about a candidate
+func (h *PrivacyHandler) ExportCandidate(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	candidateID := chi.URLParam(r, "id")
+
+	// Build the archive before responding, so that a failure is still reported as an error
+	var buf bytes.Buffer
+	err := h.service.Export(r.Context(), candidateID, &buf)
+	if err == privacy.ErrNotFound {
+		errors.WriteError(w, errors.NewNotFoundError("Candidate"))
+		return
+	}
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to export candidate", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "candidate.exported", candidateID, map[string]interface{}{"bytes": buf.Len()})
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="candidate-%s.zip"`, candidateID))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to write candidate export", "candidate_id", candidateID, "error", err)
+	}
+}
+
+// EraseCandidate irreversibly erases a candidate's personal data. The request must
+// give a reason, which is kept in the audit trail.
+func (h *PrivacyHandler) EraseCandidate(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	candidateID := chi.URLParam(r, "id")
+
+	var req eraseCandidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		errors.WriteError(w, errors.NewValidationError("reason", "A reason for the erasure is required"))
+		return
+	}
+
+	erasure, err := h.service.Erase(r.Context(), candidateID)
+	if err == privacy.ErrNotFound {
+		errors.WriteError(w, errors.NewNotFoundError("Candidate"))
+		return
+	}
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to erase candidate", err))
+		return
+	}
+
+	// Recorded after the erasure, which clears the details of earlier events
+	h.audit(r.Context(), currentUser, "candidate.erased", candidateID, privacy.AuditDetails(erasure, req.Reason))
+
+	errors.WriteJSON(w, http.StatusOK, erasure)
+}
+
+// audit records a candidate audit event; failures are logged but do not fail the request
+func (h *PrivacyHandler) audit(ctx context.Context, user *models.User, action, candidateID string, details map[string]interface{}) {
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: "candidate",
+		EntityID:   candidateID,
+		Details:    details,
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "candidate_id", candidateID, "error", err)
+	}
+}
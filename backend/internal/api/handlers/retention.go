@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/privacy"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// RetentionHandler handles administration of data retention policies
+type RetentionHandler struct {
+	service       *privacy.Service
+	retentionRepo repository.RetentionRepository
+	auditRepo     repository.AuditRepository
+}
+
+// NewRetentionHandler creates a new RetentionHandler
+func NewRetentionHandler(service *privacy.Service, retentionRepo repository.RetentionRepository, auditRepo repository.AuditRepository) *RetentionHandler {
+	return &RetentionHandler{
+		service:       service,
+		retentionRepo: retentionRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+// retentionPolicyRequest is the request body for creating and updating retention policies
+type retentionPolicyRequest struct {
+	Name            string `json:"name"`
+	CandidateStatus string `json:"candidate_status"`
+	Action          string `json:"action"`
+	AfterDays       int    `json:"after_days"`
+	Mode            string `json:"mode"` // Defaults to "dry_run"
+}
+
+// ListPolicies returns every retention policy
+func (h *RetentionHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.retentionRepo.List(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch retention policies", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{"policies": policies})
+}
+
+// CreatePolicy creates a retention policy. New policies run in dry run mode
+// unless another mode is given.
+func (h *RetentionHandler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	policy := &models.RetentionPolicy{CreatedBy: currentUser.ID}
+	if !h.decodePolicy(w, r, policy) {
+		return
+	}
+
+	if err := h.retentionRepo.Create(r.Context(), policy); err != nil {
+		if isUniqueViolation(err) {
+			errors.WriteError(w, errors.NewConflictError("A retention policy with that name already exists"))
+			return
+		}
+		errors.WriteError(w, errors.NewInternalServerError("Failed to create retention policy", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "retention_policy.created", policy)
+	errors.WriteJSON(w, http.StatusCreated, policy)
+}
+
+// UpdatePolicy changes a retention policy
+func (h *RetentionHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	policy, ok := h.getPolicy(w, r)
+	if !ok {
+		return
+	}
+	if !h.decodePolicy(w, r, policy) {
+		return
+	}
+
+	if err := h.retentionRepo.Update(r.Context(), policy); err != nil {
+		if isUniqueViolation(err) {
+			errors.WriteError(w, errors.NewConflictError("A retention policy with that name already exists"))
+			return
+		}
+		errors.WriteError(w, errors.NewInternalServerError("Failed to update retention policy", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "retention_policy.updated", policy)
+	errors.WriteJSON(w, http.StatusOK, policy)
+}
+
+// DeletePolicy removes a retention policy
+func (h *RetentionHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+
+	policy, ok := h.getPolicy(w, r)
+	if !ok {
+		return
+	}
+	if err := h.retentionRepo.Delete(r.Context(), policy.ID); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to delete retention policy", err))
+		return
+	}
+
+	h.audit(r.Context(), currentUser, "retention_policy.deleted", policy)
+	errors.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Retention policy deleted successfully",
+	})
+}
+
+// PreviewPolicy lists the candidates a policy would apply to if it ran now, in
+// any mode, without changing anything
+func (h *RetentionHandler) PreviewPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, ok := h.getPolicy(w, r)
+	if !ok {
+		return
+	}
+
+	candidates, err := h.service.DueCandidates(r.Context(), policy)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to preview retention policy", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, &models.RetentionReport{
+		PolicyID:   policy.ID,
+		PolicyName: policy.Name,
+		Action:     policy.Action,
+		DryRun:     true,
+		Candidates: candidates,
+	})
+}
+
+// getPolicy fetches the policy named in the URL, writing an error response and
+// returning false if there is none
+func (h *RetentionHandler) getPolicy(w http.ResponseWriter, r *http.Request) (*models.RetentionPolicy, bool) {
+	policy, err := h.retentionRepo.GetByID(r.Context(), chi.URLParam(r, "policyId"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch retention policy", err))
+		return nil, false
+	}
+	if policy == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Retention policy"))
+		return nil, false
+	}
+	return policy, true
+}
+
+// decodePolicy reads and validates the request body into the policy, writing an
+// error response and returning false if it is invalid
+func (h *RetentionHandler) decodePolicy(w http.ResponseWriter, r *http.Request, policy *models.RetentionPolicy) bool {
+	var req retentionPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		errors.WriteError(w, errors.NewValidationError("name", "is required"))
+		return false
+	}
+	if !slices.Contains(privacy.CandidateStatuses, req.CandidateStatus) {
+		errors.WriteError(w, errors.NewValidationError("candidate_status", "must be one of "+strings.Join(privacy.CandidateStatuses, ", ")))
+		return false
+	}
+	if req.Action != privacy.ActionAnonymize && req.Action != privacy.ActionDeleteResume {
+		errors.WriteError(w, errors.NewValidationError("action", "must be anonymize or delete_resume"))
+		return false
+	}
+	if req.AfterDays <= 0 {
+		errors.WriteError(w, errors.NewValidationError("after_days", "must be a positive number of days"))
+		return false
+	}
+	if req.Mode == "" {
+		req.Mode = privacy.ModeDryRun
+	}
+	if req.Mode != privacy.ModeDisabled && req.Mode != privacy.ModeDryRun && req.Mode != privacy.ModeEnforce {
+		errors.WriteError(w, errors.NewValidationError("mode", "must be disabled, dry_run or enforce"))
+		return false
+	}
+
+	policy.Name = req.Name
+	policy.CandidateStatus = req.CandidateStatus
+	policy.Action = req.Action
+	policy.AfterDays = req.AfterDays
+	policy.Mode = req.Mode
+	return true
+}
+
+// audit records a retention policy change
+func (h *RetentionHandler) audit(ctx context.Context, user *models.User, action string, policy *models.RetentionPolicy) {
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: "retention_policy",
+		EntityID:   policy.ID,
+		Details: map[string]interface{}{
+			"name":             policy.Name,
+			"candidate_status": policy.CandidateStatus,
+			"action":           policy.Action,
+			"after_days":       policy.AfterDays,
+			"mode":             policy.Mode,
+		},
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "retention_policy_id", policy.ID, "error", err)
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/candidate-organizer/backend/internal/auth"
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// RoleHandler handles role and permission administration requests
+type RoleHandler struct {
+	roleRepo  repository.RoleRepository
+	auditRepo repository.AuditRepository
+}
+
+// NewRoleHandler creates a new RoleHandler
+func NewRoleHandler(roleRepo repository.RoleRepository, auditRepo repository.AuditRepository) *RoleHandler {
+	return &RoleHandler{roleRepo: roleRepo, auditRepo: auditRepo}
+}
+
+// roleRequest is the request body for creating and updating roles
+type roleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// ListRoles returns all roles and the permissions that can be granted
+func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roleRepo.List(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch roles", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"roles":       roles,
+		"permissions": auth.AllPermissions,
+	})
+}
+
+// CreateRole creates a custom role
+func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	if !roleNamePattern.MatchString(req.Name) {
+		errors.WriteError(w, errors.NewValidationError("name", "must be lowercase letters, digits and underscores"))
+		return
+	}
+	if appErr := validatePermissions(req.Permissions); appErr != nil {
+		errors.WriteError(w, appErr)
+		return
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+
+	existing, err := h.roleRepo.GetByName(r.Context(), req.Name)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch role", err))
+		return
+	}
+	if existing != nil {
+		errors.WriteError(w, errors.NewConflictError("A role with that name already exists"))
+		return
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}
+	if err := h.roleRepo.Create(r.Context(), role); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to create role", err))
+		return
+	}
+	h.audit(r.Context(), "role.created", role, nil)
+
+	errors.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "Role created successfully",
+		"role":    role,
+	})
+}
+
+// UpdateRole replaces a role's description and permissions
+func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	role, err := h.roleRepo.GetByName(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch role", err))
+		return
+	}
+	if role == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Role"))
+		return
+	}
+
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.WriteError(w, errors.NewBadRequestErrorWithCause("Invalid request body", err))
+		return
+	}
+
+	if appErr := validatePermissions(req.Permissions); appErr != nil {
+		errors.WriteError(w, appErr)
+		return
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+
+	// Removing user management from admin would leave nobody able to manage roles
+	if role.Name == auth.RoleAdmin && !slices.Contains(req.Permissions, auth.PermUsersManage) {
+		errors.WriteError(w, errors.NewValidationError("permissions", "the admin role must keep users.manage"))
+		return
+	}
+
+	previous := role.Permissions
+	role.Description = req.Description
+	role.Permissions = req.Permissions
+	if err := h.roleRepo.Update(r.Context(), role); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to update role", err))
+		return
+	}
+	h.audit(r.Context(), "role.updated", role, map[string]interface{}{
+		"previous_permissions": previous,
+	})
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Role updated successfully",
+		"role":    role,
+	})
+}
+
+// DeleteRole deletes a custom role that no users are assigned to
+func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	role, err := h.roleRepo.GetByName(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch role", err))
+		return
+	}
+	if role == nil {
+		errors.WriteError(w, errors.NewNotFoundError("Role"))
+		return
+	}
+	if role.IsSystem {
+		errors.WriteError(w, errors.NewConflictError("Built-in roles cannot be deleted"))
+		return
+	}
+
+	count, err := h.roleRepo.CountUsers(r.Context(), role.Name)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to count role members", err))
+		return
+	}
+	if count > 0 {
+		errors.WriteError(w, errors.NewConflictError("Role is still assigned to users"))
+		return
+	}
+
+	if err := h.roleRepo.Delete(r.Context(), role.Name); err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to delete role", err))
+		return
+	}
+	h.audit(r.Context(), "role.deleted", role, nil)
+
+	errors.WriteJSON(w, http.StatusOK, map[string]string{
+		"message": "Role deleted successfully",
+	})
+}
+
+// validatePermissions checks that every permission is known
+func validatePermissions(permissions []string) *errors.AppError {
+	for _, p := range permissions {
+		if !auth.IsValidPermission(p) {
+			return errors.NewValidationError("permissions", "unknown permission "+p)
+		}
+	}
+	return nil
+}
+
+// audit records a role change by the current user with the role's permissions
+func (h *RoleHandler) audit(ctx context.Context, action string, role *models.Role, details map[string]interface{}) {
+	user := ctx.Value("user").(*models.User)
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details["permissions"] = role.Permissions
+	event := &models.AuditEvent{
+		ActorID:    user.ID,
+		Action:     action,
+		EntityType: "role",
+		EntityID:   role.Name,
+		Details:    details,
+	}
+	if err := h.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "role", role.Name, "error", err)
+	}
+}
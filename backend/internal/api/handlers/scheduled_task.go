@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/jobs"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/internal/scheduler"
+	"github.com/go-chi/chi/v5"
+)
+
+// ScheduledTaskHandler lets admins see recurring tasks and their runs, and run
+// a task on demand
+type ScheduledTaskHandler struct {
+	sched     *scheduler.Scheduler
+	queue     *jobs.Queue
+	auditRepo repository.AuditRepository
+}
+
+// NewScheduledTaskHandler creates a new ScheduledTaskHandler
+func NewScheduledTaskHandler(sched *scheduler.Scheduler, queue *jobs.Queue, auditRepo repository.AuditRepository) *ScheduledTaskHandler {
+	return &ScheduledTaskHandler{
+		sched:     sched,
+		queue:     queue,
+		auditRepo: auditRepo,
+	}
+}
+
+// ListTasks returns every task with its schedule and its next and last runs
+func (h *ScheduledTaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.sched.Tasks(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch scheduled tasks", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{"tasks": tasks})
+}
+
+// ListRuns returns a task's most recent runs, newest first
+func (h *ScheduledTaskHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	runs, err := h.sched.Runs(r.Context(), chi.URLParam(r, "name"), limit)
+	if err == scheduler.ErrUnknownTask {
+		errors.WriteError(w, errors.NewNotFoundError("Scheduled task"))
+		return
+	}
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch scheduled task runs", err))
+		return
+	}
+
+	errors.WriteJSON(w, http.StatusOK, map[string]interface{}{"runs": runs})
+}
+
+// RunTask queues a run of the task now and returns the background job that runs it
+func (h *ScheduledTaskHandler) RunTask(w http.ResponseWriter, r *http.Request) {
+	currentUser := r.Context().Value("user").(*models.User)
+	name := chi.URLParam(r, "name")
+	if !h.sched.Has(name) {
+		errors.WriteError(w, errors.NewNotFoundError("Scheduled task"))
+		return
+	}
+
+	job, err := h.sched.Trigger(r.Context(), h.queue, name, currentUser.ID)
+	if err != nil {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to queue scheduled task", err))
+		return
+	}
+
+	event := &models.AuditEvent{
+		ActorID:    currentUser.ID,
+		Action:     "scheduled_task.triggered",
+		EntityType: "background_job",
+		EntityID:   job.ID,
+		Details:    map[string]interface{}{"task": name},
+	}
+	if err := h.auditRepo.Record(r.Context(), event); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to record audit event", "action", event.Action, "task", name, "error", err)
+	}
+
+	errors.WriteJSON(w, http.StatusAccepted, job)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/candidate-organizer/backend/internal/auth"
 	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
 	"github.com/candidate-organizer/backend/internal/models"
 	"github.com/candidate-organizer/backend/internal/repository"
 )
@@ -14,18 +15,27 @@ import (
 // AuthMiddleware is middleware for authenticating requests
 type AuthMiddleware struct {
 	jwtManager *auth.JWTManager
+	sessions   *auth.SessionManager
 	userRepo   repository.UserRepository
+	apiKeyRepo repository.APIKeyRepository
 }
 
 // NewAuthMiddleware creates a new auth middleware
-func NewAuthMiddleware(jwtManager *auth.JWTManager, userRepo repository.UserRepository) *AuthMiddleware {
+func NewAuthMiddleware(
+	jwtManager *auth.JWTManager,
+	sessions *auth.SessionManager,
+	userRepo repository.UserRepository,
+	apiKeyRepo repository.APIKeyRepository,
+) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtManager: jwtManager,
+		sessions:   sessions,
 		userRepo:   userRepo,
+		apiKeyRepo: apiKeyRepo,
 	}
 }
 
-// Authenticate validates the JWT token and adds user to context
+// Authenticate validates the JWT token or API key and adds user to context
 func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Try to get token from cookie first
@@ -53,40 +63,110 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		// Validate token
-		claims, err := m.jwtManager.ValidateToken(token)
-		if err != nil {
-			errors.WriteError(w, errors.NewUnauthorizedError("Invalid or expired token"))
-			return
-		}
+		var user *models.User
+		ctx := r.Context()
+		if auth.IsAPIKey(token) {
+			key, appErr := m.authenticateAPIKey(ctx, token)
+			if appErr != nil {
+				errors.WriteError(w, appErr)
+				return
+			}
+			if user, appErr = m.loadUser(ctx, key.UserID); appErr != nil {
+				errors.WriteError(w, appErr)
+				return
+			}
 
-		// Get user from database
-		user, err := m.userRepo.GetByID(r.Context(), claims.UserID)
-		if err != nil {
-			errors.WriteError(w, errors.NewUnauthorizedError("User not found"))
-			return
+			// The key can only use the permissions within its scopes
+			user.Permissions = auth.ScopePermissions(user.Permissions, key.Scopes)
+			ctx = repository.WithAPIKey(ctx, key.ID)
+		} else {
+			claims, appErr := m.authenticateSession(ctx, token)
+			if appErr != nil {
+				errors.WriteError(w, appErr)
+				return
+			}
+			if user, appErr = m.loadUser(ctx, claims.UserID); appErr != nil {
+				errors.WriteError(w, appErr)
+				return
+			}
+			ctx = context.WithValue(ctx, "session_id", claims.SessionID)
 		}
 
-		// Add user to context
-		ctx := context.WithValue(r.Context(), "user", user)
+		// Add user and their job access scope to context, and the user to log lines
+		ctx = context.WithValue(ctx, "user", user)
+		ctx = logging.WithUser(ctx, user.ID)
+		ctx = repository.WithAccessScope(ctx, repository.AccessScope{
+			UserID:  user.ID,
+			AllJobs: user.HasPermission(auth.PermJobsAccessAll),
+		})
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// RequireAdmin ensures the authenticated user is an admin
-func (m *AuthMiddleware) RequireAdmin(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		user, ok := r.Context().Value("user").(*models.User)
-		if !ok {
-			errors.WriteError(w, errors.NewUnauthorizedError("User not found in context"))
-			return
-		}
+// authenticateSession validates an access token and checks that its session is still active
+func (m *AuthMiddleware) authenticateSession(ctx context.Context, token string) (*auth.Claims, *errors.AppError) {
+	claims, err := m.jwtManager.ValidateToken(ctx, token)
+	if err != nil || claims.SessionID == "" {
+		return nil, errors.NewUnauthorizedError("Invalid or expired token")
+	}
 
-		if user.Role != "admin" {
-			errors.WriteError(w, errors.NewForbiddenError("Admin access required"))
-			return
-		}
+	// Reject tokens whose session has been revoked, e.g. by logging out
+	active, err := m.sessions.IsActive(ctx, claims.SessionID, claims.UserID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to check session", err)
+	}
+	if !active {
+		return nil, errors.NewUnauthorizedError("Session has been revoked")
+	}
+	return claims, nil
+}
 
-		next.ServeHTTP(w, r)
-	})
+// authenticateAPIKey looks up an API key and records that it was used
+func (m *AuthMiddleware) authenticateAPIKey(ctx context.Context, token string) (*models.APIKey, *errors.AppError) {
+	key, err := m.apiKeyRepo.GetByHash(ctx, auth.HashAPIKey(token))
+	if err != nil {
+		return nil, errors.NewInternalServerError("Failed to check API key", err)
+	}
+	if key == nil || !key.IsActive() {
+		return nil, errors.NewUnauthorizedError("Invalid, expired or revoked API key")
+	}
+
+	if err := m.apiKeyRepo.Touch(ctx, key.ID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record use of API key", "api_key_id", key.ID, "error", err)
+	}
+	return key, nil
+}
+
+// loadUser fetches the authenticated user, refusing users who are no longer active
+func (m *AuthMiddleware) loadUser(ctx context.Context, userID string) (*models.User, *errors.AppError) {
+	user, err := m.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.NewUnauthorizedError("User not found")
+	}
+
+	// Tokens and keys issued before a user was deactivated stop working immediately
+	if !user.IsActive() {
+		return nil, errors.NewUnauthorizedError("Account is deactivated")
+	}
+	return user, nil
+}
+
+// RequirePermission ensures the authenticated user's role grants the named permission
+func (m *AuthMiddleware) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value("user").(*models.User)
+			if !ok {
+				errors.WriteError(w, errors.NewUnauthorizedError("User not found in context"))
+				return
+			}
+
+			if !user.HasPermission(permission) {
+				errors.WriteError(w, errors.NewForbiddenError("Permission required: "+permission))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
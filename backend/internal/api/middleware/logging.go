@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// responseWriter captures the status, size and server error of a response
+type responseWriter struct {
+	middleware.WrapResponseWriter
+	err error
+}
+
+// RecordError implements errors.ErrorRecorder
+func (w *responseWriter) RecordError(err error) {
+	w.err = err
+}
+
+// RequestLogger logs a line for every request with its request ID, user, route,
+// status and latency, and puts a logger carrying the request and trace IDs in the
+// context. It must run after middleware.RequestID and Tracing.
+func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			reqLogger := logger.With("request_id", middleware.GetReqID(r.Context()))
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				reqLogger = reqLogger.With("trace_id", sc.TraceID().String())
+			}
+			ctx := logging.WithLogger(r.Context(), reqLogger)
+			ctx, info := logging.WithRequestInfo(ctx)
+
+			ww := &responseWriter{WrapResponseWriter: middleware.NewWrapResponseWriter(w, r.ProtoMajor)}
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			}
+			// The route pattern is filled in as the router matches the request
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				attrs = append(attrs, slog.String("route", rctx.RoutePattern()))
+			}
+			if userID := info.UserID(); userID != "" {
+				attrs = append(attrs, slog.String("user_id", userID))
+			}
+
+			level := slog.LevelInfo
+			if ww.err != nil {
+				attrs = append(attrs, slog.Any("error", ww.err))
+			}
+			if status >= 500 {
+				level = slog.LevelError
+			}
+			reqLogger.LogAttrs(r.Context(), level, "request", attrs...)
+		})
+	}
+}
+
+// Recoverer turns a panic into a 500 response and logs it with its stack trace.
+// It must run after RequestLogger so the panic is logged with the request.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+			// Aborting the handler is how net/http cancels a response; pass it on
+			if rvr == http.ErrAbortHandler {
+				panic(rvr)
+			}
+			logging.FromContext(r.Context()).Error("Panic while handling request",
+				"panic", fmt.Sprint(rvr), "stack", string(debug.Stack()))
+			if r.Header.Get("Connection") != "Upgrade" {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequestLoggerRecordsErrorsThroughMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	// The same order as the server's router
+	r := chi.NewRouter()
+	r.Use(RequestLogger(logger))
+	r.Use(Metrics)
+	r.Use(Recoverer)
+	r.Get("/candidates/{id}", func(w http.ResponseWriter, r *http.Request) {
+		errors.WriteError(w, errors.NewInternalServerError("Failed to fetch candidate", stderrors.New("connection refused")))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/candidates/42", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected a single JSON log line, got %q: %v", buf.String(), err)
+	}
+	if line["msg"] != "request" || line["level"] != "ERROR" {
+		t.Errorf("log line = %v", line)
+	}
+	if line["route"] != "/candidates/{id}" || line["status"] != float64(500) {
+		t.Errorf("log line = %v", line)
+	}
+	if errMsg, _ := line["error"].(string); !strings.Contains(errMsg, "connection refused") {
+		t.Errorf("log line error = %v, want the recorded error", line["error"])
+	}
+}
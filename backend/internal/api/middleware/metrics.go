@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// metricsResponseWriter captures the status of a response and passes recorded
+// errors on to the writer it wraps, so they still reach the request log line
+type metricsResponseWriter struct {
+	middleware.WrapResponseWriter
+	recorder errors.ErrorRecorder
+}
+
+// RecordError implements errors.ErrorRecorder
+func (w *metricsResponseWriter) RecordError(err error) {
+	if w.recorder != nil {
+		w.recorder.RecordError(err)
+	}
+}
+
+// Metrics records the latency and status of every request by route pattern
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := &metricsResponseWriter{WrapResponseWriter: middleware.NewWrapResponseWriter(w, r.ProtoMajor)}
+		ww.recorder, _ = w.(errors.ErrorRecorder)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := ""
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+		metrics.ObserveHTTPRequest(r.Method, route, status, time.Since(start))
+	})
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/ratelimit"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// RateLimiter limits requests per route group with a token bucket for each caller
+type RateLimiter struct {
+	store      ratelimit.Store
+	limits     map[string]ratelimit.Limit
+	trustProxy bool
+}
+
+// NewRateLimiter creates a rate limiter with a limit for each route group. With
+// trustProxy, the client IP is read from the X-Forwarded-For header.
+func NewRateLimiter(store ratelimit.Store, limits map[string]ratelimit.Limit, trustProxy bool) *RateLimiter {
+	return &RateLimiter{
+		store:      store,
+		limits:     limits,
+		trustProxy: trustProxy,
+	}
+}
+
+// Limit applies the route group's limit. Callers are identified by API key, then
+// by user, then by IP, so it should be used after Authenticate where there is one.
+func (l *RateLimiter) Limit(group string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limit := l.limits[group]
+		if limit.Unlimited() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := l.store.Take(r.Context(), group+":"+l.callerKey(r), limit)
+			if err != nil {
+				// Fail open: an unavailable store should not take the API down with it
+				logging.FromContext(r.Context()).Error("Failed to check rate limit", "group", group, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, ceilSeconds(limit.Period)))
+
+			if !result.Allowed {
+				header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				errors.WriteError(w, errors.NewTooManyRequestsError("Rate limit exceeded, try again later"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// callerKey identifies who is making the request
+func (l *RateLimiter) callerKey(r *http.Request) string {
+	ctx := r.Context()
+	if keyID := repository.APIKeyFromContext(ctx); keyID != "" {
+		return "key:" + keyID
+	}
+	if user, ok := ctx.Value("user").(*models.User); ok {
+		return "user:" + user.ID
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+// clientIP returns the address of the client. Behind a trusted proxy that is the
+// last X-Forwarded-For entry, the one the proxy added; earlier ones can be forged.
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.trustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			last := forwarded[len(forwarded)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as the headers require
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/ratelimit"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"no proxy", false, "203.0.113.7:51234", nil, "203.0.113.7"},
+		{"untrusted header is ignored", false, "203.0.113.7:51234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted header", true, "10.0.0.2:51234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"last entry of a list", true, "10.0.0.2:51234", []string{"192.0.2.66, 198.51.100.1"}, "198.51.100.1"},
+		{"last entry of the last header", true, "10.0.0.2:51234", []string{"192.0.2.66", "192.0.2.67,198.51.100.1"}, "198.51.100.1"},
+		{"trusted without header", true, "10.0.0.2:51234", nil, "10.0.0.2"},
+		{"empty last entry", true, "10.0.0.2:51234", []string{"192.0.2.66, "}, "10.0.0.2"},
+		{"address without port", false, "203.0.113.7", nil, "203.0.113.7"},
+		{"IPv6", false, "[2001:db8::1]:51234", nil, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(ratelimit.NewMemoryStore(), nil, tt.trustProxy)
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/google/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if got := l.clientIP(req); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterLimit(t *testing.T) {
+	limits := map[string]ratelimit.Limit{"auth": {Burst: 2, Period: time.Minute}}
+	l := NewRateLimiter(ratelimit.NewMemoryStore(), limits, false)
+	handler := l.Limit("auth")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	call := func(remoteAddr string, user *models.User) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/google/login", nil)
+		req.RemoteAddr = remoteAddr
+		if user != nil {
+			req = req.WithContext(context.WithValue(req.Context(), "user", user))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		rec := call("203.0.113.7:1", nil)
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != wantRemaining {
+			t.Fatalf("request %d: status %d, remaining %q", i+1, rec.Code, rec.Header().Get("RateLimit-Remaining"))
+		}
+	}
+
+	rec := call("203.0.113.7:2", nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	if got := rec.Header().Get("RateLimit-Policy"); got != "2;w=60" {
+		t.Errorf("RateLimit-Policy = %q", got)
+	}
+
+	// Signed-in users and other addresses have their own buckets
+	if rec := call("203.0.113.7:3", &models.User{ID: "u-1"}); rec.Code != http.StatusOK {
+		t.Errorf("signed-in user status = %d, want 200", rec.Code)
+	}
+	if rec := call("198.51.100.1:1", nil); rec.Code != http.StatusOK {
+		t.Errorf("other address status = %d, want 200", rec.Code)
+	}
+
+	// Groups without a limit are not limited
+	open := l.Limit("upload")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec = httptest.NewRecorder()
+	open.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("unlimited group: status %d, headers %v", rec.Code, rec.Header())
+	}
+}
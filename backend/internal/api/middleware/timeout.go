@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WriteTimeout gives every response d to be written. It stands in for
+// http.Server.WriteTimeout, which cannot be lifted for streamed responses; routes
+// that stream lift this deadline with Streams.Stream.
+func WriteTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Writers that cannot take a deadline, such as test recorders, go without
+			_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Streams tracks long-lived responses such as streamed chats. They have no write
+// deadline, so http.Server.Shutdown would wait for them until its own timeout;
+// Stop cancels them instead.
+type Streams struct {
+	ctx  context.Context
+	stop context.CancelFunc
+}
+
+// NewStreams creates a Streams
+func NewStreams() *Streams {
+	ctx, stop := context.WithCancel(context.Background())
+	return &Streams{ctx: ctx, stop: stop}
+}
+
+// Stream lifts the write deadline of the response and cancels the request's
+// context when Stop is called
+func (s *Streams) Stream(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		stop := context.AfterFunc(s.ctx, cancel)
+		defer stop()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Stop cancels every stream, current and future. Register it with
+// http.Server.RegisterOnShutdown.
+func (s *Streams) Stop() {
+	s.stop()
+}
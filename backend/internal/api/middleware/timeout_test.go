@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestWriteTimeoutAndStreams(t *testing.T) {
+	streams := NewStreams()
+	stopped := make(chan struct{})
+
+	// Wrapped as in the server's router, so the deadline has to reach the connection
+	r := chi.NewRouter()
+	r.Use(WriteTimeout(20 * time.Millisecond))
+	r.Use(RequestLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	r.Use(Metrics)
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	}
+	r.Get("/slow", slow)
+	r.With(streams.Stream).Get("/stream", slow)
+	r.With(streams.Stream).Get("/wait", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		http.NewResponseController(w).Flush()
+		<-r.Context().Done()
+		close(stopped)
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	get := func(path string) (string, error) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	if body, err := get("/slow"); err == nil && body == "done" {
+		t.Error("a response written after the deadline arrived")
+	}
+	if body, err := get("/stream"); err != nil || body != "done" {
+		t.Errorf("stream = %q, %v; want it to outlive the deadline", body, err)
+	}
+
+	resp, err := http.Get(server.URL + "/wait")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	streams.Stop()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("Stop did not cancel the stream")
+	}
+}
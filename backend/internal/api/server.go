@@ -1,25 +1,42 @@
 package api
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/candidate-organizer/backend/internal/api/handlers"
 	appmiddleware "github.com/candidate-organizer/backend/internal/api/middleware"
 	"github.com/candidate-organizer/backend/internal/auth"
 	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/candidate-organizer/backend/internal/errors"
+	"github.com/candidate-organizer/backend/internal/jobs"
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/mailer"
+	"github.com/candidate-organizer/backend/internal/mergepatch"
+	"github.com/candidate-organizer/backend/internal/metrics"
 	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/privacy"
+	"github.com/candidate-organizer/backend/internal/ratelimit"
 	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/internal/scheduler"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 )
 
+// readinessTimeout bounds the dependency checks of a readiness probe
+const readinessTimeout = 2 * time.Second
+
 // Server represents the API server
 type Server struct {
 	config         *config.Config
@@ -28,8 +45,33 @@ type Server struct {
 	candidateRepo  repository.CandidateRepository
 	commentRepo    repository.CommentRepository
 	attributeRepo  repository.AttributeRepository
-	authHandler    *handlers.AuthHandler
-	authMiddleware *appmiddleware.AuthMiddleware
+	offerRepo      repository.OfferRepository
+	auditRepo      repository.AuditRepository
+	roleRepo          repository.RoleRepository
+	keys              *auth.KeyManager
+	sessions          *auth.SessionManager
+	authHandler       *handlers.AuthHandler
+	offerHandler      *handlers.OfferHandler
+	roleHandler       *handlers.RoleHandler
+	invitationHandler *handlers.InvitationHandler
+	groupMapHandler   *handlers.GroupMappingHandler
+	apiKeyHandler     *handlers.APIKeyHandler
+	backgroundJobHandler *handlers.BackgroundJobHandler
+	scheduledTaskHandler *handlers.ScheduledTaskHandler
+	privacyHandler       *handlers.PrivacyHandler
+	retentionHandler     *handlers.RetentionHandler
+	consentHandler       *handlers.ConsentHandler
+	candidateHandler     *handlers.CandidateHandler
+	authMiddleware    *appmiddleware.AuthMiddleware
+	rateLimiter       *appmiddleware.RateLimiter
+	streams           *appmiddleware.Streams
+	readiness         ReadinessChecker
+	draining          atomic.Bool
+}
+
+// ReadinessChecker reports whether the server's dependencies can serve traffic
+type ReadinessChecker interface {
+	Ready(ctx context.Context) error
 }
 
 // NewServer creates a new API server
@@ -40,13 +82,70 @@ func NewServer(
 	candidateRepo repository.CandidateRepository,
 	commentRepo repository.CommentRepository,
 	attributeRepo repository.AttributeRepository,
+	offerRepo repository.OfferRepository,
+	auditRepo repository.AuditRepository,
+	roleRepo repository.RoleRepository,
+	invitationRepo repository.InvitationRepository,
+	groupMappingRepo repository.GroupMappingRepository,
+	sessionRepo repository.SessionRepository,
+	apiKeyRepo repository.APIKeyRepository,
+	consentRepo repository.ConsentRepository,
+	retentionRepo repository.RetentionRepository,
+	keys *auth.KeyManager,
+	limitStore ratelimit.Store,
+	readiness ReadinessChecker,
+	queue *jobs.Queue,
+	sched *scheduler.Scheduler,
+	privacyService *privacy.Service,
+	m mailer.Mailer,
 ) *Server {
+	// Create JWT and session managers
+	jwtManager := auth.NewJWTManager(keys, cfg.AccessTokenTTL)
+	sessions := auth.NewSessionManager(jwtManager, sessionRepo, cfg.SessionTTL)
+
 	// Create auth handler
-	authHandler := handlers.NewAuthHandler(userRepo, cfg)
+	authHandler := handlers.NewAuthHandler(userRepo, invitationRepo, groupMappingRepo, roleRepo, sessions, cfg)
+
+	// Create offer handler
+	offerHandler := handlers.NewOfferHandler(offerRepo, candidateRepo, jobRepo, userRepo, auditRepo)
+
+	// Create role handler
+	roleHandler := handlers.NewRoleHandler(roleRepo, auditRepo)
+
+	// Create invitation handler
+	invitationHandler := handlers.NewInvitationHandler(invitationRepo, userRepo, roleRepo, auditRepo, m, cfg)
+
+	// Create group mapping handler for the configured identity providers
+	providers := []string{"google"}
+	for _, provider := range cfg.OIDCProviders {
+		providers = append(providers, provider.Name)
+	}
+	groupMapHandler := handlers.NewGroupMappingHandler(groupMappingRepo, roleRepo, auditRepo, providers)
+
+	// Create API key handler
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo, userRepo, roleRepo, auditRepo)
+
+	// Create background job admin handler
+	backgroundJobHandler := handlers.NewBackgroundJobHandler(queue, auditRepo)
+
+	// Create scheduled task admin handler
+	scheduledTaskHandler := handlers.NewScheduledTaskHandler(sched, queue, auditRepo)
+
+	// Create data subject request handler
+	privacyHandler := handlers.NewPrivacyHandler(privacyService, auditRepo)
+
+	// Create retention policy and talent pool consent handlers
+	retentionHandler := handlers.NewRetentionHandler(privacyService, retentionRepo, auditRepo)
+	consentHandler := handlers.NewConsentHandler(consentRepo, candidateRepo, auditRepo)
+
+	// Create candidate partial update handler
+	candidateHandler := handlers.NewCandidateHandler(candidateRepo, attributeRepo, jobRepo, auditRepo)
+
+	// Create auth middleware
+	authMiddleware := appmiddleware.NewAuthMiddleware(jwtManager, sessions, userRepo, apiKeyRepo)
 
-	// Create JWT manager and auth middleware
-	jwtManager := auth.NewJWTManager(cfg.JWTSecret, 24*time.Hour)
-	authMiddleware := appmiddleware.NewAuthMiddleware(jwtManager, userRepo)
+	// Create rate limiter
+	rateLimiter := appmiddleware.NewRateLimiter(limitStore, cfg.RateLimits, cfg.TrustProxy)
 
 	return &Server{
 		config:         cfg,
@@ -55,8 +154,27 @@ func NewServer(
 		candidateRepo:  candidateRepo,
 		commentRepo:    commentRepo,
 		attributeRepo:  attributeRepo,
-		authHandler:    authHandler,
-		authMiddleware: authMiddleware,
+		offerRepo:      offerRepo,
+		auditRepo:      auditRepo,
+		roleRepo:          roleRepo,
+		keys:              keys,
+		sessions:          sessions,
+		authHandler:       authHandler,
+		offerHandler:      offerHandler,
+		roleHandler:       roleHandler,
+		invitationHandler: invitationHandler,
+		groupMapHandler:   groupMapHandler,
+		apiKeyHandler:     apiKeyHandler,
+		backgroundJobHandler: backgroundJobHandler,
+		scheduledTaskHandler: scheduledTaskHandler,
+		privacyHandler:       privacyHandler,
+		retentionHandler:     retentionHandler,
+		consentHandler:       consentHandler,
+		candidateHandler:     candidateHandler,
+		authMiddleware:    authMiddleware,
+		rateLimiter:       rateLimiter,
+		streams:           appmiddleware.NewStreams(),
+		readiness:         readiness,
 	}
 }
 
@@ -65,35 +183,58 @@ func (s *Server) Router() http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(appmiddleware.WriteTimeout(s.config.HTTPWriteTimeout))
 	r.Use(middleware.RequestID)
+	r.Use(appmiddleware.Tracing)
+	r.Use(appmiddleware.RequestLogger(slog.Default()))
+	r.Use(appmiddleware.Metrics)
+	r.Use(appmiddleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{s.config.FrontendURL},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Match"},
+		ExposedHeaders:   []string{"ETag", "Link", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	// Health check
+	// Health checks: liveness restarts a stuck process, readiness routes traffic
 	r.Get("/health", s.handleHealth)
+	r.Get("/health/live", s.handleHealth)
+	r.Get("/health/ready", s.handleReady)
+
+	// Prometheus metrics
+	r.Get("/metrics", s.handleMetrics)
+
+	// Public keys for verifying access tokens
+	r.Get("/.well-known/jwks.json", s.handleJWKS)
 
 	// API routes
 	r.Route("/api/v1", func(r chi.Router) {
+		limit := s.rateLimiter.Limit
+
 		// Auth routes (public and protected combined)
 		r.Route("/auth", func(r chi.Router) {
-			// Public auth routes (no middleware)
-			r.Get("/google", s.authHandler.GoogleLogin)
-			r.Get("/callback", s.authHandler.GoogleCallback)
+			// Public auth routes, limited by IP since the caller is not yet known
+			r.Group(func(r chi.Router) {
+				r.Use(limit("auth"))
+				r.Get("/google", s.authHandler.GoogleLogin)
+				r.Get("/callback", s.authHandler.GoogleCallback)
+				r.Get("/providers", s.authHandler.ListProviders)
+				r.Get("/oidc/{provider}", s.authHandler.OIDCLogin)
+				r.Get("/oidc/{provider}/callback", s.authHandler.OIDCCallback)
+				r.Post("/refresh", s.authHandler.RefreshToken)
+			})
 
 			// Protected auth routes (with middleware)
 			r.Group(func(r chi.Router) {
 				r.Use(s.authMiddleware.Authenticate)
-				r.Post("/refresh", s.authHandler.RefreshToken)
+				r.Use(limit("default"))
 				r.Post("/logout", s.authHandler.Logout)
+				r.Post("/logout-all", s.authHandler.LogoutAll)
 				r.Get("/me", s.authHandler.GetProfile)
+				r.Get("/sessions", s.authHandler.ListSessions)
+				r.Delete("/sessions/{sessionId}", s.authHandler.RevokeSession)
 			})
 		})
 
@@ -101,50 +242,193 @@ func (s *Server) Router() http.Handler {
 		r.Group(func(r chi.Router) {
 			// Apply auth middleware to all routes in this group
 			r.Use(s.authMiddleware.Authenticate)
+			r.Use(limit("default"))
+
+			can := s.authMiddleware.RequirePermission
 
-			// User routes (admin only)
+			// User routes
 			r.Route("/users", func(r chi.Router) {
-				r.Use(s.authMiddleware.RequireAdmin)
+				r.Use(can(auth.PermUsersManage))
 				r.Get("/", s.handleListUsers)
 				r.Post("/{id}/promote", s.handlePromoteUser)
+				r.Put("/{id}/role", s.handleSetUserRole)
+				r.Post("/{id}/demote", s.handleDemoteUser)
+				r.Post("/{id}/deactivate", s.handleDeactivateUser)
+				r.Post("/{id}/reactivate", s.handleReactivateUser)
+				r.Post("/{id}/transfer-ownership", s.handleTransferOwnership)
+				r.Get("/{id}/sessions", s.handleListUserSessions)
+				r.Delete("/{id}/sessions", s.handleRevokeUserSessions)
+				r.Delete("/{id}", s.handleDeleteUser)
+			})
+
+			// Role routes
+			r.Route("/roles", func(r chi.Router) {
+				r.Use(can(auth.PermUsersManage))
+				r.Get("/", s.roleHandler.ListRoles)
+				r.Post("/", s.roleHandler.CreateRole)
+				r.Put("/{name}", s.roleHandler.UpdateRole)
+				r.Delete("/{name}", s.roleHandler.DeleteRole)
+			})
+
+			// Invitation routes (pre-provisioned users)
+			r.Route("/invitations", func(r chi.Router) {
+				r.Use(can(auth.PermUsersManage))
+				r.Get("/", s.invitationHandler.ListInvitations)
+				r.Post("/", s.invitationHandler.CreateInvitation)
+				r.Post("/{invitationId}/resend", s.invitationHandler.ResendInvitation)
+				r.Delete("/{invitationId}", s.invitationHandler.RevokeInvitation)
+			})
+
+			// Personal API key routes
+			r.Route("/api-keys", func(r chi.Router) {
+				r.Get("/", s.apiKeyHandler.ListMyKeys)
+				r.Post("/", s.apiKeyHandler.CreateMyKey)
+				r.Delete("/{keyId}", s.apiKeyHandler.RevokeMyKey)
+			})
+
+			// Service account routes
+			r.Route("/service-accounts", func(r chi.Router) {
+				r.Use(can(auth.PermUsersManage))
+				r.Get("/", s.apiKeyHandler.ListServiceAccounts)
+				r.Post("/", s.apiKeyHandler.CreateServiceAccount)
+				r.Get("/{id}/api-keys", s.apiKeyHandler.ListServiceAccountKeys)
+				r.Post("/{id}/api-keys", s.apiKeyHandler.CreateServiceAccountKey)
+				r.Delete("/{id}/api-keys/{keyId}", s.apiKeyHandler.RevokeServiceAccountKey)
+			})
+
+			// Identity provider group to role mapping routes
+			r.Route("/group-role-mappings", func(r chi.Router) {
+				r.Use(can(auth.PermUsersManage))
+				r.Get("/", s.groupMapHandler.ListMappings)
+				r.Post("/", s.groupMapHandler.CreateMapping)
+				r.Put("/{mappingId}", s.groupMapHandler.UpdateMapping)
+				r.Delete("/{mappingId}", s.groupMapHandler.DeleteMapping)
+			})
+
+			// Background job queue administration
+			r.Route("/admin/jobs", func(r chi.Router) {
+				r.Use(can(auth.PermSystemManage))
+				r.Get("/", s.backgroundJobHandler.ListJobs)
+				r.Get("/{jobId}", s.backgroundJobHandler.GetJob)
+				r.Post("/{jobId}/retry", s.backgroundJobHandler.RetryJob)
+			})
+
+			// Scheduled task administration
+			r.Route("/admin/tasks", func(r chi.Router) {
+				r.Use(can(auth.PermSystemManage))
+				r.Get("/", s.scheduledTaskHandler.ListTasks)
+				r.Get("/{name}/runs", s.scheduledTaskHandler.ListRuns)
+				r.Post("/{name}/run", s.scheduledTaskHandler.RunTask)
+			})
+
+			// Data subject access and erasure requests
+			r.Route("/admin/privacy", func(r chi.Router) {
+				r.Use(can(auth.PermPrivacyManage))
+				r.Get("/candidates/{id}/export", s.privacyHandler.ExportCandidate)
+				r.Post("/candidates/{id}/erase", s.privacyHandler.EraseCandidate)
+			})
+
+			// Data retention policies
+			r.Route("/admin/retention/policies", func(r chi.Router) {
+				r.Use(can(auth.PermPrivacyManage))
+				r.Get("/", s.retentionHandler.ListPolicies)
+				r.Post("/", s.retentionHandler.CreatePolicy)
+				r.Put("/{policyId}", s.retentionHandler.UpdatePolicy)
+				r.Delete("/{policyId}", s.retentionHandler.DeletePolicy)
+				r.Get("/{policyId}/preview", s.retentionHandler.PreviewPolicy)
 			})
 
 			// Job posting routes
 			r.Route("/jobs", func(r chi.Router) {
-				r.Get("/", s.handleListJobs)
-				r.Post("/", s.handleCreateJob)
-				r.Get("/{id}", s.handleGetJob)
-				r.Put("/{id}", s.handleUpdateJob)
-				r.Delete("/{id}", s.handleDeleteJob)
+				r.With(can(auth.PermJobsRead)).Get("/", s.handleListJobs)
+				r.With(can(auth.PermJobsManage)).Post("/", s.handleCreateJob)
+				r.With(can(auth.PermJobsRead)).Get("/{id}", s.handleGetJob)
+				r.With(can(auth.PermJobsManage)).Put("/{id}", s.handleUpdateJob)
+				r.With(can(auth.PermJobsManage)).Patch("/{id}", s.handlePatchJob)
+				r.With(can(auth.PermJobsManage)).Delete("/{id}", s.handleDeleteJob)
+
+				// Hiring team
+				r.With(can(auth.PermJobsRead)).Get("/{id}/team", s.handleListJobTeam)
+				r.With(can(auth.PermJobsManage)).Put("/{id}/team/{userId}", s.handleSetJobTeamMember)
+				r.With(can(auth.PermJobsManage)).Delete("/{id}/team/{userId}", s.handleRemoveJobTeamMember)
 			})
 
 			// Candidate routes
 			r.Route("/candidates", func(r chi.Router) {
-				r.Get("/", s.handleListCandidates)
-				r.Post("/", s.handleCreateCandidate)
-				r.Post("/upload", s.handleUploadResume)
-				r.Get("/{id}", s.handleGetCandidate)
-				r.Put("/{id}", s.handleUpdateCandidate)
-				r.Delete("/{id}", s.handleDeleteCandidate)
-				r.Put("/{id}/status", s.handleUpdateCandidateStatus)
+				r.With(can(auth.PermCandidatesRead)).Get("/", s.handleListCandidates)
+				r.With(can(auth.PermCandidatesWrite)).Post("/", s.handleCreateCandidate)
+				r.With(can(auth.PermCandidatesWrite), limit("upload")).Post("/upload", s.handleUploadResume)
+				r.With(can(auth.PermCandidatesRead)).Get("/{id}", s.handleGetCandidate)
+				r.With(can(auth.PermCandidatesWrite)).Put("/{id}", s.handleUpdateCandidate)
+				r.With(can(auth.PermCandidatesWrite)).Patch("/{id}", s.candidateHandler.PatchCandidate)
+				r.With(can(auth.PermCandidatesWrite)).Delete("/{id}", s.handleDeleteCandidate)
+				r.With(can(auth.PermCandidatesWrite)).Put("/{id}/status", s.handleUpdateCandidateStatus)
 
 				// Candidate attributes
-				r.Post("/{id}/attributes", s.handleAddAttribute)
-				r.Put("/{id}/attributes/{attrId}", s.handleUpdateAttribute)
-				r.Delete("/{id}/attributes/{attrId}", s.handleDeleteAttribute)
+				r.Group(func(r chi.Router) {
+					r.Use(can(auth.PermCandidatesWrite))
+					r.Post("/{id}/attributes", s.handleAddAttribute)
+					r.Put("/{id}/attributes/{attrId}", s.handleUpdateAttribute)
+					r.Patch("/{id}/attributes/{attrId}", s.candidateHandler.PatchAttribute)
+					r.Delete("/{id}/attributes/{attrId}", s.handleDeleteAttribute)
+				})
 
 				// Comments
-				r.Get("/{id}/comments", s.handleListComments)
-				r.Post("/{id}/comments", s.handleAddComment)
-				r.Put("/{id}/comments/{commentId}", s.handleUpdateComment)
-				r.Delete("/{id}/comments/{commentId}", s.handleDeleteComment)
+				r.With(can(auth.PermCandidatesRead)).Get("/{id}/comments", s.handleListComments)
+				r.Group(func(r chi.Router) {
+					r.Use(can(auth.PermCommentsWrite))
+					r.Post("/{id}/comments", s.handleAddComment)
+					r.Put("/{id}/comments/{commentId}", s.handleUpdateComment)
+					r.Delete("/{id}/comments/{commentId}", s.handleDeleteComment)
+				})
+
+				// Offers
+				r.With(can(auth.PermOffersRead)).Get("/{id}/offers", s.offerHandler.ListOffers)
+				r.With(can(auth.PermOffersManage)).Post("/{id}/offers", s.offerHandler.CreateOffer)
+
+				// Talent pool consents
+				r.With(can(auth.PermCandidatesRead)).Get("/{id}/consents", s.consentHandler.ListConsents)
+				r.With(can(auth.PermCandidatesWrite)).Post("/{id}/consents", s.consentHandler.RecordConsent)
+				r.With(can(auth.PermCandidatesWrite)).Delete("/{id}/consents/{consentId}", s.consentHandler.RevokeConsent)
 
 				// AI features
-				r.Post("/{id}/summary", s.handleGenerateSummary)
+				r.With(can(auth.PermAIUse), limit("ai")).Post("/{id}/summary", s.handleGenerateSummary)
+			})
+
+			// Offer routes
+			r.Route("/offers/{offerId}", func(r chi.Router) {
+				r.Use(can(auth.PermOffersRead))
+				r.Get("/", s.offerHandler.GetOffer)
+				r.Get("/letter", s.offerHandler.GetOfferLetter)
+
+				// Approvers act on their own step; the handler checks who is next in the chain
+				r.Post("/approve", s.offerHandler.ApproveOffer)
+				r.Post("/reject", s.offerHandler.RejectOffer)
+
+				// Offer management
+				r.Group(func(r chi.Router) {
+					r.Use(can(auth.PermOffersManage))
+					r.Put("/", s.offerHandler.UpdateOffer)
+					r.Delete("/", s.offerHandler.DeleteOffer)
+					r.Post("/submit", s.offerHandler.SubmitOffer)
+					r.Post("/send", s.offerHandler.SendOffer)
+					r.Post("/accept", s.offerHandler.AcceptOffer)
+					r.Post("/decline", s.offerHandler.DeclineOffer)
+					r.Get("/audit", s.offerHandler.GetOfferAudit)
+				})
+			})
+
+			// Offer letter template routes
+			r.Route("/offer-templates", func(r chi.Router) {
+				r.Use(can(auth.PermOffersManage))
+				r.Get("/", s.offerHandler.ListTemplates)
+				r.Post("/", s.offerHandler.CreateTemplate)
+				r.Put("/{templateId}", s.offerHandler.UpdateTemplate)
+				r.Delete("/{templateId}", s.offerHandler.DeleteTemplate)
 			})
 
 			// AI chat
-			r.Post("/chat", s.handleChat)
+			r.With(can(auth.PermAIUse), limit("ai"), s.streams.Stream).Post("/chat", s.handleChat)
 		})
 	})
 
@@ -212,8 +496,69 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
 }
 
+// handleReady reports whether this instance should receive traffic: it is not
+// shutting down, the database is reachable and all migrations are applied
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		respondJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	if err := s.readiness.Ready(ctx); err != nil {
+		respondJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"status": "unavailable",
+			"error":  err.Error(),
+		})
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// StopStreams ends streamed responses, which are not bound by the write timeout,
+// so that they do not hold up shutdown
+func (s *Server) StopStreams() {
+	s.streams.Stop()
+}
+
+// Drain marks the server as shutting down, so readiness checks fail and load
+// balancers stop routing new requests to it
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+// handleMetrics serves Prometheus metrics. When METRICS_TOKEN is set, scrapers
+// must send it as a bearer token.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.config.MetricsToken != "" {
+		expected := "Bearer " + s.config.MetricsToken
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+			respondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Invalid metrics token"})
+			return
+		}
+	}
+	metrics.Handler().ServeHTTP(w, r)
+}
+
 // User management handlers
 
+// handleJWKS publishes the public keys that access tokens are signed with, so
+// other services can verify them without sharing a secret
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	jwks, err := s.keys.JWKS()
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to build JWKS",
+		})
+		return
+	}
+
+	// Short enough that consumers see new keys before they start signing
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondJSON(w, http.StatusOK, jwks)
+}
+
 // handleListUsers returns a list of all users (admin only)
 func (s *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
 	users, err := s.userRepo.List(r.Context())
@@ -231,6 +576,19 @@ func (s *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
 
 // handlePromoteUser promotes a user to admin role (admin only)
 func (s *Server) handlePromoteUser(w http.ResponseWriter, r *http.Request) {
+	role, err := s.roleRepo.GetByName(r.Context(), auth.RoleAdmin)
+	if err != nil || role == nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch admin role",
+		})
+		return
+	}
+
+	s.changeUserRole(w, r, chi.URLParam(r, "id"), role, "User promoted to admin successfully")
+}
+
+// handleSetUserRole assigns a role to a user (requires users.manage)
+func (s *Server) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id")
 	if userID == "" {
 		respondJSON(w, http.StatusBadRequest, map[string]string{
@@ -239,29 +597,422 @@ func (s *Server) handlePromoteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Promote the user to admin
-	if err := s.userRepo.PromoteToAdmin(r.Context(), userID); err != nil {
+	var req struct {
+		Role string `json:"role"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	role, err := s.roleRepo.GetByName(r.Context(), req.Role)
+	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": "Failed to promote user",
+			"error": "Failed to fetch role",
 		})
 		return
 	}
 
-	// Fetch the updated user to return
+	if role == nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Unknown role",
+		})
+		return
+	}
+
+	s.changeUserRole(w, r, userID, role, "User role updated successfully")
+}
+
+// handleDemoteUser moves a user back to the default role (requires users.manage)
+func (s *Server) handleDemoteUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	role, err := s.roleRepo.GetByName(r.Context(), s.config.DefaultRole)
+	if err != nil || role == nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch default role",
+		})
+		return
+	}
+
+	s.changeUserRole(w, r, userID, role, "User demoted successfully")
+}
+
+// changeUserRole assigns the role to the user, refusing to remove the caller's own
+// or the last active administrator's user management permission
+func (s *Server) changeUserRole(w http.ResponseWriter, r *http.Request, userID string, role *models.Role, message string) {
+	target, ok := s.fetchManagedUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	// Deleted users keep their row but cannot be given access again
+	if target.DeletedAt != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "User not found",
+		})
+		return
+	}
+
+	// Prevent admins from locking themselves out of user management
+	currentUser := r.Context().Value("user").(*models.User)
+	if currentUser.ID == userID && !slices.Contains(role.Permissions, auth.PermUsersManage) {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "You cannot remove your own user management permission",
+		})
+		return
+	}
+
+	err := s.userRepo.SetRoleKeeping(r.Context(), userID, role.Name, auth.PermUsersManage)
+	if !s.checkUserChange(w, err, "Failed to update user role") {
+		return
+	}
+
+	s.auditUser(r, "user.role_changed", userID, map[string]interface{}{
+		"from": target.Role,
+		"to":   role.Name,
+	})
+
 	user, err := s.userRepo.GetByID(r.Context(), userID)
 	if err != nil {
 		respondJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": "User promoted but failed to fetch updated user",
+			"error": "User role updated but failed to fetch updated user",
 		})
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"message": "User promoted to admin successfully",
+		"message": message,
 		"user":    user,
 	})
 }
 
+// handleDeactivateUser blocks a user from signing in (requires users.manage)
+func (s *Server) handleDeactivateUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	currentUser := r.Context().Value("user").(*models.User)
+	if currentUser.ID == userID {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "You cannot deactivate your own account",
+		})
+		return
+	}
+
+	target, ok := s.fetchManagedUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	s.setUserStatus(w, r, target, repository.UserStatusDeactivated, "user.deactivated", "User deactivated successfully")
+}
+
+// handleReactivateUser allows a deactivated user to sign in again (requires users.manage)
+func (s *Server) handleReactivateUser(w http.ResponseWriter, r *http.Request) {
+	target, ok := s.fetchManagedUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	// Deleted users cannot come back; their personal data is gone
+	if target.DeletedAt != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "User not found",
+		})
+		return
+	}
+
+	s.setUserStatus(w, r, target, repository.UserStatusActive, "user.reactivated", "User reactivated successfully")
+}
+
+func (s *Server) setUserStatus(w http.ResponseWriter, r *http.Request, target *models.User, status, action, message string) {
+	err := s.userRepo.SetStatusKeeping(r.Context(), target.ID, status, auth.PermUsersManage)
+	if !s.checkUserChange(w, err, "Failed to update user status") {
+		return
+	}
+
+	s.auditUser(r, action, target.ID, nil)
+
+	// Deactivated users are signed out everywhere, not just refused new logins
+	if status == repository.UserStatusDeactivated {
+		s.revokeUserSessions(r, target.ID, repository.SessionRevokedDeactivated)
+	}
+
+	user, err := s.userRepo.GetByID(r.Context(), target.ID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "User status updated but failed to fetch updated user",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": message,
+		"user":    user,
+	})
+}
+
+// handleListUserSessions returns a user's active sessions (requires users.manage)
+func (s *Server) handleListUserSessions(w http.ResponseWriter, r *http.Request) {
+	target, ok := s.fetchManagedUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	sessions, err := s.sessions.ListActive(r.Context(), target.ID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch sessions",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+	})
+}
+
+// handleRevokeUserSessions signs a user out of every session (requires users.manage)
+func (s *Server) handleRevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	target, ok := s.fetchManagedUser(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	count, err := s.sessions.RevokeAllForUser(r.Context(), target.ID, repository.SessionRevokedAdmin)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to revoke sessions",
+		})
+		return
+	}
+
+	s.auditUser(r, "user.sessions_revoked", target.ID, map[string]interface{}{
+		"sessions": count,
+	})
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "Sessions revoked successfully",
+		"sessions": count,
+	})
+}
+
+// revokeUserSessions signs a user out everywhere as a side effect of another
+// change. Failures are logged; the access check still refuses inactive users.
+func (s *Server) revokeUserSessions(r *http.Request, userID, reason string) {
+	if _, err := s.sessions.RevokeAllForUser(r.Context(), userID, reason); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to revoke sessions", "target_user_id", userID, "reason", reason, "error", err)
+	}
+}
+
+// handleTransferOwnership reassigns a user's jobs, candidates, offers and hiring team
+// seats to another active user (requires users.manage)
+func (s *Server) handleTransferOwnership(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	var req struct {
+		ToUserID string `json:"to_user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if _, ok := s.fetchManagedUser(w, r, userID); !ok {
+		return
+	}
+
+	transfer, ok := s.transferOwnership(w, r, userID, req.ToUserID)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":     "Ownership transferred successfully",
+		"transferred": transfer,
+	})
+}
+
+// handleDeleteUser soft-deletes a user, optionally transferring their records to
+// the user given by the transfer_to query parameter first (requires users.manage)
+func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	currentUser := r.Context().Value("user").(*models.User)
+	if currentUser.ID == userID {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "You cannot delete your own account",
+		})
+		return
+	}
+
+	target, ok := s.fetchManagedUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if target.DeletedAt != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "User not found",
+		})
+		return
+	}
+
+	// Refuse early so that a refused deletion transfers nothing; DeleteKeeping
+	// makes the check that holds under concurrent changes
+	if !s.guardLastAdmin(w, r, target) {
+		return
+	}
+
+	var transfer *repository.OwnershipTransfer
+	if toUserID := r.URL.Query().Get("transfer_to"); toUserID != "" {
+		if transfer, ok = s.transferOwnership(w, r, userID, toUserID); !ok {
+			return
+		}
+	}
+
+	err := s.userRepo.DeleteKeeping(r.Context(), userID, auth.PermUsersManage)
+	if !s.checkUserChange(w, err, "Failed to delete user") {
+		return
+	}
+
+	s.auditUser(r, "user.deleted", userID, nil)
+	s.revokeUserSessions(r, userID, repository.SessionRevokedDeactivated)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":     "User deleted successfully",
+		"transferred": transfer,
+	})
+}
+
+// transferOwnership validates the recipient and reassigns the user's records to them
+func (s *Server) transferOwnership(w http.ResponseWriter, r *http.Request, fromUserID, toUserID string) (*repository.OwnershipTransfer, bool) {
+	if toUserID == "" || toUserID == fromUserID {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "A different user to transfer ownership to is required",
+		})
+		return nil, false
+	}
+
+	recipient, err := s.userRepo.GetByID(r.Context(), toUserID)
+	if err == repository.ErrUserNotFound || (err == nil && !recipient.IsActive()) {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Ownership can only be transferred to an active user",
+		})
+		return nil, false
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch user",
+		})
+		return nil, false
+	}
+
+	transfer, err := s.userRepo.TransferOwnership(r.Context(), fromUserID, toUserID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to transfer ownership",
+		})
+		return nil, false
+	}
+
+	s.auditUser(r, "user.ownership_transferred", fromUserID, map[string]interface{}{
+		"to_user_id": toUserID,
+		"jobs":       transfer.Jobs,
+		"candidates": transfer.Candidates,
+		"offers":     transfer.Offers,
+		"team_seats": transfer.TeamSeats,
+	})
+	return transfer, true
+}
+
+// fetchManagedUser loads the user targeted by a user management request,
+// writing a 404 or 500 response if it cannot
+func (s *Server) fetchManagedUser(w http.ResponseWriter, r *http.Request, userID string) (*models.User, bool) {
+	user, err := s.userRepo.GetByID(r.Context(), userID)
+	if err == repository.ErrUserNotFound {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "User not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch user",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+// checkUserChange writes the response for a failed change to a user and returns
+// false, or returns true if err is nil. The change is refused with a 409 if it
+// would leave no active user able to manage users.
+func (s *Server) checkUserChange(w http.ResponseWriter, err error, message string) bool {
+	switch {
+	case err == nil:
+		return true
+	case err == repository.ErrUserNotFound:
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "User not found",
+		})
+	case err == repository.ErrLastHolder:
+		respondJSON(w, http.StatusConflict, map[string]string{
+			"error": "Cannot remove the last active administrator",
+		})
+	default:
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": message,
+		})
+	}
+	return false
+}
+
+// guardLastAdmin writes a 409 response and returns false if removing the user's
+// access would leave no active user able to manage users
+func (s *Server) guardLastAdmin(w http.ResponseWriter, r *http.Request, target *models.User) bool {
+	if !target.IsActive() || !target.HasPermission(auth.PermUsersManage) {
+		return true
+	}
+
+	count, err := s.userRepo.CountActiveWithPermission(r.Context(), auth.PermUsersManage)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to count administrators",
+		})
+		return false
+	}
+
+	if count <= 1 {
+		respondJSON(w, http.StatusConflict, map[string]string{
+			"error": "Cannot remove the last active administrator",
+		})
+		return false
+	}
+	return true
+}
+
+// auditUser records a user management action taken by the current user
+func (s *Server) auditUser(r *http.Request, action, userID string, details map[string]interface{}) {
+	currentUser := r.Context().Value("user").(*models.User)
+	event := &models.AuditEvent{
+		ActorID:    currentUser.ID,
+		Action:     action,
+		EntityType: "user",
+		EntityID:   userID,
+		Details:    details,
+	}
+	if err := s.auditRepo.Record(r.Context(), event); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to record audit event", "action", action, "target_user_id", userID, "error", err)
+	}
+}
+
 // Job posting handlers
 
 // handleListJobs returns a list of job postings with pagination
@@ -306,6 +1057,7 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 		Location     string `json:"location"`
 		SalaryRange  string `json:"salary_range"`
 		Status       string `json:"status"`
+		Confidential bool   `json:"confidential"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -346,6 +1098,7 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 		Location:     req.Location,
 		SalaryRange:  req.SalaryRange,
 		Status:       req.Status,
+		Confidential: req.Confidential,
 		CreatedBy:    user.ID,
 	}
 
@@ -387,12 +1140,14 @@ func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", handlers.ETag(job.Version))
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"job": job,
 	})
 }
 
-// handleUpdateJob updates an existing job posting
+// handleUpdateJob updates an existing job posting if it is still at the version
+// in If-Match
 func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "id")
 	if jobID == "" {
@@ -402,6 +1157,12 @@ func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := handlers.IfMatchVersion(r)
+	if err != nil {
+		errors.WriteError(w, err)
+		return
+	}
+
 	// Check if job exists
 	existingJob, err := s.jobRepo.GetByID(r.Context(), jobID)
 	if err != nil {
@@ -418,6 +1179,11 @@ func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if existingJob.Version != version {
+		handlers.WriteVersionConflict(w, existingJob.Version)
+		return
+	}
+
 	var req struct {
 		Title        string `json:"title"`
 		Description  string `json:"description"`
@@ -425,6 +1191,7 @@ func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request) {
 		Location     string `json:"location"`
 		SalaryRange  string `json:"salary_range"`
 		Status       string `json:"status"`
+		Confidential *bool  `json:"confidential"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -459,21 +1226,119 @@ func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request) {
 	if req.Status != "" {
 		existingJob.Status = req.Status
 	}
+	if req.Confidential != nil {
+		existingJob.Confidential = *req.Confidential
+	}
 
-	if err := s.jobRepo.Update(r.Context(), existingJob); err != nil {
+	// The job may have changed since it was read above
+	if err := s.jobRepo.UpdateIfVersion(r.Context(), existingJob, version); err != nil {
+		if err == repository.ErrVersionConflict {
+			s.respondJobConflict(w, r, jobID)
+			return
+		}
+		if err == repository.ErrAccessDenied {
+			respondJSON(w, http.StatusForbidden, map[string]string{
+				"error": "Only the job's owners and recruiters can edit it",
+			})
+			return
+		}
 		respondJSON(w, http.StatusInternalServerError, map[string]string{
 			"error": "Failed to update job posting",
 		})
 		return
 	}
 
+	w.Header().Set("ETag", handlers.ETag(existingJob.Version))
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Job posting updated successfully",
 		"job":     existingJob,
 	})
 }
 
-// handleDeleteJob deletes a job posting
+// jobPatchFields are the job fields a merge patch may change
+var jobPatchFields = []string{"title", "description", "requirements", "location", "salary_range", "status", "confidential"}
+
+// handlePatchJob applies a JSON merge patch to a job posting if it is still at
+// the version in If-Match, changing only the fields the patch names
+func (s *Server) handlePatchJob(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	version, err := handlers.IfMatchVersion(r)
+	if err != nil {
+		errors.WriteError(w, err)
+		return
+	}
+	patch, err := mergepatch.Read(r)
+	if err != nil {
+		errors.WriteError(w, handlers.PatchError(err))
+		return
+	}
+
+	job, err := s.jobRepo.GetByID(r.Context(), jobID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch job posting",
+		})
+		return
+	}
+	if job == nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Job posting not found",
+		})
+		return
+	}
+	if job.Version != version {
+		handlers.WriteVersionConflict(w, job.Version)
+		return
+	}
+
+	changed, err := mergepatch.Merge(job, patch, jobPatchFields...)
+	if err != nil {
+		errors.WriteError(w, handlers.PatchError(err))
+		return
+	}
+	if slices.Contains(changed, "title") && strings.TrimSpace(job.Title) == "" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Title is required",
+		})
+		return
+	}
+	if slices.Contains(changed, "status") && job.Status != "draft" && job.Status != "open" && job.Status != "closed" {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Status must be 'draft', 'open', or 'closed'",
+		})
+		return
+	}
+
+	if len(changed) > 0 {
+		if err := s.jobRepo.UpdateIfVersion(r.Context(), job, version); err != nil {
+			if err == repository.ErrVersionConflict {
+				s.respondJobConflict(w, r, jobID)
+				return
+			}
+			if err == repository.ErrAccessDenied {
+				respondJSON(w, http.StatusForbidden, map[string]string{
+					"error": "Only the job's owners and recruiters can edit it",
+				})
+				return
+			}
+			respondJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": "Failed to update job posting",
+			})
+			return
+		}
+		s.auditJob(r, "job_posting.updated", jobID, map[string]interface{}{
+			"fields": changed,
+		})
+	}
+
+	w.Header().Set("ETag", handlers.ETag(job.Version))
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Job posting updated successfully",
+		"job":     job,
+	})
+}
+
+// handleDeleteJob deletes a job posting if it is still at the version in If-Match
 func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "id")
 	if jobID == "" {
@@ -483,6 +1348,12 @@ func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := handlers.IfMatchVersion(r)
+	if err != nil {
+		errors.WriteError(w, err)
+		return
+	}
+
 	// Check if job exists
 	job, err := s.jobRepo.GetByID(r.Context(), jobID)
 	if err != nil {
@@ -499,7 +1370,22 @@ func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.jobRepo.Delete(r.Context(), jobID); err != nil {
+	if job.Version != version {
+		handlers.WriteVersionConflict(w, job.Version)
+		return
+	}
+
+	if err := s.jobRepo.DeleteIfVersion(r.Context(), jobID, version); err != nil {
+		if err == repository.ErrVersionConflict {
+			s.respondJobConflict(w, r, jobID)
+			return
+		}
+		if err == repository.ErrAccessDenied {
+			respondJSON(w, http.StatusForbidden, map[string]string{
+				"error": "Only the job's owners can delete it",
+			})
+			return
+		}
 		respondJSON(w, http.StatusInternalServerError, map[string]string{
 			"error": "Failed to delete job posting",
 		})
@@ -511,7 +1397,195 @@ func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Placeholder handlers - to be implemented
+// respondJobConflict responds 412 for a job that changed between being read and
+// written, with the ETag of its version now
+func (s *Server) respondJobConflict(w http.ResponseWriter, r *http.Request, jobID string) {
+	current := 0
+	if job, err := s.jobRepo.GetByID(r.Context(), jobID); err == nil && job != nil {
+		current = job.Version
+	}
+	handlers.WriteVersionConflict(w, current)
+}
+
+// auditJob records a change to a job posting made by the current user
+func (s *Server) auditJob(r *http.Request, action, jobID string, details map[string]interface{}) {
+	currentUser := r.Context().Value("user").(*models.User)
+	event := &models.AuditEvent{
+		ActorID:    currentUser.ID,
+		Action:     action,
+		EntityType: "job_posting",
+		EntityID:   jobID,
+		Details:    details,
+	}
+	if err := s.auditRepo.Record(r.Context(), event); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to record audit event", "action", action, "job_id", jobID, "error", err)
+	}
+}
+
+// Hiring team handlers
+
+// handleListJobTeam returns the hiring team for a job posting
+func (s *Server) handleListJobTeam(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+
+	job, err := s.jobRepo.GetByID(r.Context(), jobID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch job posting",
+		})
+		return
+	}
+
+	if job == nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Job posting not found",
+		})
+		return
+	}
+
+	members, err := s.jobRepo.ListTeam(r.Context(), jobID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch hiring team",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"team": members,
+	})
+}
+
+// handleSetJobTeamMember adds a user to a job's hiring team or changes their team role (owners only)
+func (s *Server) handleSetJobTeamMember(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	userID := chi.URLParam(r, "userId")
+
+	var req struct {
+		TeamRole string `json:"team_role"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if req.TeamRole != repository.TeamRoleOwner && req.TeamRole != repository.TeamRoleRecruiter && req.TeamRole != repository.TeamRoleInterviewer {
+		respondJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Team role must be 'owner', 'recruiter', or 'interviewer'",
+		})
+		return
+	}
+
+	job, err := s.jobRepo.GetByID(r.Context(), jobID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch job posting",
+		})
+		return
+	}
+
+	if job == nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Job posting not found",
+		})
+		return
+	}
+
+	if _, err := s.userRepo.GetByID(r.Context(), userID); err != nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "User not found",
+		})
+		return
+	}
+
+	member := &models.JobTeamMember{
+		JobPostingID: jobID,
+		UserID:       userID,
+		TeamRole:     req.TeamRole,
+	}
+
+	if err := s.jobRepo.SetTeamMember(r.Context(), member); err != nil {
+		if err == repository.ErrLastOwner {
+			respondJSON(w, http.StatusConflict, map[string]string{
+				"error": "Cannot demote the last owner of a job",
+			})
+			return
+		}
+		if err == repository.ErrAccessDenied {
+			respondJSON(w, http.StatusForbidden, map[string]string{
+				"error": "Only the job's owners can change its hiring team",
+			})
+			return
+		}
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update hiring team",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Hiring team updated successfully",
+		"member":  member,
+	})
+}
+
+// handleRemoveJobTeamMember removes a user from a job's hiring team (owners only)
+func (s *Server) handleRemoveJobTeamMember(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	userID := chi.URLParam(r, "userId")
+
+	members, err := s.jobRepo.ListTeam(r.Context(), jobID)
+	if err != nil {
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch hiring team",
+		})
+		return
+	}
+
+	var target *models.JobTeamMember
+	for _, member := range members {
+		if member.UserID == userID {
+			target = member
+		}
+	}
+
+	if target == nil {
+		respondJSON(w, http.StatusNotFound, map[string]string{
+			"error": "User is not on this job's hiring team",
+		})
+		return
+	}
+
+	// The repository checks that the job keeps at least one owner
+	if err := s.jobRepo.RemoveTeamMember(r.Context(), jobID, userID); err != nil {
+		if err == repository.ErrLastOwner {
+			respondJSON(w, http.StatusConflict, map[string]string{
+				"error": "Cannot remove the last owner of a job",
+			})
+			return
+		}
+		if err == repository.ErrAccessDenied {
+			respondJSON(w, http.StatusForbidden, map[string]string{
+				"error": "Only the job's owners can change its hiring team",
+			})
+			return
+		}
+		respondJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update hiring team",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "Hiring team member removed successfully",
+	})
+}
+
+// Placeholder handlers - to be implemented. Updates and deletes must read the
+// If-Match version and use the repositories' UpdateIfVersion and DeleteIfVersion.
 func (s *Server) handleListCandidates(w http.ResponseWriter, r *http.Request)          { notImplemented(w) }
 func (s *Server) handleCreateCandidate(w http.ResponseWriter, r *http.Request)         { notImplemented(w) }
 func (s *Server) handleUploadResume(w http.ResponseWriter, r *http.Request)            { notImplemented(w) }
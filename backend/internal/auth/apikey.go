@@ -0,0 +1,45 @@
+package auth
+
+import "strings"
+
+// APIKeyPrefix starts every API key, so keys are recognisable in configuration
+// and secret scanners, and cannot be mistaken for access tokens
+const APIKeyPrefix = "co_"
+
+// apiKeyDisplayLength is how much of a key is stored in the clear to identify it
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// GenerateAPIKey returns a new API key, the hash to store and the prefix to display
+func GenerateAPIKey() (key, keyHash, displayPrefix string, err error) {
+	random, err := generateSecret()
+	if err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + random
+	return key, HashAPIKey(key), key[:apiKeyDisplayLength], nil
+}
+
+// HashAPIKey returns the hash under which an API key is stored
+func HashAPIKey(key string) string {
+	return hashToken(key)
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than an access token
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// ScopePermissions returns the permissions that are both granted by the role and
+// within the key's scopes. A key never grants more than its user's role.
+func ScopePermissions(permissions, scopes []string) []string {
+	scoped := []string{}
+	for _, permission := range permissions {
+		for _, scope := range scopes {
+			if permission == scope {
+				scoped = append(scoped, permission)
+				break
+			}
+		}
+	}
+	return scoped
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScopePermissions(t *testing.T) {
+	role := []string{PermCandidatesRead, PermCandidatesWrite, PermSalaryRead}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		want   []string
+	}{
+		{"subset", []string{PermCandidatesRead}, []string{PermCandidatesRead}},
+		{"all", []string{PermSalaryRead, PermCandidatesWrite, PermCandidatesRead}, role},
+		{"beyond the role", []string{PermCandidatesRead, PermUsersManage}, []string{PermCandidatesRead}},
+		{"only beyond the role", []string{PermUsersManage}, []string{}},
+		{"no scopes", nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopePermissions(role, tt.scopes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ScopePermissions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, keyHash, displayPrefix, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsAPIKey(key) || !strings.HasPrefix(key, displayPrefix) || len(displayPrefix) != apiKeyDisplayLength {
+		t.Errorf("key = %q, display prefix = %q", key, displayPrefix)
+	}
+	if keyHash != HashAPIKey(key) || strings.Contains(keyHash, key) {
+		t.Errorf("hash = %q", keyHash)
+	}
+
+	other, _, _, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == key {
+		t.Error("generated the same key twice")
+	}
+	if IsAPIKey("eyJhbGciOiJSUzI1NiJ9.e30.sig") {
+		t.Error("an access token was taken for an API key")
+	}
+}
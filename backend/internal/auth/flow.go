@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/oauth2"
+)
+
+// LoginFlow holds the values that tie an authorization callback to the browser
+// that started the login. It is kept in a short-lived HTTP-only cookie.
+type LoginFlow struct {
+	State    string `json:"s"` // Compared with the callback's state parameter (CSRF)
+	Verifier string `json:"v"` // PKCE code verifier; only its S256 challenge is sent to the provider
+	Nonce    string `json:"n"` // Must be echoed in the OIDC ID token (replay protection)
+	ReturnTo string `json:"r"` // Frontend path to continue at after signing in
+}
+
+// NewLoginFlow starts a login flow. returnTo must already have been validated.
+func NewLoginFlow(returnTo string) (*LoginFlow, error) {
+	state, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	return &LoginFlow{
+		State:    state,
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    nonce,
+		ReturnTo: returnTo,
+	}, nil
+}
+
+// Encode serialises the flow for storage in a cookie
+func (f *LoginFlow) Encode() (string, error) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeLoginFlow reads a flow stored by Encode
+func DecodeLoginFlow(value string) (*LoginFlow, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	flow := &LoginFlow{}
+	if err := json.Unmarshal(data, flow); err != nil {
+		return nil, err
+	}
+	if flow.State == "" || flow.Verifier == "" || flow.Nonce == "" {
+		return nil, fmt.Errorf("incomplete login flow")
+	}
+	return flow, nil
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"golang.org/x/oauth2"
+)
+
+// GoogleGroupsScope allows reading the signed-in user's Google Workspace group memberships
+const GoogleGroupsScope = "https://www.googleapis.com/auth/cloud-identity.groups.readonly"
+
+// groupsQueryEmail matches the email addresses that can be placed in a Cloud
+// Identity query without escaping. Quotes, backslashes and spaces could end the
+// string literal and change the query, so addresses containing them are refused.
+var groupsQueryEmail = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+$`)
+
+// ResolveGroupRole returns the role of the first mapping, in the given order, whose
+// group the identity belongs to. Group names are compared case-insensitively.
+func ResolveGroupRole(mappings []*models.GroupRoleMapping, groups []string) (string, bool) {
+	for _, mapping := range mappings {
+		if containsFold(groups, mapping.GroupName) {
+			return mapping.Role, true
+		}
+	}
+	return "", false
+}
+
+// GetGroups returns the email addresses of the Google Workspace groups the user
+// belongs to, directly or through nested groups. It requires GoogleGroupsScope.
+func (c *OAuthConfig) GetGroups(ctx context.Context, token *oauth2.Token, email string) ([]string, error) {
+	query, err := groupsQuery(email)
+	if err != nil {
+		return nil, err
+	}
+
+	client := c.Config.Client(c.clientContext(ctx), token)
+	var groups []string
+	pageToken := ""
+	for {
+		params := url.Values{"query": {query}}
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+			"https://cloudidentity.googleapis.com/v1/groups/-/memberships:searchTransitiveGroups?"+params.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get groups: %w", err)
+		}
+
+		var page struct {
+			Memberships []struct {
+				GroupKey struct {
+					ID string `json:"id"`
+				} `json:"groupKey"`
+			} `json:"memberships"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if resp.StatusCode != 200 {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to get groups: status %d, body: %s", resp.StatusCode, string(body))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode groups: %w", err)
+		}
+
+		for _, membership := range page.Memberships {
+			groups = append(groups, membership.GroupKey.ID)
+		}
+		if page.NextPageToken == "" {
+			return groups, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// groupsQuery builds the Cloud Identity query for the groups of a member
+func groupsQuery(email string) (string, error) {
+	if !groupsQueryEmail.MatchString(email) {
+		return "", fmt.Errorf("cannot look up groups for email address %q", email)
+	}
+	return fmt.Sprintf("member_key_id == '%s' && 'cloudidentity.googleapis.com/groups.discussion_forum' in labels", email), nil
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestGroupsQuery(t *testing.T) {
+	tests := []struct {
+		email   string
+		wantErr bool
+	}{
+		{"ada@example.com", false},
+		{"ada.lovelace+jobs@mail.example.co.uk", false},
+		{"o'brien@example.com", true},
+		{"x' || member_key_id != '@example.com", true},
+		{`ada\@example.com`, true},
+		{"ada @example.com", true},
+		{"ada", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			query, err := groupsQuery(tt.email)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("groupsQuery(%q) error = %v, wantErr %v", tt.email, err, tt.wantErr)
+			}
+			if err == nil && query != "member_key_id == '"+tt.email+"' && 'cloudidentity.googleapis.com/groups.discussion_forum' in labels" {
+				t.Errorf("groupsQuery(%q) = %q", tt.email, query)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Identity is the provider-independent result of a successful sign-in
+type Identity struct {
+	Provider     string // "google" or the name of an OIDC provider
+	Subject      string // The provider's stable user identifier
+	Email        string
+	Name         string
+	HostedDomain string   // Google Workspace hosted domain, if any
+	Groups       []string // Group claims, if the provider sends them
+	// GroupsUnknown is set when group membership could not be determined,
+	// so group role mappings must not be re-evaluated
+	GroupsUnknown bool
+}
+
+// Identity converts Google user info into a provider-independent identity
+func (u *GoogleUserInfo) Identity() *Identity {
+	return &Identity{
+		Provider:     "google",
+		Subject:      u.ID,
+		Email:        u.Email,
+		Name:         u.Name,
+		HostedDomain: u.HD,
+	}
+}
+
+// EmailDomain returns the domain part of the identity's email, or an empty string
+func (i *Identity) EmailDomain() string {
+	at := strings.LastIndex(i.Email, "@")
+	if at < 0 || at == len(i.Email)-1 {
+		return ""
+	}
+	return strings.ToLower(i.Email[at+1:])
+}
+
+// validateRestrictions checks the identity against allowed email domains and groups.
+// An empty list means no restriction.
+func validateRestrictions(identity *Identity, allowedDomains, allowedGroups []string) error {
+	if len(allowedDomains) > 0 {
+		domain := identity.EmailDomain()
+		if domain == "" {
+			return fmt.Errorf("invalid email format")
+		}
+		if !containsFold(allowedDomains, domain) {
+			return fmt.Errorf("email domain %s is not allowed", domain)
+		}
+	}
+
+	if len(allowedGroups) > 0 {
+		for _, group := range identity.Groups {
+			if containsFold(allowedGroups, group) {
+				return nil
+			}
+		}
+		return fmt.Errorf("user is not a member of an allowed group")
+	}
+
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -10,34 +11,41 @@ import (
 
 // Claims represents the JWT claims
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
-	Role   string `json:"role"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"` // Server-side session the token belongs to
 	jwt.RegisteredClaims
 }
 
 // JWTManager handles JWT token generation and validation
 type JWTManager struct {
-	secretKey     string
+	keys          *KeyManager
 	tokenDuration time.Duration
 }
 
-// NewJWTManager creates a new JWT manager
-func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
+// NewJWTManager creates a new JWT manager that signs with the key manager's current key
+func NewJWTManager(keys *KeyManager, tokenDuration time.Duration) *JWTManager {
 	return &JWTManager{
-		secretKey:     secretKey,
+		keys:          keys,
 		tokenDuration: tokenDuration,
 	}
 }
 
-// GenerateToken generates a new JWT token for a user
-func (m *JWTManager) GenerateToken(user *models.User) (string, error) {
+// TokenDuration returns how long generated tokens are valid
+func (m *JWTManager) TokenDuration() time.Duration {
+	return m.tokenDuration
+}
+
+// GenerateToken generates a new JWT access token for a user's session
+func (m *JWTManager) GenerateToken(user *models.User, sessionID string) (string, error) {
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Name:   user.Name,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -45,18 +53,30 @@ func (m *JWTManager) GenerateToken(user *models.User) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.secretKey))
+	key, err := m.keys.currentKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
 }
 
 // ValidateToken validates a JWT token and returns the claims
-func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
+func (m *JWTManager) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := m.keys.verificationKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// Verify signing method matches the key, so a key cannot be used with another algorithm
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(m.secretKey), nil
+		return key.public, nil
 	})
 
 	if err != nil {
@@ -0,0 +1,318 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/internal/tracing"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported access token signing algorithms
+const (
+	SigningAlgRS256 = "RS256"
+	SigningAlgES256 = "ES256"
+)
+
+const (
+	// keyRefreshInterval is how often the key set is reloaded and rotation is checked
+	keyRefreshInterval = time.Minute
+	// keyPublishLead is how long a new key is published in the JWKS before it signs,
+	// so that every instance and JWKS consumer has seen it by the time tokens use it
+	keyPublishLead = 10 * time.Minute
+)
+
+// signingKey is a parsed models.SigningKey
+type signingKey struct {
+	id          string
+	method      jwt.SigningMethod
+	private     crypto.Signer
+	public      crypto.PublicKey
+	activatesAt time.Time
+	createdAt   time.Time
+}
+
+// KeyManager holds the asymmetric keys that sign and verify access tokens. Keys
+// are stored in the database so that all instances share them, and are rotated
+// every rotationInterval; replaced keys keep verifying for the grace period.
+type KeyManager struct {
+	repo             repository.SigningKeyRepository
+	algorithm        string
+	rotationInterval time.Duration
+	grace            time.Duration
+
+	mu       sync.RWMutex
+	keys     []*signingKey // Newest activation first
+	loadedAt time.Time
+}
+
+// NewKeyManager creates a key manager. Call Init before signing tokens.
+func NewKeyManager(repo repository.SigningKeyRepository, algorithm string, rotationInterval, grace time.Duration) (*KeyManager, error) {
+	if algorithm != SigningAlgRS256 && algorithm != SigningAlgES256 {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	return &KeyManager{
+		repo:             repo,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		grace:            grace,
+	}, nil
+}
+
+// Init loads the key set, creating the first key if there is none
+func (m *KeyManager) Init(ctx context.Context) error {
+	if err := m.load(ctx); err != nil {
+		return err
+	}
+	if err := m.rotateIfDue(ctx); err != nil {
+		return err
+	}
+	if _, err := m.currentKey(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Run reloads the key set and rotates keys when due until ctx is cancelled
+func (m *KeyManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(keyRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.refresh(ctx)
+		}
+	}
+}
+
+// refresh reloads the key set and rotates keys when due, as one traced job run
+func (m *KeyManager) refresh(ctx context.Context) {
+	ctx, span := tracing.StartJob(ctx, "signing_keys.refresh")
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	if err = m.load(ctx); err != nil {
+		slog.Error("Failed to reload signing keys", "error", err)
+		return
+	}
+	if err = m.rotateIfDue(ctx); err != nil {
+		slog.Error("Failed to rotate signing keys", "error", err)
+	}
+}
+
+// JWKS returns the public keys that tokens may currently be verified with
+func (m *KeyManager) JWKS() (*JSONWebKeySet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := &JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range m.keys {
+		jwk, err := newJSONWebKey(key.id, key.method.Alg(), key.public)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// currentKey returns the newest key that has activated
+func (m *KeyManager) currentKey() (*signingKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range m.keys {
+		if !key.activatesAt.After(now) {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no active signing key")
+}
+
+// verificationKey returns the key with the given ID. An unknown ID reloads the key
+// set, at most once per refresh interval, in case another instance just rotated.
+func (m *KeyManager) verificationKey(ctx context.Context, kid string) (*signingKey, error) {
+	if key := m.lookup(kid); key != nil {
+		return key, nil
+	}
+
+	m.mu.RLock()
+	stale := time.Since(m.loadedAt) >= keyRefreshInterval
+	m.mu.RUnlock()
+	if stale {
+		if err := m.load(ctx); err != nil {
+			return nil, err
+		}
+		if key := m.lookup(kid); key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (m *KeyManager) lookup(kid string) *signingKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.keys {
+		if key.id == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+// load replaces the in-memory key set with the unexpired keys in the database
+func (m *KeyManager) load(ctx context.Context) error {
+	stored, err := m.repo.ListValid(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	keys := make([]*signingKey, 0, len(stored))
+	for _, s := range stored {
+		key, err := parseSigningKey(s)
+		if err != nil {
+			return fmt.Errorf("failed to parse signing key %s: %w", s.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.loadedAt = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// rotateIfDue generates and stores a new key when the newest key is older than the
+// rotation interval or uses another algorithm. The repository makes the final
+// decision under a lock, so concurrent instances store at most one new key.
+func (m *KeyManager) rotateIfDue(ctx context.Context) error {
+	m.mu.RLock()
+	due := len(m.keys) == 0
+	if !due {
+		newest := m.keys[0] // Keys activate in the order they are created
+		due = newest.method.Alg() != m.algorithm || time.Since(newest.createdAt) >= m.rotationInterval
+	}
+	m.mu.RUnlock()
+	if !due {
+		return nil
+	}
+
+	key, err := generateSigningKey(m.algorithm)
+	if err != nil {
+		return err
+	}
+	rotated, err := m.repo.RotateIfDue(ctx, key, m.rotationInterval, keyPublishLead, m.grace)
+	if err != nil {
+		return err
+	}
+	if rotated {
+		slog.Info("Created signing key", "kid", key.ID, "algorithm", key.Algorithm, "activates_at", key.ActivatesAt)
+	}
+	return m.load(ctx)
+}
+
+// Rotate stores a new signing key regardless of the current key's age, as after a
+// suspected key compromise. Like a scheduled rotation, the new key is published
+// before it signs, and replaced keys keep verifying for the grace period.
+func (m *KeyManager) Rotate(ctx context.Context) (*models.SigningKey, error) {
+	key, err := generateSigningKey(m.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.repo.RotateIfDue(ctx, key, 0, keyPublishLead, m.grace); err != nil {
+		return nil, err
+	}
+	slog.Info("Created signing key", "kid", key.ID, "algorithm", key.Algorithm, "activates_at", key.ActivatesAt)
+	return key, m.load(ctx)
+}
+
+// generateSigningKey creates a key pair for the algorithm
+func generateSigningKey(algorithm string) (*models.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case SigningAlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case SigningAlgES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		err = fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	kid := make([]byte, 16)
+	if _, err := rand.Read(kid); err != nil {
+		return nil, err
+	}
+
+	return &models.SigningKey{
+		ID:         hex.EncodeToString(kid),
+		Algorithm:  algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+	}, nil
+}
+
+// parseSigningKey decodes a stored key and checks it matches its algorithm
+func parseSigningKey(stored *models.SigningKey) (*signingKey, error) {
+	block, _ := pem.Decode([]byte(stored.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{
+		id:          stored.ID,
+		activatesAt: stored.ActivatesAt,
+		createdAt:   stored.CreatedAt,
+	}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if stored.Algorithm != SigningAlgRS256 {
+			return nil, fmt.Errorf("RSA key stored for %s", stored.Algorithm)
+		}
+		key.method, key.private, key.public = jwt.SigningMethodRS256, private, &private.PublicKey
+	case *ecdsa.PrivateKey:
+		if stored.Algorithm != SigningAlgES256 || private.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ECDSA key does not match %s", stored.Algorithm)
+		}
+		key.method, key.private, key.public = jwt.SigningMethodES256, private, &private.PublicKey
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+	return key, nil
+}
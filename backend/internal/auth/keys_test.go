@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeSigningKeyRepo keeps signing keys in memory, rotating them the way the
+// Postgres repository does
+type fakeSigningKeyRepo struct {
+	keys []*models.SigningKey
+}
+
+func (r *fakeSigningKeyRepo) ListValid(ctx context.Context) ([]*models.SigningKey, error) {
+	var keys []*models.SigningKey
+	for _, key := range r.keys {
+		if key.ExpiresAt == nil || key.ExpiresAt.After(time.Now()) {
+			stored := *key
+			keys = append(keys, &stored)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ActivatesAt.After(keys[j].ActivatesAt) })
+	return keys, nil
+}
+
+func (r *fakeSigningKeyRepo) RotateIfDue(ctx context.Context, key *models.SigningKey, rotateAfter, publishLead, grace time.Duration) (bool, error) {
+	var latest *models.SigningKey
+	for _, stored := range r.keys {
+		if stored.ExpiresAt == nil && (latest == nil || stored.CreatedAt.After(latest.CreatedAt)) {
+			latest = stored
+		}
+	}
+	if latest != nil && latest.Algorithm == key.Algorithm && time.Since(latest.CreatedAt) < rotateAfter {
+		return false, nil
+	}
+
+	lead := publishLead
+	if latest == nil {
+		lead = 0
+	}
+	now := time.Now()
+	key.ActivatesAt, key.CreatedAt = now.Add(lead), now
+	expiresAt := key.ActivatesAt.Add(grace)
+	for _, stored := range r.keys {
+		if stored.ExpiresAt == nil {
+			stored.ExpiresAt = &expiresAt
+		}
+	}
+	stored := *key
+	r.keys = append(r.keys, &stored)
+	return true, nil
+}
+
+func (r *fakeSigningKeyRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// advance moves the stored keys back in time, as if d had passed
+func (r *fakeSigningKeyRepo) advance(d time.Duration) {
+	for _, key := range r.keys {
+		key.ActivatesAt = key.ActivatesAt.Add(-d)
+		key.CreatedAt = key.CreatedAt.Add(-d)
+		if key.ExpiresAt != nil {
+			expiresAt := key.ExpiresAt.Add(-d)
+			key.ExpiresAt = &expiresAt
+		}
+	}
+}
+
+func newTestKeyManager(t *testing.T, repo *fakeSigningKeyRepo, algorithm string) *KeyManager {
+	t.Helper()
+	m, err := NewKeyManager(repo, algorithm, 24*time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// tokenKid returns the kid header of a token
+func tokenKid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func jwksKids(t *testing.T, m *KeyManager) []string {
+	t.Helper()
+	set, err := m.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kids []string
+	for _, key := range set.Keys {
+		kids = append(kids, key.Kid)
+	}
+	return kids
+}
+
+func TestKeyManagerRotation(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeSigningKeyRepo{}
+	keys := newTestKeyManager(t, repo, SigningAlgES256)
+	jwtManager := NewJWTManager(keys, 15*time.Minute)
+	user := &models.User{ID: "u-1", Email: "ada@example.com"}
+
+	// The first key signs straight away
+	first := repo.keys[0].ID
+	oldToken, err := jwtManager.GenerateToken(user, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := tokenKid(t, oldToken); kid != first {
+		t.Errorf("token signed with %s, want %s", kid, first)
+	}
+
+	// A new key is published before it signs
+	rotated, err := keys.Rotate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kids := jwksKids(t, keys); len(kids) != 2 || kids[0] != rotated.ID || kids[1] != first {
+		t.Errorf("JWKS kids = %v, want [%s %s]", kids, rotated.ID, first)
+	}
+	token, err := jwtManager.GenerateToken(user, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := tokenKid(t, token); kid != first {
+		t.Errorf("token signed with %s before the new key activated", kid)
+	}
+
+	// Once it activates it signs, and the old key keeps verifying for the grace period
+	repo.advance(keyPublishLead)
+	if err := keys.load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	token, err = jwtManager.GenerateToken(user, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := tokenKid(t, token); kid != rotated.ID {
+		t.Errorf("token signed with %s, want the new key %s", kid, rotated.ID)
+	}
+	if _, err := jwtManager.ValidateToken(ctx, oldToken); err != nil {
+		t.Errorf("token from the old key rejected during the grace period: %v", err)
+	}
+
+	// After the grace period the old key is gone
+	repo.advance(time.Hour)
+	if err := keys.load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if kids := jwksKids(t, keys); len(kids) != 1 || kids[0] != rotated.ID {
+		t.Errorf("JWKS kids = %v, want [%s]", kids, rotated.ID)
+	}
+	if _, err := jwtManager.ValidateToken(ctx, oldToken); err == nil {
+		t.Error("token from an expired key accepted")
+	}
+}
+
+func TestKeyManagerRotatesWhenDue(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeSigningKeyRepo{}
+	keys := newTestKeyManager(t, repo, SigningAlgES256)
+
+	// Not due yet
+	if err := keys.rotateIfDue(ctx); err != nil || len(repo.keys) != 1 {
+		t.Fatalf("rotated early: %d keys, %v", len(repo.keys), err)
+	}
+
+	repo.advance(24 * time.Hour)
+	if err := keys.load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.rotateIfDue(ctx); err != nil || len(repo.keys) != 2 {
+		t.Fatalf("did not rotate when due: %d keys, %v", len(repo.keys), err)
+	}
+
+	// Changing the algorithm rotates straight away
+	rsaKeys := newTestKeyManager(t, repo, SigningAlgRS256)
+	if len(repo.keys) != 3 || repo.keys[2].Algorithm != SigningAlgRS256 {
+		t.Fatalf("keys after changing the algorithm = %d", len(repo.keys))
+	}
+	set, err := rsaKeys.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.Keys[0].Kty != "RSA" || set.Keys[0].Alg != SigningAlgRS256 {
+		t.Errorf("newest JWK = %+v", set.Keys[0])
+	}
+}
+
+func TestValidateTokenRejectsForeignKeys(t *testing.T) {
+	ctx := context.Background()
+	jwtManager := NewJWTManager(newTestKeyManager(t, &fakeSigningKeyRepo{}, SigningAlgES256), 15*time.Minute)
+	token, err := jwtManager.GenerateToken(&models.User{ID: "u-1"}, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := jwtManager.ValidateToken(ctx, token)
+	if err != nil || claims.UserID != "u-1" || claims.SessionID != "s-1" {
+		t.Fatalf("ValidateToken = %+v, %v", claims, err)
+	}
+
+	// Signed by another instance's key set
+	other := NewJWTManager(newTestKeyManager(t, &fakeSigningKeyRepo{}, SigningAlgES256), 15*time.Minute)
+	foreign, err := other.GenerateToken(&models.User{ID: "u-1"}, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwtManager.ValidateToken(ctx, foreign); err == nil {
+		t.Error("accepted a token signed with an unknown key")
+	}
+
+	// Signed with a known kid but another algorithm
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: "u-1"})
+	hmac.Header["kid"] = tokenKid(t, token)
+	forged, err := hmac.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwtManager.ValidateToken(ctx, forged); err == nil {
+		t.Error("accepted a token signed with another algorithm")
+	}
+}
+
+func TestNewKeyManagerRejectsAlgorithm(t *testing.T) {
+	if _, err := NewKeyManager(&fakeSigningKeyRepo{}, "HS256", time.Hour, time.Hour); err == nil {
+		t.Error("NewKeyManager accepted HS256")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 
 	"golang.org/x/oauth2"
@@ -28,44 +29,57 @@ type GoogleUserInfo struct {
 type OAuthConfig struct {
 	Config          *oauth2.Config
 	WorkspaceDomain string
+	FetchGroups     bool         // Whether to read the user's Google Workspace groups at login
+	HTTPClient      *http.Client // Client for requests to Google; nil uses http.DefaultClient
 }
 
 // NewGoogleOAuthConfig creates a new Google OAuth configuration
-func NewGoogleOAuthConfig(clientID, clientSecret, redirectURL, workspaceDomain string) *OAuthConfig {
+func NewGoogleOAuthConfig(clientID, clientSecret, redirectURL, workspaceDomain string, fetchGroups bool) *OAuthConfig {
+	scopes := []string{
+		"https://www.googleapis.com/auth/userinfo.email",
+		"https://www.googleapis.com/auth/userinfo.profile",
+	}
+	if fetchGroups {
+		scopes = append(scopes, GoogleGroupsScope)
+	}
+
 	return &OAuthConfig{
 		Config: &oauth2.Config{
 			ClientID:     clientID,
 			ClientSecret: clientSecret,
 			RedirectURL:  redirectURL,
-			Scopes: []string{
-				"https://www.googleapis.com/auth/userinfo.email",
-				"https://www.googleapis.com/auth/userinfo.profile",
-			},
-			Endpoint: google.Endpoint,
+			Scopes:       scopes,
+			Endpoint:     google.Endpoint,
 		},
 		WorkspaceDomain: workspaceDomain,
+		FetchGroups:     fetchGroups,
 	}
 }
 
-// GetAuthURL generates the OAuth authorization URL
-func (c *OAuthConfig) GetAuthURL(state string) string {
+// GetAuthURL generates the OAuth authorization URL, with a PKCE challenge for the flow
+func (c *OAuthConfig) GetAuthURL(flow *LoginFlow) string {
+	opts := []oauth2.AuthCodeOption{oauth2.S256ChallengeOption(flow.Verifier)}
+
 	// Add hd parameter if workspace domain is configured
-	opts := []oauth2.AuthCodeOption{}
 	if c.WorkspaceDomain != "" {
 		opts = append(opts, oauth2.SetAuthURLParam("hd", c.WorkspaceDomain))
 	}
-	return c.Config.AuthCodeURL(state, opts...)
+	return c.Config.AuthCodeURL(flow.State, opts...)
 }
 
-// ExchangeCode exchanges the authorization code for a token
-func (c *OAuthConfig) ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error) {
-	return c.Config.Exchange(ctx, code)
+// ExchangeCode exchanges the authorization code for a token, proving the flow's PKCE verifier
+func (c *OAuthConfig) ExchangeCode(ctx context.Context, code string, flow *LoginFlow) (*oauth2.Token, error) {
+	return c.Config.Exchange(c.clientContext(ctx), code, oauth2.VerifierOption(flow.Verifier))
 }
 
 // GetUserInfo retrieves user information from Google
 func (c *OAuthConfig) GetUserInfo(ctx context.Context, token *oauth2.Token) (*GoogleUserInfo, error) {
-	client := c.Config.Client(ctx, token)
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
+	client := c.Config.Client(c.clientContext(ctx), token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.googleapis.com/oauth2/v2/userinfo", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -109,3 +123,11 @@ func (c *OAuthConfig) ValidateWorkspaceDomain(userInfo *GoogleUserInfo) error {
 
 	return nil
 }
+
+// clientContext makes the oauth2 package send its requests with c.HTTPClient
+func (c *OAuthConfig) clientContext(ctx context.Context) context.Context {
+	if c.HTTPClient == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, c.HTTPClient)
+}
@@ -0,0 +1,299 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// jwksRefreshInterval limits how often an unknown key ID triggers a JWKS refetch
+const jwksRefreshInterval = time.Minute
+
+// oidcDiscovery holds the fields we use from a provider's discovery document
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider signs users in with a generic OpenID Connect provider such as
+// Okta, Keycloak or Azure AD. The discovery document is fetched on first use
+// and ID tokens are verified against the provider's published JWKS.
+type OIDCProvider struct {
+	config     config.OIDCProvider
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// NewOIDCProvider creates a provider from its configuration. A nil client uses http.DefaultClient.
+func NewOIDCProvider(cfg config.OIDCProvider, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OIDCProvider{config: cfg, httpClient: client}
+}
+
+// Name returns the provider's configured name, used in login URLs
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// DisplayName returns a human readable name for login buttons
+func (p *OIDCProvider) DisplayName() string {
+	if p.config.DisplayName != "" {
+		return p.config.DisplayName
+	}
+	return p.config.Name
+}
+
+// GetAuthURL generates the authorization URL for the provider, with a PKCE
+// challenge and nonce for the flow
+func (p *OIDCProvider) GetAuthURL(ctx context.Context, flow *LoginFlow) (string, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return p.oauth2Config(discovery).AuthCodeURL(flow.State,
+		oauth2.S256ChallengeOption(flow.Verifier),
+		oauth2.SetAuthURLParam("nonce", flow.Nonce),
+	), nil
+}
+
+// Exchange exchanges the authorization code, verifies the returned ID token and
+// extracts the user's identity from its claims. The ID token must carry the flow's nonce.
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, flow *LoginFlow) (*Identity, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+	token, err := p.oauth2Config(discovery).Exchange(ctx, code, oauth2.VerifierOption(flow.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("token response did not include an id_token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, discovery, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != flow.Nonce {
+		return nil, fmt.Errorf("id_token nonce does not match the login")
+	}
+
+	return p.identityFromClaims(claims)
+}
+
+// ValidateIdentity checks the identity against the provider's allowed domains and groups,
+// the same way ValidateWorkspaceDomain restricts Google sign-ins
+func (p *OIDCProvider) ValidateIdentity(identity *Identity) error {
+	return validateRestrictions(identity, p.config.AllowedDomains, p.config.AllowedGroups)
+}
+
+func (p *OIDCProvider) oauth2Config(discovery *oidcDiscovery) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}
+}
+
+// discover fetches and caches the provider's discovery document
+func (p *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(p.config.IssuerURL, "/")
+	var discovery oidcDiscovery
+	if err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery document issuer %q does not match %q", discovery.Issuer, p.config.IssuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document is missing required endpoints")
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience and expiry
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, discovery *oidcDiscovery, rawIDToken string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.signingKey(ctx, discovery, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	return claims, nil
+}
+
+// signingKey returns the provider's public key with the given key ID, refetching
+// the JWKS when the key is unknown so that provider key rotation is picked up
+func (p *OIDCProvider) signingKey(ctx context.Context, discovery *oidcDiscovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var jwks struct {
+		Keys []JSONWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, discovery.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue // Skip key types we cannot use
+		}
+		keys[jwk.Kid] = key
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a cached key. Tokens without a kid are accepted when the JWKS has a single key.
+func (p *OIDCProvider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// identityFromClaims maps the configured claims onto an Identity
+func (p *OIDCProvider) identityFromClaims(claims jwt.MapClaims) (*Identity, error) {
+	identity := &Identity{Provider: p.config.Name}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = lookupClaim(claims, p.config.EmailClaim).(string)
+	identity.Name, _ = lookupClaim(claims, p.config.NameClaim).(string)
+	identity.Groups = stringsClaim(lookupClaim(claims, p.config.GroupsClaim))
+
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("id_token has no sub claim")
+	}
+	if identity.Email == "" {
+		return nil, fmt.Errorf("id_token has no %s claim", p.config.EmailClaim)
+	}
+	// The email links the first sign-in to an existing account, so the provider must vouch for it
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return nil, fmt.Errorf("email address is not verified")
+	}
+	if identity.Name == "" {
+		identity.Name = identity.Email
+	}
+	return identity, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d, body: %s", resp.StatusCode, string(body))
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+// lookupClaim resolves a claim name, following dots into nested objects
+// (e.g. "realm_access.roles" for Keycloak)
+func lookupClaim(claims map[string]interface{}, name string) interface{} {
+	if name == "" {
+		return nil
+	}
+	if value, ok := claims[name]; ok {
+		return value
+	}
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	nested, ok := claims[parts[0]].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return lookupClaim(nested, parts[1])
+}
+
+// stringsClaim converts a claim that is either a string or a list of strings
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var result []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockOIDCProvider serves a discovery document, a JWKS and a token endpoint
+// that returns an ID token with the claims set by the test
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey // Published in the JWKS
+	signer *rsa.PrivateKey // Signs ID tokens
+	claims jwt.MapClaims
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDCProvider{key: key, signer: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                m.server.URL,
+			AuthorizationEndpoint: m.server.URL + "/authorize",
+			TokenEndpoint:         m.server.URL + "/token",
+			JWKSURI:               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwk, err := newJSONWebKey("test-key", "RS256", &m.key.PublicKey)
+		if err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(JSONWebKeySet{Keys: []JSONWebKey{jwk}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, m.claims)
+		token.Header["kid"] = "test-key"
+		idToken, err := token.SignedString(m.signer)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// validClaims returns the claims of an ID token the provider should accept
+func (m *mockOIDCProvider) validClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            "client-id",
+		"sub":            "user-123",
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"name":           "Ada Lovelace",
+		"groups":         []string{"recruiting"},
+		"nonce":          nonce,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+	}
+}
+
+func (m *mockOIDCProvider) provider() *OIDCProvider {
+	return NewOIDCProvider(config.OIDCProvider{
+		Name:        "mock",
+		IssuerURL:   m.server.URL,
+		ClientID:    "client-id",
+		RedirectURL: "http://localhost/callback",
+		Scopes:      []string{"openid", "email"},
+		EmailClaim:  "email",
+		NameClaim:   "name",
+		GroupsClaim: "groups",
+	}, m.server.Client())
+}
+
+func TestOIDCExchange(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	flow, err := NewLoginFlow("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.claims = m.validClaims(flow.Nonce)
+
+	identity, err := m.provider().Exchange(context.Background(), "good-code", flow)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Provider != "mock" || identity.Subject != "user-123" || identity.Email != "ada@example.com" {
+		t.Errorf("identity = %+v", identity)
+	}
+	if identity.Name != "Ada Lovelace" || len(identity.Groups) != 1 || identity.Groups[0] != "recruiting" {
+		t.Errorf("identity = %+v", identity)
+	}
+}
+
+func TestOIDCExchangeRejectsClaims(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(claims jwt.MapClaims)
+		wantErr string
+	}{
+		{"email not verified", func(c jwt.MapClaims) { c["email_verified"] = false }, "not verified"},
+		{"email_verified missing", func(c jwt.MapClaims) { delete(c, "email_verified") }, "not verified"},
+		{"email_verified as string", func(c jwt.MapClaims) { c["email_verified"] = "true" }, "not verified"},
+		{"missing sub", func(c jwt.MapClaims) { delete(c, "sub") }, "no sub claim"},
+		{"missing email", func(c jwt.MapClaims) { delete(c, "email") }, "no email claim"},
+		{"wrong nonce", func(c jwt.MapClaims) { c["nonce"] = "replayed" }, "nonce"},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other-client" }, "invalid id_token"},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, "invalid id_token"},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, "invalid id_token"},
+		{"missing exp", func(c jwt.MapClaims) { delete(c, "exp") }, "invalid id_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockOIDCProvider(t)
+			flow, err := NewLoginFlow("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.claims = m.validClaims(flow.Nonce)
+			tt.modify(m.claims)
+
+			_, err = m.provider().Exchange(context.Background(), "good-code", flow)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Exchange error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeRejectsForeignSignature(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	flow, err := NewLoginFlow("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.claims = m.validClaims(flow.Nonce)
+
+	// Sign with a key the JWKS does not publish
+	m.signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.provider().Exchange(context.Background(), "good-code", flow); err == nil {
+		t.Error("Exchange accepted an ID token with a foreign signature")
+	}
+}
+
+func TestOIDCExchangeRejectsBadCode(t *testing.T) {
+	m := newMockOIDCProvider(t)
+	flow, err := NewLoginFlow("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.claims = m.validClaims(flow.Nonce)
+
+	if _, err := m.provider().Exchange(context.Background(), "bad-code", flow); err == nil {
+		t.Error("Exchange accepted an invalid code")
+	}
+}
+
+func TestOIDCValidateIdentity(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedDomains []string
+		allowedGroups  []string
+		identity       Identity
+		wantErr        bool
+	}{
+		{"no restrictions", nil, nil, Identity{Email: "a@other.com"}, false},
+		{"allowed domain", []string{"example.com"}, nil, Identity{Email: "a@Example.com"}, false},
+		{"other domain", []string{"example.com"}, nil, Identity{Email: "a@other.com"}, true},
+		{"subdomain", []string{"example.com"}, nil, Identity{Email: "a@evil.example.com"}, true},
+		{"allowed group", nil, []string{"recruiting"}, Identity{Email: "a@x.com", Groups: []string{"Recruiting"}}, false},
+		{"no allowed group", nil, []string{"recruiting"}, Identity{Email: "a@x.com", Groups: []string{"sales"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewOIDCProvider(config.OIDCProvider{AllowedDomains: tt.allowedDomains, AllowedGroups: tt.allowedGroups}, nil)
+			err := p.ValidateIdentity(&tt.identity)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIdentity error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
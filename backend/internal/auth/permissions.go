@@ -0,0 +1,54 @@
+package auth
+
+// Named permissions granted to roles
+const (
+	PermCandidatesRead  = "candidates.read"
+	PermCandidatesWrite = "candidates.write"
+	PermCommentsWrite   = "comments.write"
+	PermSalaryRead      = "salary.read"
+	PermJobsRead        = "jobs.read"
+	PermJobsManage      = "jobs.manage"
+	PermJobsAccessAll   = "jobs.access_all" // See and manage every job regardless of hiring team
+	PermOffersRead      = "offers.read"
+	PermOffersManage    = "offers.manage"
+	PermAIUse           = "ai.use"
+	PermUsersManage     = "users.manage"
+	PermSystemManage    = "system.manage"  // Operate background jobs and other maintenance
+	PermPrivacyManage   = "privacy.manage" // Export and erase candidates' personal data
+)
+
+// AllPermissions lists every permission known to the application
+var AllPermissions = []string{
+	PermCandidatesRead,
+	PermCandidatesWrite,
+	PermCommentsWrite,
+	PermSalaryRead,
+	PermJobsRead,
+	PermJobsManage,
+	PermJobsAccessAll,
+	PermOffersRead,
+	PermOffersManage,
+	PermAIUse,
+	PermUsersManage,
+	PermSystemManage,
+	PermPrivacyManage,
+}
+
+// Built-in role names
+const (
+	RoleAdmin         = "admin"
+	RoleRecruiter     = "recruiter"
+	RoleHiringManager = "hiring_manager"
+	RoleInterviewer   = "interviewer"
+	RoleViewer        = "viewer"
+)
+
+// IsValidPermission reports whether the permission is known to the application
+func IsValidPermission(permission string) bool {
+	for _, p := range AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// sessionCacheTTL bounds how long another server instance may keep accepting
+// access tokens of a revoked session. Revocations made by this instance apply immediately.
+const sessionCacheTTL = 30 * time.Second
+
+// TokenPair is the result of signing in or refreshing a session
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	Session      *models.Session
+}
+
+// SessionManager issues short-lived access tokens tied to server-side sessions,
+// rotates refresh tokens and checks whether sessions have been revoked
+type SessionManager struct {
+	jwtManager *JWTManager
+	repo       repository.SessionRepository
+	sessionTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]sessionCacheEntry
+}
+
+type sessionCacheEntry struct {
+	userID    string
+	active    bool
+	checkedAt time.Time
+}
+
+// NewSessionManager creates a session manager. Sessions, and so refresh tokens,
+// last sessionTTL; access tokens last as long as jwtManager issues them for.
+func NewSessionManager(jwtManager *JWTManager, repo repository.SessionRepository, sessionTTL time.Duration) *SessionManager {
+	return &SessionManager{
+		jwtManager: jwtManager,
+		repo:       repo,
+		sessionTTL: sessionTTL,
+		cache:      make(map[string]sessionCacheEntry),
+	}
+}
+
+// AccessTokenTTL returns how long access tokens are valid
+func (m *SessionManager) AccessTokenTTL() time.Duration {
+	return m.jwtManager.TokenDuration()
+}
+
+// SessionTTL returns how long sessions and refresh tokens are valid
+func (m *SessionManager) SessionTTL() time.Duration {
+	return m.sessionTTL
+}
+
+// Create starts a new session for the user
+func (m *SessionManager) Create(ctx context.Context, user *models.User, userAgent, ipAddress string) (*TokenPair, error) {
+	refreshToken, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserID:    user.ID,
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		ExpiresAt: time.Now().Add(m.sessionTTL),
+	}
+	if err := m.repo.Create(ctx, session, hashToken(refreshToken)); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := m.jwtManager.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: accessToken, RefreshToken: refreshToken, Session: session}, nil
+}
+
+// Refresh rotates the refresh token and issues a new access token. loadUser is called
+// with the session's user ID so that inactive users cannot refresh. Reuse of a rotated
+// token revokes the session and returns repository.ErrRefreshTokenReused.
+func (m *SessionManager) Refresh(ctx context.Context, refreshToken string, loadUser func(ctx context.Context, userID string) (*models.User, error)) (*TokenPair, *models.User, error) {
+	newRefreshToken, err := generateSecret()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := m.repo.Rotate(ctx, hashToken(refreshToken), hashToken(newRefreshToken))
+	if err == repository.ErrRefreshTokenReused {
+		m.forget(session.ID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := loadUser(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessToken, err := m.jwtManager.GenerateToken(user, session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &TokenPair{AccessToken: accessToken, RefreshToken: newRefreshToken, Session: session}, user, nil
+}
+
+// IsActive reports whether the session exists, belongs to the user and has not
+// been revoked or expired. Results are cached briefly to keep requests cheap.
+func (m *SessionManager) IsActive(ctx context.Context, sessionID, userID string) (bool, error) {
+	m.mu.Lock()
+	entry, ok := m.cache[sessionID]
+	m.mu.Unlock()
+	if ok && time.Since(entry.checkedAt) < sessionCacheTTL {
+		return entry.active && entry.userID == userID, nil
+	}
+
+	session, err := m.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	entry = sessionCacheEntry{checkedAt: time.Now()}
+	if session != nil {
+		entry.userID = session.UserID
+		entry.active = session.IsActive()
+	}
+
+	m.mu.Lock()
+	m.cache[sessionID] = entry
+	m.pruneLocked()
+	m.mu.Unlock()
+
+	return entry.active && entry.userID == userID, nil
+}
+
+// Revoke ends a single session
+func (m *SessionManager) Revoke(ctx context.Context, sessionID, reason string) error {
+	if err := m.repo.Revoke(ctx, sessionID, reason); err != nil {
+		return err
+	}
+	m.forget(sessionID)
+	return nil
+}
+
+// RevokeAllForUser ends every session of the user, returning how many were revoked
+func (m *SessionManager) RevokeAllForUser(ctx context.Context, userID, reason string) (int64, error) {
+	count, err := m.repo.RevokeAllForUser(ctx, userID, reason)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	for id, entry := range m.cache {
+		if entry.userID == userID {
+			delete(m.cache, id)
+		}
+	}
+	m.mu.Unlock()
+
+	return count, nil
+}
+
+// ListActive returns the user's active sessions
+func (m *SessionManager) ListActive(ctx context.Context, userID string) ([]*models.Session, error) {
+	return m.repo.ListActiveByUser(ctx, userID)
+}
+
+// Get returns a session by ID, or nil if it does not exist
+func (m *SessionManager) Get(ctx context.Context, sessionID string) (*models.Session, error) {
+	return m.repo.GetByID(ctx, sessionID)
+}
+
+func (m *SessionManager) forget(sessionID string) {
+	m.mu.Lock()
+	delete(m.cache, sessionID)
+	m.mu.Unlock()
+}
+
+// pruneLocked drops stale cache entries. The caller must hold m.mu.
+func (m *SessionManager) pruneLocked() {
+	if len(m.cache) < 10000 {
+		return
+	}
+	for id, entry := range m.cache {
+		if time.Since(entry.checkedAt) >= sessionCacheTTL {
+			delete(m.cache, id)
+		}
+	}
+}
+
+// generateSecret returns a random, URL-safe token
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex encoded SHA-256 of a token, as stored in the database
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// fakeSessionRepo keeps sessions and refresh tokens in memory, rotating tokens
+// the way the Postgres repository does
+type fakeSessionRepo struct {
+	repository.SessionRepository
+	sessions map[string]*models.Session
+	tokens   map[string]*fakeRefreshToken // By hash
+}
+
+type fakeRefreshToken struct {
+	sessionID string
+	used      bool
+}
+
+func newFakeSessionRepo() *fakeSessionRepo {
+	return &fakeSessionRepo{sessions: map[string]*models.Session{}, tokens: map[string]*fakeRefreshToken{}}
+}
+
+func (r *fakeSessionRepo) Create(ctx context.Context, session *models.Session, refreshTokenHash string) error {
+	session.ID = fmt.Sprintf("s-%d", len(r.sessions)+1)
+	session.CreatedAt = time.Now()
+	r.sessions[session.ID] = session
+	r.tokens[refreshTokenHash] = &fakeRefreshToken{sessionID: session.ID}
+	return nil
+}
+
+func (r *fakeSessionRepo) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	if session, ok := r.sessions[id]; ok {
+		copied := *session
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *fakeSessionRepo) Rotate(ctx context.Context, refreshTokenHash, newRefreshTokenHash string) (*models.Session, error) {
+	token, ok := r.tokens[refreshTokenHash]
+	if !ok {
+		return nil, repository.ErrRefreshTokenInvalid
+	}
+	session := r.sessions[token.sessionID]
+	if !session.IsActive() {
+		return nil, repository.ErrRefreshTokenInvalid
+	}
+	if token.used {
+		r.revoke(session, repository.SessionRevokedReuse)
+		return session, repository.ErrRefreshTokenReused
+	}
+	token.used = true
+	r.tokens[newRefreshTokenHash] = &fakeRefreshToken{sessionID: session.ID}
+	return session, nil
+}
+
+func (r *fakeSessionRepo) Revoke(ctx context.Context, id, reason string) error {
+	if session, ok := r.sessions[id]; ok {
+		r.revoke(session, reason)
+	}
+	return nil
+}
+
+func (r *fakeSessionRepo) revoke(session *models.Session, reason string) {
+	now := time.Now()
+	session.RevokedAt, session.RevokedReason = &now, reason
+}
+
+func newTestSessionManager(t *testing.T) (*SessionManager, *fakeSessionRepo) {
+	t.Helper()
+	repo := newFakeSessionRepo()
+	jwtManager := NewJWTManager(newTestKeyManager(t, &fakeSigningKeyRepo{}, SigningAlgES256), 15*time.Minute)
+	return NewSessionManager(jwtManager, repo, 24*time.Hour), repo
+}
+
+func TestSessionRefreshRotation(t *testing.T) {
+	ctx := context.Background()
+	sessions, repo := newTestSessionManager(t)
+	user := &models.User{ID: "u-1", Email: "ada@example.com"}
+	loadUser := func(ctx context.Context, userID string) (*models.User, error) {
+		return user, nil
+	}
+
+	first, err := sessions.Create(ctx, user, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := sessions.jwtManager.ValidateToken(ctx, first.AccessToken)
+	if err != nil || claims.SessionID != first.Session.ID {
+		t.Fatalf("access token claims = %+v, %v", claims, err)
+	}
+	if _, ok := repo.tokens[first.RefreshToken]; ok {
+		t.Error("refresh token stored in the clear")
+	}
+
+	// Each refresh issues a new refresh token for the same session
+	second, _, err := sessions.Refresh(ctx, first.RefreshToken, loadUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.Session.ID != first.Session.ID {
+		t.Errorf("refresh did not rotate: %+v", second)
+	}
+	third, _, err := sessions.Refresh(ctx, second.RefreshToken, loadUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The session is cached as active, then a rotated token is replayed
+	if active, err := sessions.IsActive(ctx, first.Session.ID, user.ID); err != nil || !active {
+		t.Fatalf("IsActive = %v, %v", active, err)
+	}
+	if _, _, err := sessions.Refresh(ctx, first.RefreshToken, loadUser); err != repository.ErrRefreshTokenReused {
+		t.Fatalf("replayed refresh error = %v, want ErrRefreshTokenReused", err)
+	}
+
+	// The replay revokes the whole session at once, including the latest token
+	if active, err := sessions.IsActive(ctx, first.Session.ID, user.ID); err != nil || active {
+		t.Errorf("IsActive after reuse = %v, %v", active, err)
+	}
+	if _, _, err := sessions.Refresh(ctx, third.RefreshToken, loadUser); err != repository.ErrRefreshTokenInvalid {
+		t.Errorf("refresh after reuse error = %v, want ErrRefreshTokenInvalid", err)
+	}
+	if reason := repo.sessions[first.Session.ID].RevokedReason; reason != repository.SessionRevokedReuse {
+		t.Errorf("revoked reason = %q", reason)
+	}
+}
+
+func TestSessionRefreshChecksUser(t *testing.T) {
+	ctx := context.Background()
+	sessions, _ := newTestSessionManager(t)
+	user := &models.User{ID: "u-1"}
+
+	pair, err := sessions.Create(ctx, user, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errDeactivated := fmt.Errorf("deactivated")
+	_, _, err = sessions.Refresh(ctx, pair.RefreshToken, func(ctx context.Context, userID string) (*models.User, error) {
+		if userID != user.ID {
+			t.Errorf("loadUser called with %q", userID)
+		}
+		return nil, errDeactivated
+	})
+	if err != errDeactivated {
+		t.Errorf("error = %v, want the loadUser error", err)
+	}
+
+	if _, _, err := sessions.Refresh(ctx, "not-a-token", nil); err != repository.ErrRefreshTokenInvalid {
+		t.Errorf("unknown token error = %v, want ErrRefreshTokenInvalid", err)
+	}
+}
+
+func TestSessionRevoke(t *testing.T) {
+	ctx := context.Background()
+	sessions, _ := newTestSessionManager(t)
+	user := &models.User{ID: "u-1"}
+
+	pair, err := sessions.Create(ctx, user, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := sessions.IsActive(ctx, pair.Session.ID, user.ID); !active {
+		t.Fatal("new session is not active")
+	}
+	if active, _ := sessions.IsActive(ctx, pair.Session.ID, "u-2"); active {
+		t.Error("session is active for another user")
+	}
+
+	// Revocations by this instance apply despite the cache
+	if err := sessions.Revoke(ctx, pair.Session.ID, repository.SessionRevokedLogout); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := sessions.IsActive(ctx, pair.Session.ID, user.ID); active {
+		t.Error("revoked session is still active")
+	}
+}
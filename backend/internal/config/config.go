@@ -1,49 +1,330 @@
 package config
 
 import (
-	"fmt"
-	"os"
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/encryption"
+	"github.com/candidate-organizer/backend/internal/ratelimit"
 )
 
 // Config holds all application configuration
 type Config struct {
 	Port              string
 	DatabaseURL       string
+	DBMaxOpenConns    int // Connection pool size
+	DBMaxIdleConns    int // Connections kept open while idle
 	GoogleClientID    string
 	GoogleClientSecret string
 	GoogleRedirectURL string
 	WorkspaceDomain   string
-	JWTSecret         string
+	GoogleFetchGroups bool // Read Google Workspace groups at login for group role mappings
+	JWTSigningAlg     string        // "ES256" or "RS256"
+	JWTKeyRotation    time.Duration // How often a new signing key is created
+	JWTKeyGrace       time.Duration // How long replaced keys keep verifying tokens
 	FrontendURL       string
+	DefaultRole       string
+	InviteOnly        bool          // Only invited users may sign in
+	InvitationTTL     time.Duration // How long an invitation stays valid
+	SMTPHost          string        // Emails are logged instead of sent when empty
+	SMTPPort          string
+	SMTPUsername      string
+	SMTPPassword      string
+	SMTPFrom          string
+	OIDCProviders     []OIDCProvider
+	CookieSecure      bool          // Send cookies over HTTPS only
+	CookieDomain      string        // Empty scopes cookies to the API host
+	AccessTokenTTL    time.Duration // Lifetime of access tokens
+	SessionTTL        time.Duration // Lifetime of sessions and their refresh tokens
+	RateLimitStore    string        // "memory" or "postgres" (shared by all instances)
+	RateLimits        map[string]ratelimit.Limit // Per route group: default, auth, ai and upload
+	TrustProxy        bool          // Read the client IP from X-Forwarded-For
+	LogFormat         string        // "json" or "text"
+	LogLevel          string        // debug, info, warn or error
+	MetricsToken      string        // Bearer token required on /metrics; empty leaves it open
+	TraceExporter     string        // "none", "otlp" or "stdout"
+	HTTPReadTimeout   time.Duration // Time to read a whole request, including uploads
+	HTTPWriteTimeout  time.Duration // Time to write a response; streamed chats have none and end at shutdown
+	HTTPIdleTimeout   time.Duration // How long keep-alive connections wait for the next request
+	ShutdownTimeout   time.Duration // How long in-flight requests may finish after SIGTERM
+	JobWorkerConcurrency int  // Background jobs each worker runs at once
+	JobWorkersInProcess  bool // Run a background job worker inside the API server
+	SchedulerEnabled     bool           // Run scheduled tasks; each occurrence runs on one instance
+	SchedulerLocation    *time.Location // Time zone of task schedules
+	StaleCandidateAfter  time.Duration  // Inactivity after which candidates are included in reminders
+	ResumeStorageDir     string         // Directory holding uploaded resumes; empty keeps only their URLs
+	EncryptionMasterKeys []MasterKey    // Keys wrapping the data keys, current first; empty disables encryption
+	EncryptedColumns     []string       // Candidate columns encrypted at rest
+
+	settings map[string]Setting // Where each value came from, for config print
+}
+
+// MasterKey is a 256-bit key that wraps the data keys encrypting sensitive columns
+type MasterKey struct {
+	ID  string
+	Key []byte
 }
 
-// Load reads configuration from environment variables
+// OIDCProvider configures a generic OpenID Connect login provider. Each provider
+// named in OIDC_PROVIDERS reads its settings from OIDC_<NAME>_* variables, or an
+// oidc.<name> section of the config file.
+type OIDCProvider struct {
+	Name           string
+	DisplayName    string
+	IssuerURL      string
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string
+	Scopes         []string
+	EmailClaim     string
+	NameClaim      string
+	GroupsClaim    string
+	AllowedDomains []string // Defaults to WORKSPACE_DOMAIN; empty allows any email domain
+	AllowedGroups  []string // Empty allows any group membership
+}
+
+// Load reads configuration from the config file named by CONFIG_FILE, the
+// environment and <NAME>_FILE secret files, in increasing precedence. Every
+// invalid setting is reported in the returned error, not just the first.
 func Load() (*Config, error) {
+	src, err := newSource()
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
-		Port:              getEnv("PORT", "8080"),
-		DatabaseURL:       getEnv("DATABASE_URL", ""),
-		GoogleClientID:    getEnv("GOOGLE_CLIENT_ID", ""),
-		GoogleClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
-		GoogleRedirectURL: getEnv("GOOGLE_REDIRECT_URL", "http://localhost:8080/auth/callback"),
-		WorkspaceDomain:   getEnv("WORKSPACE_DOMAIN", ""),
-		JWTSecret:         getEnv("JWT_SECRET", ""),
-		FrontendURL:       getEnv("FRONTEND_URL", "http://localhost:3000"),
+		Port:              src.get("PORT", "8080"),
+		DatabaseURL:       src.secret("DATABASE_URL", ""),
+		DBMaxOpenConns:    src.positiveInt("DATABASE_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    src.positiveInt("DATABASE_MAX_IDLE_CONNS", 5),
+		GoogleClientID:    src.get("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret: src.secret("GOOGLE_CLIENT_SECRET", ""),
+		GoogleRedirectURL: src.get("GOOGLE_REDIRECT_URL", "http://localhost:8080/auth/callback"),
+		WorkspaceDomain:   src.get("WORKSPACE_DOMAIN", ""),
+		GoogleFetchGroups: src.bool("GOOGLE_FETCH_GROUPS", false),
+		JWTSigningAlg:     strings.ToUpper(src.get("JWT_SIGNING_ALG", "ES256")),
+		FrontendURL:       src.get("FRONTEND_URL", "http://localhost:3000"),
+		DefaultRole:       src.get("DEFAULT_ROLE", "recruiter"),
+		InviteOnly:        src.bool("INVITE_ONLY", false),
+		SMTPHost:          src.get("SMTP_HOST", ""),
+		SMTPPort:          src.get("SMTP_PORT", "587"),
+		SMTPUsername:      src.get("SMTP_USERNAME", ""),
+		SMTPPassword:      src.secret("SMTP_PASSWORD", ""),
+		SMTPFrom:          src.get("SMTP_FROM", "no-reply@localhost"),
+		CookieDomain:      src.get("COOKIE_DOMAIN", ""),
+		RateLimitStore:    strings.ToLower(src.get("RATE_LIMIT_STORE", "memory")),
+		TrustProxy:        src.bool("TRUST_PROXY", false),
+		MetricsToken:      src.secret("METRICS_TOKEN", ""),
+		TraceExporter:     strings.ToLower(src.get("TRACE_EXPORTER", "none")),
 	}
 
-	// Validate required fields
-	if cfg.DatabaseURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL is required")
+	// Cookies are secure by default whenever the frontend is served over HTTPS
+	production := strings.HasPrefix(cfg.FrontendURL, "https://")
+	cfg.CookieSecure = src.bool("COOKIE_SECURE", production)
+
+	// Logs are JSON in production and readable text in development
+	defaultFormat := "text"
+	if production {
+		defaultFormat = "json"
 	}
-	if cfg.JWTSecret == "" {
-		return nil, fmt.Errorf("JWT_SECRET is required")
+	cfg.LogFormat = strings.ToLower(src.get("LOG_FORMAT", defaultFormat))
+	cfg.LogLevel = strings.ToLower(src.get("LOG_LEVEL", "info"))
+
+	cfg.InvitationTTL = time.Duration(src.positiveInt("INVITATION_TTL_HOURS", 168)) * time.Hour
+	cfg.AccessTokenTTL = time.Duration(src.positiveInt("ACCESS_TOKEN_TTL_MINUTES", 15)) * time.Minute
+	cfg.SessionTTL = time.Duration(src.positiveInt("SESSION_TTL_HOURS", 720)) * time.Hour
+	cfg.JWTKeyRotation = time.Duration(src.positiveInt("JWT_KEY_ROTATION_DAYS", 30)) * 24 * time.Hour
+	cfg.JWTKeyGrace = time.Duration(src.positiveInt("JWT_KEY_GRACE_HOURS", 24)) * time.Hour
+
+	// Tighter limits for sign-in, the AI endpoints and resume uploads
+	cfg.RateLimits = map[string]ratelimit.Limit{}
+	for _, group := range []struct{ name, fallback string }{
+		{"default", "300/m"}, {"auth", "20/m"}, {"ai", "10/m"}, {"upload", "20/m"},
+	} {
+		name := "RATE_LIMIT_" + strings.ToUpper(group.name)
+		limit, err := ratelimit.ParseLimit(src.get(name, group.fallback))
+		if err != nil {
+			src.errorf("%s: %w", name, err)
+			continue
+		}
+		cfg.RateLimits[group.name] = limit
 	}
 
+	// HTTP server timeouts. Heroku kills a dyno 30 seconds after SIGTERM.
+	cfg.HTTPReadTimeout = time.Duration(src.positiveInt("HTTP_READ_TIMEOUT_SECONDS", 60)) * time.Second
+	cfg.HTTPWriteTimeout = time.Duration(src.positiveInt("HTTP_WRITE_TIMEOUT_SECONDS", 120)) * time.Second
+	cfg.HTTPIdleTimeout = time.Duration(src.positiveInt("HTTP_IDLE_TIMEOUT_SECONDS", 120)) * time.Second
+	cfg.ShutdownTimeout = time.Duration(src.positiveInt("SHUTDOWN_TIMEOUT_SECONDS", 25)) * time.Second
+
+	// Background jobs run in the API server unless a separate worker process is used
+	cfg.JobWorkerConcurrency = src.positiveInt("JOB_WORKER_CONCURRENCY", 4)
+	cfg.JobWorkersInProcess = src.bool("JOB_WORKERS_IN_PROCESS", true)
+
+	// Recurring tasks such as digests and purges
+	cfg.SchedulerEnabled = src.bool("SCHEDULER_ENABLED", true)
+	timezone := src.get("SCHEDULER_TIMEZONE", "UTC")
+	if cfg.SchedulerLocation, err = time.LoadLocation(timezone); err != nil {
+		src.errorf("SCHEDULER_TIMEZONE: unknown time zone %q", timezone)
+	}
+	cfg.StaleCandidateAfter = time.Duration(src.positiveInt("STALE_CANDIDATE_DAYS", 14)) * 24 * time.Hour
+
+	// Uploaded files, which data subject exports and erasure include
+	cfg.ResumeStorageDir = src.get("RESUME_STORAGE_DIR", "")
+
+	// Envelope encryption of sensitive candidate columns
+	cfg.EncryptionMasterKeys = parseMasterKeys(src, src.secret("ENCRYPTION_MASTER_KEYS", ""))
+	cfg.EncryptedColumns = splitList(src.get("ENCRYPTED_COLUMNS", strings.Join(encryption.CandidateColumns, ",")))
+	if len(cfg.EncryptedColumns) == 1 && cfg.EncryptedColumns[0] == "none" {
+		cfg.EncryptedColumns = nil // Decrypts every column in the background
+	}
+
+	for _, name := range splitList(src.get("OIDC_PROVIDERS", "")) {
+		cfg.OIDCProviders = append(cfg.OIDCProviders, loadOIDCProvider(src, strings.ToLower(name), cfg.WorkspaceDomain))
+	}
+
+	validate(src, cfg)
+	if err := src.err(); err != nil {
+		return nil, err
+	}
+	cfg.settings = src.settings
 	return cfg, nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// validate checks the loaded values and how they fit together
+func validate(src *source, cfg *Config) {
+	if cfg.DatabaseURL == "" {
+		src.errorf("DATABASE_URL is required")
+	}
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		src.errorf("PORT must be a port number, got %q", cfg.Port)
+	}
+	if port, err := strconv.Atoi(cfg.SMTPPort); err != nil || port < 1 || port > 65535 {
+		src.errorf("SMTP_PORT must be a port number, got %q", cfg.SMTPPort)
+	}
+	if !strings.Contains(cfg.SMTPFrom, "@") {
+		src.errorf("SMTP_FROM must be an email address, got %q", cfg.SMTPFrom)
+	}
+	for name, value := range map[string]string{"FRONTEND_URL": cfg.FrontendURL, "GOOGLE_REDIRECT_URL": cfg.GoogleRedirectURL} {
+		if !isHTTPURL(value) {
+			src.errorf("%s must be an http or https URL, got %q", name, value)
+		}
+	}
+	if (cfg.GoogleClientID == "") != (cfg.GoogleClientSecret == "") {
+		src.errorf("GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET must be set together")
+	}
+	if cfg.DefaultRole == "" {
+		src.errorf("DEFAULT_ROLE must not be empty")
+	}
+	if cfg.DBMaxIdleConns > cfg.DBMaxOpenConns {
+		src.errorf("DATABASE_MAX_IDLE_CONNS must not exceed DATABASE_MAX_OPEN_CONNS")
+	}
+	if cfg.JWTSigningAlg != "ES256" && cfg.JWTSigningAlg != "RS256" {
+		src.errorf("JWT_SIGNING_ALG must be ES256 or RS256")
+	}
+	if cfg.LogFormat != "json" && cfg.LogFormat != "text" {
+		src.errorf("LOG_FORMAT must be json or text")
+	}
+	switch cfg.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		src.errorf("LOG_LEVEL must be debug, info, warn or error")
+	}
+	if cfg.TraceExporter != "none" && cfg.TraceExporter != "otlp" && cfg.TraceExporter != "stdout" {
+		src.errorf("TRACE_EXPORTER must be none, otlp or stdout")
+	}
+	if cfg.RateLimitStore != "memory" && cfg.RateLimitStore != "postgres" {
+		src.errorf("RATE_LIMIT_STORE must be memory or postgres")
+	}
+	for _, column := range cfg.EncryptedColumns {
+		if !slices.Contains(encryption.CandidateColumns, column) {
+			src.errorf("ENCRYPTED_COLUMNS: %q cannot be encrypted; use %s", column, strings.Join(encryption.CandidateColumns, ", "))
+		}
+	}
+	// Tokens signed just before a rotation must stay verifiable until they expire
+	if cfg.JWTKeyGrace < cfg.AccessTokenTTL {
+		src.errorf("JWT_KEY_GRACE_HOURS must be at least the access token lifetime")
+	}
+}
+
+// loadOIDCProvider reads a provider's settings. Like Google sign-ins, it only
+// accepts the workspace domain unless ALLOWED_DOMAINS says otherwise; "*" allows any domain.
+func loadOIDCProvider(src *source, name, workspaceDomain string) OIDCProvider {
+	prefix := "OIDC_" + strings.ToUpper(name) + "_"
+	provider := OIDCProvider{
+		Name:           name,
+		DisplayName:    src.get(prefix+"DISPLAY_NAME", name),
+		IssuerURL:      src.get(prefix+"ISSUER", ""),
+		ClientID:       src.get(prefix+"CLIENT_ID", ""),
+		ClientSecret:   src.secret(prefix+"CLIENT_SECRET", ""),
+		RedirectURL:    src.get(prefix+"REDIRECT_URL", ""),
+		Scopes:         splitList(src.get(prefix+"SCOPES", "openid,email,profile")),
+		EmailClaim:     src.get(prefix+"EMAIL_CLAIM", "email"),
+		NameClaim:      src.get(prefix+"NAME_CLAIM", "name"),
+		GroupsClaim:    src.get(prefix+"GROUPS_CLAIM", "groups"),
+		AllowedDomains: splitList(src.get(prefix+"ALLOWED_DOMAINS", workspaceDomain)),
+		AllowedGroups:  splitList(src.get(prefix+"ALLOWED_GROUPS", "")),
+	}
+
+	if len(provider.AllowedDomains) == 1 && provider.AllowedDomains[0] == "*" {
+		provider.AllowedDomains = nil
+	}
+
+	if name == "google" {
+		src.errorf("OIDC provider name %q is reserved", name)
+	}
+	if provider.IssuerURL == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+		src.errorf("%sISSUER, %sCLIENT_ID and %sREDIRECT_URL are required", prefix, prefix, prefix)
+	}
+	for _, value := range []string{provider.IssuerURL, provider.RedirectURL} {
+		if value != "" && !isHTTPURL(value) {
+			src.errorf("%s settings must be http or https URLs, got %q", strings.TrimSuffix(prefix, "_"), value)
+		}
+	}
+	return provider
+}
+
+// parseMasterKeys reads comma separated ID:base64 master keys. The first one
+// wraps new data keys; the others are kept until keys wrapped with them are rewrapped.
+func parseMasterKeys(src *source, value string) []MasterKey {
+	var keys []MasterKey
+	for _, item := range splitList(value) {
+		id, encoded, ok := strings.Cut(item, ":")
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		switch {
+		case !ok || id == "":
+			src.errorf("ENCRYPTION_MASTER_KEYS must be a list of ID:base64 keys")
+			continue
+		case err != nil || len(key) != 32:
+			src.errorf("ENCRYPTION_MASTER_KEYS: key %q must be 32 bytes encoded in base64", id)
+			continue
+		}
+		for _, other := range keys {
+			if other.ID == id {
+				src.errorf("ENCRYPTION_MASTER_KEYS: key ID %q is used twice", id)
+			}
+		}
+		keys = append(keys, MasterKey{ID: id, Key: key})
+	}
+	return keys
+}
+
+// isHTTPURL reports whether value is an absolute http or https URL
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// splitList splits a comma separated value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	return defaultValue
+	return items
 }
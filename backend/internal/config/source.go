@@ -0,0 +1,236 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Where a setting's value came from
+const (
+	SourceDefault    = "default"
+	SourceFile       = "file"
+	SourceEnv        = "env"
+	SourceSecretFile = "secret file"
+)
+
+// Setting is one configuration value as resolved by Load
+type Setting struct {
+	Name   string // Environment variable name, e.g. DATABASE_URL
+	Value  string
+	Source string
+	Secret bool // Hidden by Redacted
+}
+
+// Redacted returns the value with secrets hidden. URLs keep everything but
+// their password.
+func (s Setting) Redacted() string {
+	if !s.Secret || s.Value == "" {
+		return s.Value
+	}
+	if u, err := url.Parse(s.Value); err == nil && u.Scheme != "" && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+	return "[REDACTED]"
+}
+
+// source resolves settings by name. In increasing precedence a value comes from
+// the config file, the environment, or a secret file named by <NAME>_FILE in
+// either of them. Problems are collected so they can all be reported at once.
+type source struct {
+	file     map[string]string // Config file values, keyed by environment variable name
+	used     map[string]bool   // Config file keys that were read
+	settings map[string]Setting
+	errs     []error
+}
+
+// newSource reads the YAML or TOML config file named by CONFIG_FILE, if any
+func newSource() (*source, error) {
+	s := &source{file: map[string]string{}, used: map[string]bool{}, settings: map[string]Setting{}}
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("CONFIG_FILE: %w", err)
+	}
+	values := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("CONFIG_FILE must be a .yaml, .yml or .toml file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("CONFIG_FILE %s: %w", path, err)
+	}
+	if err := flatten("", values, s.file); err != nil {
+		return nil, fmt.Errorf("CONFIG_FILE %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// flatten turns nested file sections into environment variable names, so
+// database.url sets DATABASE_URL and oidc.okta.issuer sets OIDC_OKTA_ISSUER.
+// Lists become comma separated values.
+func flatten(prefix string, value any, out map[string]string) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			name := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+			if err := flatten(name, item, out); err != nil {
+				return err
+			}
+		}
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				return fmt.Errorf("%s: lists may only hold plain values", prefix)
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		out[prefix] = strings.Join(items, ",")
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+// lookup finds a setting without applying a default
+func (s *source) lookup(name string) (value, from string, ok bool) {
+	if path, pathFrom, found := s.raw(name + "_FILE"); found && path != "" {
+		// A file value the secret overrides is still a known setting
+		if _, inFile := s.file[name]; inFile {
+			s.used[name] = true
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			s.errs = append(s.errs, fmt.Errorf("%s_FILE (from %s): %w", name, pathFrom, err))
+			return "", "", false
+		}
+		return strings.TrimRight(string(data), "\r\n"), SourceSecretFile, true
+	}
+	return s.raw(name)
+}
+
+// raw reads a value from the environment or the config file
+func (s *source) raw(name string) (value, from string, ok bool) {
+	if _, inFile := s.file[name]; inFile {
+		s.used[name] = true
+	}
+	if value := os.Getenv(name); value != "" {
+		return value, SourceEnv, true
+	}
+	if value := s.file[name]; value != "" {
+		return value, SourceFile, true
+	}
+	return "", "", false
+}
+
+// get returns a setting, or defaultValue when it is unset or empty
+func (s *source) get(name, defaultValue string) string {
+	value, from, ok := s.lookup(name)
+	if !ok {
+		value, from = defaultValue, SourceDefault
+	}
+	s.settings[name] = Setting{Name: name, Value: value, Source: from}
+	return value
+}
+
+// secret is get for values that must not be printed or logged
+func (s *source) secret(name, defaultValue string) string {
+	value := s.get(name, defaultValue)
+	setting := s.settings[name]
+	setting.Secret = true
+	s.settings[name] = setting
+	return value
+}
+
+// bool reads true or false (or 1/0, t/f)
+func (s *source) bool(name string, defaultValue bool) bool {
+	value := s.get(name, strconv.FormatBool(defaultValue))
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s must be true or false, got %q", name, value))
+		return defaultValue
+	}
+	return b
+}
+
+// positiveInt reads a positive integer
+func (s *source) positiveInt(name string, defaultValue int) int {
+	value := s.get(name, strconv.Itoa(defaultValue))
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		s.errs = append(s.errs, fmt.Errorf("%s must be a positive integer, got %q", name, value))
+		return defaultValue
+	}
+	return n
+}
+
+// errorf records a validation error
+func (s *source) errorf(format string, args ...any) {
+	s.errs = append(s.errs, fmt.Errorf(format, args...))
+}
+
+// err reports every problem found, including config file keys that no setting
+// reads, which are usually typos
+func (s *source) err() error {
+	var unknown []string
+	for name := range s.file {
+		if !s.used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		s.errorf("CONFIG_FILE: unknown setting %s", name)
+	}
+	return errors.Join(s.errs...)
+}
+
+// Settings returns every setting Load read, sorted by name
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, 0, len(c.settings))
+	for _, setting := range c.settings {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Name < settings[j].Name })
+	return settings
+}
+
+// Print writes the settings as NAME=value lines annotated with their source
+func (c *Config) Print(w io.Writer, redacted bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, setting := range c.Settings() {
+		value := setting.Value
+		if redacted {
+			value = setting.Redacted()
+		}
+		fmt.Fprintf(tw, "%s=%s\t# %s\n", setting.Name, value, setting.Source)
+	}
+	return tw.Flush()
+}
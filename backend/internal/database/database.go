@@ -1,11 +1,13 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 
-	_ "github.com/lib/pq"
+	"github.com/candidate-organizer/backend/migrations"
+	"github.com/lib/pq"
 )
 
 // DB wraps the sql.DB connection
@@ -13,12 +15,15 @@ type DB struct {
 	*sql.DB
 }
 
-// New creates a new database connection
-func New(databaseURL string) (*DB, error) {
-	db, err := sql.Open("postgres", databaseURL)
+// New creates a new database connection pool of up to maxOpenConns connections,
+// keeping maxIdleConns open while idle. Statements run on it are recorded in the
+// query duration metrics.
+func New(databaseURL string, maxOpenConns, maxIdleConns int) (*DB, error) {
+	connector, err := pq.NewConnector(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
+	db := sql.OpenDB(instrumentedConnector{connector})
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
@@ -26,14 +31,30 @@ func New(databaseURL string) (*DB, error) {
 	}
 
 	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
 
-	log.Println("Database connection established")
+	slog.Info("Database connection established")
 
 	return &DB{db}, nil
 }
 
+// Ready checks that the database is reachable and has every migration this build
+// expects applied
+func (db *DB) Ready(ctx context.Context) error {
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database unreachable: %w", err)
+	}
+	pending, err := PendingMigrations(ctx, db.DB, migrations.FS)
+	if err != nil {
+		return fmt.Errorf("failed to check migrations: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d pending migrations, starting with %s", len(pending), pending[0].Name)
+	}
+	return nil
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.DB.Close()
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"log/slog"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/metrics"
+	"github.com/candidate-organizer/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// repositoryPackage prefixes the function names of repository methods
+const repositoryPackage = "/internal/repository."
+
+// slowQueryThreshold is the duration above which statements are logged as slow
+const slowQueryThreshold = 500 * time.Millisecond
+
+// tablePattern finds the table a statement reads from, inserts into or updates
+var tablePattern = regexp.MustCompile(`(?i)\b(?:from|into|update)\s+([a-z_][a-z0-9_]*)`)
+
+// instrumentedConnector wraps a driver connector so that every statement run by
+// the repositories is traced and timed in metrics.DBQueryDuration, and failed or
+// slow statements are logged with the request-scoped logger from their context
+type instrumentedConnector struct {
+	driver.Connector
+}
+
+func (c instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn}, nil
+}
+
+// instrumentedConn traces ExecContext and QueryContext and passes everything else
+// through to the driver's connection
+type instrumentedConn struct {
+	driver.Conn
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, done := startQuery(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	done(err)
+	return result, err
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, done := startQuery(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	done(err)
+	return rows, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck // Fallback for drivers without BeginTx
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// startQuery starts a span for a statement, named after its operation and table
+// and attributed to the repository method that runs it. The returned function
+// ends the span and records the statement's duration in the metrics.
+func startQuery(ctx context.Context, query string) (context.Context, func(error)) {
+	start := time.Now()
+	operation, table := queryLabels(query)
+
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName(strings.ToUpper(operation)),
+		semconv.DBQueryText(query),
+	}
+	name := strings.ToUpper(operation)
+	if table != "none" {
+		attrs = append(attrs, semconv.DBCollectionName(table))
+		name += " " + table
+	}
+	ctx, span := tracing.Tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	// Walking the stack is only worth it when the span is exported
+	if span.IsRecording() {
+		if method := repositoryMethod(); method != "" {
+			span.SetAttributes(semconv.CodeFunctionName(method))
+		}
+	}
+
+	return ctx, func(err error) {
+		if err == driver.ErrSkip {
+			span.End()
+			return
+		}
+		elapsed := time.Since(start)
+		metrics.DBQueryDuration.WithLabelValues(operation, table).Observe(elapsed.Seconds())
+		if err != nil {
+			metrics.DBQueryErrors.WithLabelValues(operation, table).Inc()
+		}
+		logQuery(ctx, operation, table, elapsed, err)
+		tracing.End(span, err)
+	}
+}
+
+// logQuery logs a failed or slow statement, so that it appears next to the
+// request's other log lines. Failures are often expected, such as unique
+// violations the repositories translate, so they are only logged at debug level.
+func logQuery(ctx context.Context, operation, table string, elapsed time.Duration, err error) {
+	logger := logging.FromContext(ctx)
+	level, message := slog.LevelDebug, "Database statement failed"
+	if err == nil {
+		if elapsed < slowQueryThreshold {
+			return
+		}
+		level, message = slog.LevelWarn, "Slow database statement"
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("operation", operation),
+		slog.String("table", table),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if method := repositoryMethod(); method != "" {
+		attrs = append(attrs, slog.String("method", method))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+// repositoryMethod returns the repository method on the call stack, such as
+// "PostgresCandidateRepository.List", or "" for statements run elsewhere
+func repositoryMethod() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if i := strings.Index(frame.Function, repositoryPackage); i >= 0 {
+			method := frame.Function[i+len(repositoryPackage):]
+			return strings.NewReplacer("(*", "", ")", "").Replace(method)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// queryLabels derives low-cardinality labels from a statement, e.g. ("select", "candidates")
+func queryLabels(query string) (operation, table string) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "unknown", "none"
+	}
+	operation = strings.ToLower(fields[0])
+	switch operation {
+	case "select", "insert", "update", "delete", "with":
+	default:
+		operation = "other"
+	}
+
+	table = "none"
+	if m := tablePattern.FindStringSubmatch(query); m != nil {
+		table = strings.ToLower(m[1])
+	}
+	return operation, table
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationLock is the advisory lock key that stops two migrate runs overlapping
+const migrationLock = 7_304_002
+
+// Migration is a migration file, such as 012_schema_migrations.sql
+type Migration struct {
+	Version int
+	Name    string
+}
+
+// Migrations lists the migration files in fsys in version order
+func Migrations(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	seen := make(map[int]string)
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s does not start with a version number", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, name)
+		}
+		seen[version] = name
+		migrations = append(migrations, Migration{Version: version, Name: name})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// PendingMigrations returns the migrations in fsys that schema_migrations does not
+// record as applied. Before that table exists, every migration is pending.
+func PendingMigrations(ctx context.Context, db *sql.DB, fsys fs.FS) ([]Migration, error) {
+	migrations, err := Migrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return migrations, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies the pending migrations in fsys in version order, each in its
+// own transaction together with its schema_migrations row, and returns them.
+//
+// A database whose tables were created before schema_migrations existed must be
+// given the last version already applied as baseline; those versions are then
+// recorded without being run. Otherwise baseline is 0.
+func Migrate(ctx context.Context, db *sql.DB, fsys fs.FS, baseline int) ([]Migration, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLock); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLock); err != nil {
+			slog.Error("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	var tracked, populated bool
+	err = conn.QueryRowContext(ctx, `
+		SELECT to_regclass('schema_migrations') IS NOT NULL, to_regclass('users') IS NOT NULL
+	`).Scan(&tracked, &populated)
+	if err != nil {
+		return nil, err
+	}
+	if !tracked && populated && baseline == 0 {
+		return nil, fmt.Errorf("the database has tables but no schema_migrations; pass the last migration version already applied as the baseline")
+	}
+
+	// The same definition as 012_schema_migrations.sql, so rows can be recorded from the first migration on
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return nil, err
+	}
+
+	migrations, err := Migrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range migrations {
+		if m.Version > baseline {
+			break
+		}
+		if err := recordMigration(ctx, conn, m); err != nil {
+			return nil, err
+		}
+	}
+
+	pending, err := PendingMigrations(ctx, db, fsys)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range pending {
+		if err := applyMigration(ctx, conn, fsys, m); err != nil {
+			return pending[:i], fmt.Errorf("migration %s failed: %w", m.Name, err)
+		}
+		slog.Info("Applied migration", "migration", m.Name)
+	}
+	return pending, nil
+}
+
+// applyMigration runs one migration file and records it
+func applyMigration(ctx context.Context, conn *sql.Conn, fsys fs.FS, m Migration) error {
+	script, err := fs.ReadFile(fsys, m.Name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			slog.Error("Failed to roll back migration", "migration", m.Name, "error", err)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING
+	`, m.Version, m.Name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordMigration marks a migration as applied without running it
+func recordMigration(ctx context.Context, conn *sql.Conn, m Migration) error {
+	_, err := conn.ExecContext(ctx, `
+		INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING
+	`, m.Version, m.Name)
+	return err
+}
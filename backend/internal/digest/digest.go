@@ -0,0 +1,136 @@
+// Package digest writes the scheduled emails that keep hiring teams up to date:
+// a daily summary of activity on their jobs and reminders about candidates who
+// have been waiting too long.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/mailer"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// Sender emails digests and reminders
+type Sender struct {
+	repo        repository.DigestRepository
+	mailer      mailer.Mailer
+	frontendURL string
+}
+
+// NewSender creates a Sender. Messages link to jobs under frontendURL.
+func NewSender(repo repository.DigestRepository, m mailer.Mailer, frontendURL string) *Sender {
+	return &Sender{repo: repo, mailer: m, frontendURL: strings.TrimRight(frontendURL, "/")}
+}
+
+// SendDaily emails each hiring team member a summary of their open jobs: the
+// candidates added and comments left since a time, and candidates with no
+// activity since staleBefore. Members with nothing to report get no email.
+// It returns how many digests were sent.
+func (s *Sender) SendDaily(ctx context.Context, since, staleBefore time.Time) (int, error) {
+	activity, err := s.repo.ListJobActivity(ctx, since, staleBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, jobs := range groupByUser(activity, func(a *models.JobActivity) string { return a.UserID }) {
+		var body strings.Builder
+		for _, a := range jobs {
+			if a.NewCandidates == 0 && a.NewComments == 0 && a.StaleCandidates == 0 {
+				continue
+			}
+			fmt.Fprintf(&body, "%s\n", a.JobTitle)
+			writeCount(&body, a.NewCandidates, "new candidate", "new candidates")
+			writeCount(&body, a.NewComments, "new comment", "new comments")
+			writeCount(&body, a.StaleCandidates, "candidate waiting for a next step", "candidates waiting for a next step")
+			fmt.Fprintf(&body, "  %s/jobs/%s\n\n", s.frontendURL, a.JobID)
+		}
+		if body.Len() == 0 {
+			continue
+		}
+
+		user := jobs[0]
+		msg := mailer.Message{
+			To:      user.UserEmail,
+			Subject: "Your daily hiring summary",
+			Body:    fmt.Sprintf("Hi %s,\n\nHere is what happened on your jobs since %s.\n\n%s", user.UserName, since.Format("Monday 15:04 MST"), body.String()),
+		}
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			logging.FromContext(ctx).Error("Failed to send digest", "user_id", user.UserID, "error", err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// SendStaleReminders emails each job owner a list of candidates on their open jobs
+// with no activity since staleBefore. It returns how many reminders were sent.
+func (s *Sender) SendStaleReminders(ctx context.Context, staleBefore time.Time) (int, error) {
+	stale, err := s.repo.ListStaleCandidates(ctx, staleBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, candidates := range groupByUser(stale, func(c *models.StaleCandidate) string { return c.OwnerID }) {
+		var body strings.Builder
+		jobID := ""
+		for _, c := range candidates {
+			if c.JobID != jobID {
+				if jobID != "" {
+					fmt.Fprintf(&body, "  %s/jobs/%s\n\n", s.frontendURL, jobID)
+				}
+				jobID = c.JobID
+				fmt.Fprintf(&body, "%s\n", c.JobTitle)
+			}
+			fmt.Fprintf(&body, "  - %s (%s, last updated %s)\n", c.CandidateName, c.Status, c.UpdatedAt.Format("January 2"))
+		}
+		fmt.Fprintf(&body, "  %s/jobs/%s\n", s.frontendURL, jobID)
+
+		owner := candidates[0]
+		msg := mailer.Message{
+			To:      owner.OwnerEmail,
+			Subject: fmt.Sprintf("%d candidates are waiting for a next step", len(candidates)),
+			Body: fmt.Sprintf("Hi %s,\n\nThese candidates on your jobs have had no updates or comments since %s:\n\n%s",
+				owner.OwnerName, staleBefore.Format("January 2"), body.String()),
+		}
+		if len(candidates) == 1 {
+			msg.Subject = "A candidate is waiting for a next step"
+		}
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			logging.FromContext(ctx).Error("Failed to send stale candidate reminder", "user_id", owner.OwnerID, "error", err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// writeCount writes a line such as "  3 new candidates", or nothing for zero
+func writeCount(b *strings.Builder, n int, singular, plural string) {
+	switch n {
+	case 0:
+	case 1:
+		fmt.Fprintf(b, "  1 %s\n", singular)
+	default:
+		fmt.Fprintf(b, "  %d %s\n", n, plural)
+	}
+}
+
+// groupByUser splits rows ordered by user into one slice per user
+func groupByUser[T any](rows []T, userID func(T) string) [][]T {
+	var groups [][]T
+	for i, row := range rows {
+		if i == 0 || userID(row) != userID(rows[i-1]) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], row)
+	}
+	return groups
+}
@@ -0,0 +1,335 @@
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// Data key purposes
+const (
+	PurposeData       = "data"        // Encrypts column values
+	PurposeBlindIndex = "blind_index" // Computes blind indexes; never rotated, so that indexes stay comparable
+)
+
+// CandidateColumns are the candidate columns that can be encrypted
+var CandidateColumns = []string{"email", "phone", "salary_expectation", "parsed_data"}
+
+const (
+	// sealedVersion follows repository.SealedPrefix in every ciphertext written now
+	sealedVersion = "v2:"
+	// legacySealedVersion marks values authenticated with their column name only.
+	// They are still read, and rewritten by Reencrypt.
+	legacySealedVersion = "v1:"
+	// keyRefreshInterval is how often the keys are reloaded, so that every
+	// instance starts writing with a new data key soon after a rotation
+	keyRefreshInterval = time.Minute
+	// reencryptBatch is how many candidates are re-encrypted in one transaction
+	reencryptBatch = 200
+)
+
+// Keyring holds the unwrapped data keys and implements repository.FieldCipher.
+// Values are sealed as "enc:v2:<data key ID>:<base64 nonce and ciphertext>",
+// authenticated with their row ID and column name so that they cannot be moved
+// between rows or columns.
+type Keyring struct {
+	repo    repository.DataKeyRepository
+	kms     KMS
+	columns []string
+
+	mu       sync.RWMutex
+	keys     map[string]cipher.AEAD // Data keys by ID
+	current  string                 // Newest data key
+	indexKey []byte
+	loadedAt time.Time
+}
+
+// NewKeyring creates a keyring that encrypts columns. Keys are loaded, and the
+// first ones created, on first use; call Init to do so at startup.
+func NewKeyring(repo repository.DataKeyRepository, kms KMS, columns []string) *Keyring {
+	columns = slices.Clone(columns)
+	slices.Sort(columns)
+	return &Keyring{repo: repo, kms: kms, columns: slices.Compact(columns)}
+}
+
+// Init creates the first data key and blind index key if there are none, and
+// loads the keys
+func (k *Keyring) Init(ctx context.Context) error {
+	for _, purpose := range []string{PurposeData, PurposeBlindIndex} {
+		key, err := k.newKey(ctx, purpose)
+		if err != nil {
+			return err
+		}
+		created, err := k.repo.CreateIfMissing(ctx, key)
+		if err != nil {
+			return err
+		}
+		if created {
+			slog.Info("Created encryption key", "purpose", purpose, "key_id", key.ID, "master_key_id", key.MasterKeyID)
+		}
+	}
+	return k.load(ctx)
+}
+
+// Sealer returns the current data key
+func (k *Keyring) Sealer(ctx context.Context) (repository.FieldSealer, error) {
+	if err := k.refresh(ctx); err != nil {
+		return nil, err
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return &sealer{id: k.current, aead: k.keys[k.current], columns: k.columns}, nil
+}
+
+// Open decrypts a sealed value. Keys created by another instance since the last
+// load are loaded on demand.
+func (k *Keyring) Open(ctx context.Context, rowID, column, value string) (string, error) {
+	var id, ciphertext string
+	additionalData := sealedAdditionalData(rowID, column)
+	rest, ok := strings.CutPrefix(value, repository.SealedPrefix+sealedVersion)
+	if !ok {
+		if rest, ok = strings.CutPrefix(value, repository.SealedPrefix+legacySealedVersion); ok {
+			additionalData = []byte(column)
+		}
+	}
+	if ok {
+		id, ciphertext, ok = strings.Cut(rest, ":")
+	}
+	if !ok {
+		return "", fmt.Errorf("%s is not a sealed value", column)
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a sealed value: %w", column, err)
+	}
+
+	if err := k.refresh(ctx); err != nil {
+		return "", err
+	}
+	k.mu.RLock()
+	aead, ok := k.keys[id]
+	k.mu.RUnlock()
+	if !ok {
+		if err := k.load(ctx); err != nil {
+			return "", err
+		}
+		k.mu.RLock()
+		aead, ok = k.keys[id]
+		k.mu.RUnlock()
+		if !ok {
+			return "", fmt.Errorf("%s is encrypted with unknown data key %s", column, id)
+		}
+	}
+
+	plaintext, err := open(aead, raw, additionalData)
+	if err != nil {
+		return "", fmt.Errorf("decrypting %s: %w", column, err)
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns the hex HMAC-SHA256 of a trimmed, lowercased value
+func (k *Keyring) BlindIndex(ctx context.Context, value string) (string, error) {
+	if err := k.refresh(ctx); err != nil {
+		return "", err
+	}
+	k.mu.RLock()
+	mac := hmac.New(sha256.New, k.indexKey)
+	k.mu.RUnlock()
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Rotate creates a new data key, which encrypts everything written from now on,
+// and rewraps every key with the current master key. Existing values are
+// re-encrypted in the background.
+func (k *Keyring) Rotate(ctx context.Context) (*models.DataKey, error) {
+	key, err := k.newKey(ctx, PurposeData)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.repo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	if _, err := k.Rewrap(ctx); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Rewrap wraps the keys wrapped with an older master key again with the current
+// one, and returns them. The older master key can be removed from the
+// configuration afterwards.
+func (k *Keyring) Rewrap(ctx context.Context) ([]*models.DataKey, error) {
+	keys, err := k.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rewrapped []*models.DataKey
+	for _, key := range keys {
+		if key.MasterKeyID == k.kms.CurrentKeyID() {
+			continue
+		}
+		plaintext, err := k.kms.Unwrap(ctx, key.MasterKeyID, key.WrappedKey)
+		if err != nil {
+			return rewrapped, fmt.Errorf("unwrapping data key %s: %w", key.ID, err)
+		}
+		wrapped, err := k.kms.Wrap(ctx, plaintext)
+		if err != nil {
+			return rewrapped, err
+		}
+		if err := k.repo.Rewrap(ctx, key.ID, wrapped, k.kms.CurrentKeyID()); err != nil {
+			return rewrapped, err
+		}
+		key.WrappedKey, key.MasterKeyID = wrapped, k.kms.CurrentKeyID()
+		rewrapped = append(rewrapped, key)
+	}
+	return rewrapped, k.load(ctx)
+}
+
+// Reencrypt rewrites candidates that are not encrypted with the current data key
+// and columns, then retires the data keys no longer in use. It returns how many
+// candidates it rewrote. If ctx is done first, the next run carries on.
+func (k *Keyring) Reencrypt(ctx context.Context, candidateRepo repository.CandidateRepository) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := candidateRepo.Reencrypt(ctx, reencryptBatch)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < reencryptBatch {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		return total, nil
+	}
+
+	k.mu.RLock()
+	current := k.current
+	k.mu.RUnlock()
+	retired, err := k.repo.RetireUnused(ctx, current)
+	if err != nil {
+		return total, err
+	}
+	if retired > 0 {
+		slog.Info("Retired unused data keys", "count", retired)
+	}
+	return total, nil
+}
+
+// newKey generates a random key wrapped with the current master key
+func (k *Keyring) newKey(ctx context.Context, purpose string) (*models.DataKey, error) {
+	plaintext := make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, err
+	}
+	wrapped, err := k.kms.Wrap(ctx, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DataKey{Purpose: purpose, WrappedKey: wrapped, MasterKeyID: k.kms.CurrentKeyID()}, nil
+}
+
+// refresh loads the keys if they have not been loaded for keyRefreshInterval,
+// creating the first ones if this is the first load
+func (k *Keyring) refresh(ctx context.Context) error {
+	k.mu.RLock()
+	loadedAt := k.loadedAt
+	k.mu.RUnlock()
+	switch {
+	case loadedAt.IsZero():
+		return k.Init(ctx)
+	case time.Since(loadedAt) > keyRefreshInterval:
+		return k.load(ctx)
+	}
+	return nil
+}
+
+// load reads and unwraps every key
+func (k *Keyring) load(ctx context.Context) error {
+	keys, err := k.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("loading data keys: %w", err)
+	}
+
+	aeads := make(map[string]cipher.AEAD, len(keys))
+	var current string
+	var indexKey []byte
+	for _, key := range keys {
+		plaintext, err := k.kms.Unwrap(ctx, key.MasterKeyID, key.WrappedKey)
+		if err != nil {
+			return fmt.Errorf("unwrapping data key %s: %w", key.ID, err)
+		}
+		if key.Purpose == PurposeBlindIndex {
+			indexKey = plaintext
+			continue
+		}
+		if aeads[key.ID], err = newAEAD(plaintext); err != nil {
+			return fmt.Errorf("data key %s: %w", key.ID, err)
+		}
+		if current == "" && key.RetiredAt == nil {
+			current = key.ID // Keys are listed newest first
+		}
+	}
+	if current == "" || indexKey == nil {
+		return fmt.Errorf("no data keys; they are created when the server starts")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = aeads
+	k.current = current
+	k.indexKey = indexKey
+	k.loadedAt = time.Now()
+	return nil
+}
+
+// sealer encrypts the configured columns with one data key
+type sealer struct {
+	id      string
+	aead    cipher.AEAD
+	columns []string
+}
+
+func (s *sealer) KeyID() string {
+	return s.id
+}
+
+func (s *sealer) Columns() []string {
+	return s.columns
+}
+
+func (s *sealer) Seal(rowID, column, value string) (string, error) {
+	if value == "" || !slices.Contains(s.columns, column) {
+		return value, nil
+	}
+	if rowID == "" {
+		return "", fmt.Errorf("sealing %s requires the row ID", column)
+	}
+	ciphertext, err := seal(s.aead, []byte(value), sealedAdditionalData(rowID, column))
+	if err != nil {
+		return "", err
+	}
+	return repository.SealedPrefix + sealedVersion + s.id + ":" + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// sealedAdditionalData is what a value is authenticated with besides its key:
+// the column and the ID of its row
+func sealedAdditionalData(rowID, column string) []byte {
+	return []byte(column + "/" + rowID)
+}
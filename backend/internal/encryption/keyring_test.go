@@ -0,0 +1,269 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// fakeDataKeyRepo keeps data keys in memory
+type fakeDataKeyRepo struct {
+	keys []*models.DataKey // Oldest first
+}
+
+func (r *fakeDataKeyRepo) List(ctx context.Context) ([]*models.DataKey, error) {
+	keys := make([]*models.DataKey, 0, len(r.keys))
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		key := *r.keys[i]
+		keys = append(keys, &key)
+	}
+	return keys, nil
+}
+
+func (r *fakeDataKeyRepo) Create(ctx context.Context, key *models.DataKey) error {
+	key.ID = fmt.Sprintf("key-%d", len(r.keys)+1)
+	key.CreatedAt = time.Now()
+	stored := *key
+	r.keys = append(r.keys, &stored)
+	return nil
+}
+
+func (r *fakeDataKeyRepo) CreateIfMissing(ctx context.Context, key *models.DataKey) (bool, error) {
+	for _, existing := range r.keys {
+		if existing.Purpose == key.Purpose && existing.RetiredAt == nil {
+			return false, nil
+		}
+	}
+	return true, r.Create(ctx, key)
+}
+
+func (r *fakeDataKeyRepo) Rewrap(ctx context.Context, id string, wrappedKey []byte, masterKeyID string) error {
+	for _, key := range r.keys {
+		if key.ID == id {
+			key.WrappedKey, key.MasterKeyID = wrappedKey, masterKeyID
+		}
+	}
+	return nil
+}
+
+func (r *fakeDataKeyRepo) RetireUnused(ctx context.Context, currentID string) (int64, error) {
+	return 0, nil
+}
+
+func newTestKMS(t *testing.T, current string, ids ...string) *LocalKMS {
+	t.Helper()
+	keys := map[string][]byte{}
+	for _, id := range ids {
+		keys[id] = bytes.Repeat([]byte(id[:1]), 32)
+	}
+	kms, err := NewLocalKMS(current, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kms
+}
+
+func newTestKeyring(t *testing.T, repo *fakeDataKeyRepo, kms KMS) *Keyring {
+	t.Helper()
+	k := NewKeyring(repo, kms, CandidateColumns)
+	if err := k.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func mustSeal(t *testing.T, k *Keyring, rowID, column, value string) string {
+	t.Helper()
+	sealer, err := k.Sealer(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := sealer.Seal(rowID, column, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealed
+}
+
+func TestSealAndOpen(t *testing.T) {
+	ctx := context.Background()
+	k := newTestKeyring(t, &fakeDataKeyRepo{}, newTestKMS(t, "a", "a"))
+
+	sealed := mustSeal(t, k, "row-1", "email", "ada@example.com")
+	if !strings.HasPrefix(sealed, repository.SealedPrefix+sealedVersion) || strings.Contains(sealed, "ada@example.com") {
+		t.Fatalf("sealed value = %q", sealed)
+	}
+	if again := mustSeal(t, k, "row-1", "email", "ada@example.com"); again == sealed {
+		t.Error("sealing twice gave the same ciphertext; nonces must be random")
+	}
+
+	got, err := k.Open(ctx, "row-1", "email", sealed)
+	if err != nil || got != "ada@example.com" {
+		t.Errorf("Open = %q, %v", got, err)
+	}
+
+	// Ciphertext is bound to its row and column
+	if _, err := k.Open(ctx, "row-2", "email", sealed); err == nil {
+		t.Error("opened a value copied to another row")
+	}
+	if _, err := k.Open(ctx, "row-1", "phone", sealed); err == nil {
+		t.Error("opened a value copied to another column")
+	}
+
+	// Tampering is detected
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if tampered == sealed {
+		tampered = sealed[:len(sealed)-2] + "BB"
+	}
+	if _, err := k.Open(ctx, "row-1", "email", tampered); err == nil {
+		t.Error("opened a tampered value")
+	}
+	if _, err := k.Open(ctx, "row-1", "email", "enc:v9:x:y"); err == nil {
+		t.Error("opened a value with an unknown version")
+	}
+}
+
+func TestSealLeavesOtherValues(t *testing.T) {
+	k := NewKeyring(&fakeDataKeyRepo{}, newTestKMS(t, "a", "a"), []string{"email"})
+	if err := k.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mustSeal(t, k, "row-1", "phone", "+1 555 123 4567"); got != "+1 555 123 4567" {
+		t.Errorf("unencrypted column sealed as %q", got)
+	}
+	if got := mustSeal(t, k, "row-1", "email", ""); got != "" {
+		t.Errorf("empty value sealed as %q", got)
+	}
+
+	sealer, err := k.Sealer(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sealer.Seal("", "email", "ada@example.com"); err == nil {
+		t.Error("sealed a value without a row ID")
+	}
+}
+
+func TestOpenLegacyValues(t *testing.T) {
+	ctx := context.Background()
+	k := newTestKeyring(t, &fakeDataKeyRepo{}, newTestKMS(t, "a", "a"))
+
+	// Values written before row binding are authenticated with the column only
+	k.mu.RLock()
+	id, aead := k.current, k.keys[k.current]
+	k.mu.RUnlock()
+	ciphertext, err := seal(aead, []byte("ada@example.com"), []byte("email"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := repository.SealedPrefix + legacySealedVersion + id + ":" + base64.RawStdEncoding.EncodeToString(ciphertext)
+
+	got, err := k.Open(ctx, "row-1", "email", legacy)
+	if err != nil || got != "ada@example.com" {
+		t.Errorf("Open(legacy) = %q, %v", got, err)
+	}
+	if _, err := k.Open(ctx, "row-1", "phone", legacy); err == nil {
+		t.Error("opened a legacy value copied to another column")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeDataKeyRepo{}
+	k := newTestKeyring(t, repo, newTestKMS(t, "a", "a"))
+
+	before := mustSeal(t, k, "row-1", "phone", "+1 555 123 4567")
+	oldSealer, _ := k.Sealer(ctx)
+
+	key, err := k.Rotate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSealer, err := k.Sealer(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newSealer.KeyID() != key.ID || newSealer.KeyID() == oldSealer.KeyID() {
+		t.Errorf("sealer key = %s, want the new key %s", newSealer.KeyID(), key.ID)
+	}
+
+	after := mustSeal(t, k, "row-1", "phone", "+1 555 123 4567")
+	for _, sealed := range []string{before, after} {
+		if got, err := k.Open(ctx, "row-1", "phone", sealed); err != nil || got != "+1 555 123 4567" {
+			t.Errorf("Open(%q) = %q, %v", sealed, got, err)
+		}
+	}
+
+	// Another instance picks up the new key when it meets a value sealed with it
+	other := newTestKeyring(t, repo, newTestKMS(t, "a", "a"))
+	if got, err := other.Open(ctx, "row-1", "phone", after); err != nil || got != "+1 555 123 4567" {
+		t.Errorf("other instance Open = %q, %v", got, err)
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeDataKeyRepo{}
+	k := newTestKeyring(t, repo, newTestKMS(t, "a", "a"))
+	sealed := mustSeal(t, k, "row-1", "email", "ada@example.com")
+	index, err := k.BlindIndex(ctx, "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotate the master key: b wraps from now on, a still unwraps
+	rotated := newTestKeyring(t, repo, newTestKMS(t, "b", "a", "b"))
+	rewrapped, err := rotated.Rewrap(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rewrapped) != 2 {
+		t.Errorf("rewrapped %d keys, want the data and blind index keys", len(rewrapped))
+	}
+	for _, key := range repo.keys {
+		if key.MasterKeyID != "b" {
+			t.Errorf("key %s is still wrapped with %s", key.ID, key.MasterKeyID)
+		}
+	}
+
+	// Once rewrapped, master key a can be removed
+	onlyB := newTestKeyring(t, repo, newTestKMS(t, "b", "b"))
+	if got, err := onlyB.Open(ctx, "row-1", "email", sealed); err != nil || got != "ada@example.com" {
+		t.Errorf("Open after rewrap = %q, %v", got, err)
+	}
+	if got, err := onlyB.BlindIndex(ctx, " Ada@Example.com "); err != nil || got != index {
+		t.Errorf("BlindIndex after rewrap = %q, %v; want %q", got, err, index)
+	}
+}
+
+func TestLocalKMS(t *testing.T) {
+	ctx := context.Background()
+	kms := newTestKMS(t, "a", "a", "b")
+	dataKey := bytes.Repeat([]byte{7}, 32)
+
+	wrapped, err := kms.Wrap(ctx, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := kms.Unwrap(ctx, "a", wrapped); err != nil || !bytes.Equal(got, dataKey) {
+		t.Errorf("Unwrap = %x, %v", got, err)
+	}
+	// The master key ID is authenticated
+	if _, err := kms.Unwrap(ctx, "b", wrapped); err == nil {
+		t.Error("unwrapped with the wrong master key")
+	}
+	if _, err := kms.Unwrap(ctx, "c", wrapped); err == nil {
+		t.Error("unwrapped with an unknown master key")
+	}
+	if _, err := NewLocalKMS("c", map[string][]byte{"a": dataKey}); err == nil {
+		t.Error("NewLocalKMS accepted a current key that is not configured")
+	}
+}
@@ -0,0 +1,94 @@
+// Package encryption encrypts sensitive columns at rest with envelope encryption.
+// Values are encrypted with AES-GCM data keys; the data keys are stored in the
+// database wrapped by a master key that a KMS holds.
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// KMS wraps and unwraps data keys with master keys that never leave it.
+// LocalKMS holds master keys from configuration; a cloud KMS can implement the
+// same interface.
+type KMS interface {
+	// CurrentKeyID names the master key that Wrap uses
+	CurrentKeyID() string
+	// Wrap encrypts a data key with the current master key
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	// Unwrap decrypts a data key wrapped with the named master key
+	Unwrap(ctx context.Context, masterKeyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKMS wraps data keys with AES-256-GCM master keys held in memory
+type LocalKMS struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewLocalKMS creates a KMS from 32-byte master keys by ID. Data keys are
+// wrapped with current; the others only unwrap keys wrapped before a rotation.
+func NewLocalKMS(current string, keys map[string][]byte) (*LocalKMS, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("master key %q is not configured", current)
+	}
+	kms := &LocalKMS{current: current, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+		kms.keys[id] = aead
+	}
+	return kms, nil
+}
+
+func (k *LocalKMS) CurrentKeyID() string {
+	return k.current
+}
+
+func (k *LocalKMS) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	return seal(k.keys[k.current], dataKey, []byte(k.current))
+}
+
+func (k *LocalKMS) Unwrap(ctx context.Context, masterKeyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %q is not configured", masterKeyID)
+	}
+	return open(aead, wrapped, []byte(masterKeyID))
+}
+
+// newAEAD returns AES-256-GCM with key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which prefixes the result.
+// additionalData is authenticated but not encrypted.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the output of seal
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
@@ -75,6 +75,38 @@ func NewConflictError(message string) *AppError {
 	}
 }
 
+// NewPreconditionFailedError creates a 412 Precondition Failed error
+func NewPreconditionFailedError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusPreconditionFailed,
+		Message: message,
+	}
+}
+
+// NewUnsupportedMediaTypeError creates a 415 Unsupported Media Type error
+func NewUnsupportedMediaTypeError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusUnsupportedMediaType,
+		Message: message,
+	}
+}
+
+// NewPreconditionRequiredError creates a 428 Precondition Required error
+func NewPreconditionRequiredError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusPreconditionRequired,
+		Message: message,
+	}
+}
+
+// NewTooManyRequestsError creates a 429 Too Many Requests error
+func NewTooManyRequestsError(message string) *AppError {
+	return &AppError{
+		Code:    http.StatusTooManyRequests,
+		Message: message,
+	}
+}
+
 // NewInternalServerError creates a 500 Internal Server Error
 func NewInternalServerError(message string, err error) *AppError {
 	return &AppError{
@@ -2,10 +2,16 @@ package errors
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
+// ErrorRecorder is implemented by response writers that log the error behind a
+// response along with the request, such as the request logger's
+type ErrorRecorder interface {
+	RecordError(err error)
+}
+
 // ErrorResponse represents the JSON error response structure
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -21,28 +27,25 @@ type SuccessResponse struct {
 
 // WriteError writes an error response to the HTTP response writer
 func WriteError(w http.ResponseWriter, err error) {
-	var appErr *AppError
 	var statusCode int
 	var message string
 
 	// Check if it's an AppError
 	if e, ok := err.(*AppError); ok {
-		appErr = e
 		statusCode = e.Code
 		message = e.Message
 	} else {
 		// Default to internal server error for unknown errors
 		statusCode = http.StatusInternalServerError
 		message = "An internal server error occurred"
-		log.Printf("Unhandled error: %v", err)
 	}
 
 	// Log the error if it's a server error (5xx)
 	if statusCode >= 500 {
-		if appErr != nil && appErr.Err != nil {
-			log.Printf("Server error: %s - %v", message, appErr.Err)
+		if recorder, ok := w.(ErrorRecorder); ok {
+			recorder.RecordError(err)
 		} else {
-			log.Printf("Server error: %s", message)
+			slog.Error("Server error", "error", err)
 		}
 	}
 
@@ -58,7 +61,7 @@ func WriteJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
+		slog.Error("Failed to encode JSON response", "error", err)
 	}
 }
 
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/candidate-organizer/backend/internal/mailer"
+)
+
+// KindSendEmail sends a mailer.Message
+const KindSendEmail = "email.send"
+
+// QueuedMailer is a mailer.Mailer that enqueues messages for a worker to send, so
+// that requests do not wait on the SMTP server and failed sends are retried
+type QueuedMailer struct {
+	queue *Queue
+}
+
+// NewQueuedMailer creates a QueuedMailer
+func NewQueuedMailer(queue *Queue) *QueuedMailer {
+	return &QueuedMailer{queue: queue}
+}
+
+func (m *QueuedMailer) Send(ctx context.Context, msg mailer.Message) error {
+	_, err := m.queue.Enqueue(ctx, KindSendEmail, msg, EnqueueOptions{})
+	return err
+}
+
+// RegisterEmail makes the worker send queued email with m
+func RegisterEmail(w *Worker, m mailer.Mailer) {
+	Handle(w, KindSendEmail, m.Send)
+}
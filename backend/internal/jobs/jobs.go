@@ -0,0 +1,76 @@
+// Package jobs runs work off the request path. Jobs are stored in PostgreSQL and
+// claimed with SELECT ... FOR UPDATE SKIP LOCKED, so workers in any number of
+// processes share one queue.
+package jobs
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"time"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"    // Waiting for run_at, including between retries
+	StatusRunning   = "running"   // Claimed by a worker
+	StatusSucceeded = "succeeded" // Finished
+	StatusDead      = "dead"      // Failed on every attempt; only an admin retry runs it again
+)
+
+// DefaultMaxAttempts is how often a job runs before it is dead-lettered
+const DefaultMaxAttempts = 5
+
+// DefaultTimeout bounds an attempt at a job that does not set its own timeout
+const DefaultTimeout = 5 * time.Minute
+
+const (
+	// retryBaseDelay is the wait before the first retry; it doubles on each attempt
+	retryBaseDelay = 10 * time.Second
+	// retryMaxDelay caps the wait between retries
+	retryMaxDelay = time.Hour
+)
+
+// Job is a unit of background work
+type Job struct {
+	ID             string          `json:"id"`
+	Kind           string          `json:"kind"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	UniqueKey      string          `json:"unique_key,omitempty"`
+	Attempts       int             `json:"attempts"`
+	MaxAttempts    int             `json:"max_attempts"`
+	RunAt          time.Time       `json:"run_at"`
+	LockedAt       *time.Time      `json:"locked_at,omitempty"`
+	LockedBy       string          `json:"locked_by,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	FinishedAt     *time.Time      `json:"finished_at,omitempty"`
+	TimeoutSeconds int             `json:"timeout_seconds,omitempty"` // Bounds each attempt; zero uses DefaultTimeout
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// EnqueueOptions control when and how often a job runs
+type EnqueueOptions struct {
+	RunAt       time.Time     // Zero runs the job as soon as a worker is free
+	UniqueKey   string        // While a job with this key is queued or running, enqueueing another returns it instead
+	MaxAttempts int           // Zero uses DefaultMaxAttempts
+	Timeout     time.Duration // Bounds each attempt, rounded up to whole seconds; zero uses DefaultTimeout
+}
+
+// timeout returns how long an attempt at the job may run
+func (j *Job) timeout() time.Duration {
+	if j.TimeoutSeconds > 0 {
+		return time.Duration(j.TimeoutSeconds) * time.Second
+	}
+	return DefaultTimeout
+}
+
+// retryDelay is the exponential backoff after a failed attempt, with up to 20%
+// jitter so that jobs failing together do not retry together
+func retryDelay(attempt int) time.Duration {
+	delay := retryMaxDelay
+	if attempt < 20 {
+		delay = min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	}
+	return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+}
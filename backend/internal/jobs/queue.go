@@ -0,0 +1,281 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrDuplicate is returned when retrying a job whose unique key is taken by another
+// queued or running job
+var ErrDuplicate = errors.New("another job with the same unique key is queued or running")
+
+const jobColumns = `id, kind, payload, status, unique_key, attempts, max_attempts, run_at,
+		locked_at, locked_by, last_error, finished_at, created_at, updated_at, timeout_seconds`
+
+// Queue stores jobs in the background_jobs table
+type Queue struct {
+	db *sql.DB
+}
+
+// NewQueue creates a Queue
+func NewQueue(db *sql.DB) *Queue {
+	return &Queue{db: db}
+}
+
+// Enqueue adds a job whose payload is encoded as JSON. When opts.UniqueKey is
+// already held by a queued or running job, that job is returned instead.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}, opts EnqueueOptions) (*Job, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s payload: %w", kind, err)
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	var runAt, timeout interface{}
+	if !opts.RunAt.IsZero() {
+		runAt = opts.RunAt
+	}
+	if opts.Timeout > 0 {
+		timeout = int64((opts.Timeout + time.Second - 1) / time.Second)
+	}
+
+	query := `
+		INSERT INTO background_jobs (kind, payload, unique_key, max_attempts, run_at, timeout_seconds)
+		VALUES ($1, $2, $3, $4, COALESCE($5, CURRENT_TIMESTAMP), $6)
+		ON CONFLICT (unique_key) WHERE status IN ('queued', 'running') DO NOTHING
+		RETURNING ` + jobColumns
+	// The job holding the key may finish between the insert and the lookup
+	for i := 0; i < 3; i++ {
+		job, err := scanJob(q.db.QueryRowContext(ctx, query, kind, payloadJSON, nullString(opts.UniqueKey), maxAttempts, runAt, timeout))
+		if err != sql.ErrNoRows {
+			return job, err
+		}
+		job, err = scanJob(q.db.QueryRowContext(ctx, `
+			SELECT `+jobColumns+` FROM background_jobs
+			WHERE unique_key = $1 AND status IN ('queued', 'running')
+		`, opts.UniqueKey))
+		if err != sql.ErrNoRows {
+			return job, err
+		}
+	}
+	return nil, fmt.Errorf("failed to enqueue %s job with unique key %q", kind, opts.UniqueKey)
+}
+
+// Get returns the job, or nil if it does not exist
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	job, err := scanJob(q.db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM background_jobs WHERE id = $1`, id))
+	if err == sql.ErrNoRows || isInvalidID(err) {
+		return nil, nil
+	}
+	return job, err
+}
+
+// List returns jobs, newest first, optionally filtered by status and kind
+func (q *Queue) List(ctx context.Context, status, kind string, limit, offset int) ([]*Job, error) {
+	query := `
+		SELECT ` + jobColumns + ` FROM background_jobs
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR kind = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := q.db.QueryContext(ctx, query, status, kind, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// CountByStatus returns the number of jobs in each status
+func (q *Queue) CountByStatus(ctx context.Context) (map[string]int, error) {
+	rows, err := q.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM background_jobs GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+// Retry queues a dead job to run again straight away with a fresh set of attempts.
+// It returns nil if the job does not exist or is not dead.
+func (q *Queue) Retry(ctx context.Context, id string) (*Job, error) {
+	query := `
+		UPDATE background_jobs
+		SET status = 'queued', attempts = 0, run_at = CURRENT_TIMESTAMP, locked_at = NULL, locked_by = NULL, finished_at = NULL
+		WHERE id = $1 AND status = 'dead'
+		RETURNING ` + jobColumns
+	job, err := scanJob(q.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows || isInvalidID(err) {
+		return nil, nil
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, ErrDuplicate
+	}
+	return job, err
+}
+
+// DeleteFinished deletes jobs that succeeded before the given time, returning how
+// many were deleted. Dead jobs are kept until they are retried or inspected.
+func (q *Queue) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `DELETE FROM background_jobs WHERE status = 'succeeded' AND finished_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// claim marks up to limit due jobs of the given kinds as running on the worker
+func (q *Queue) claim(ctx context.Context, workerID string, kinds []string, limit int) ([]*Job, error) {
+	query := `
+		UPDATE background_jobs
+		SET status = 'running', attempts = attempts + 1, locked_at = CURRENT_TIMESTAMP, locked_by = $1
+		WHERE id IN (
+			SELECT id FROM background_jobs
+			WHERE status = 'queued' AND run_at <= CURRENT_TIMESTAMP AND kind = ANY($2)
+			ORDER BY run_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+	rows, err := q.db.QueryContext(ctx, query, workerID, pq.Array(kinds), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// complete records a successful attempt
+func (q *Queue) complete(ctx context.Context, job *Job) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE background_jobs SET status = 'succeeded', finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'running' AND locked_by = $2
+	`, job.ID, job.LockedBy)
+	return err
+}
+
+// fail records a failed attempt. The job is retried at retryAt, or dead-lettered
+// when retryAt is nil.
+func (q *Queue) fail(ctx context.Context, job *Job, cause error, retryAt *time.Time) error {
+	var err error
+	if retryAt == nil {
+		_, err = q.db.ExecContext(ctx, `
+			UPDATE background_jobs SET status = 'dead', last_error = $3, finished_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND status = 'running' AND locked_by = $2
+		`, job.ID, job.LockedBy, cause.Error())
+	} else {
+		_, err = q.db.ExecContext(ctx, `
+			UPDATE background_jobs SET status = 'queued', last_error = $3, run_at = $4
+			WHERE id = $1 AND status = 'running' AND locked_by = $2
+		`, job.ID, job.LockedBy, cause.Error(), *retryAt)
+	}
+	return err
+}
+
+// release puts back a job whose attempt was interrupted by shutdown, without
+// counting the attempt
+func (q *Queue) release(ctx context.Context, job *Job) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE background_jobs SET status = 'queued', attempts = attempts - 1, run_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'running' AND locked_by = $2
+	`, job.ID, job.LockedBy)
+	return err
+}
+
+// requeueStale recovers jobs whose worker died mid-attempt: jobs running for
+// longer than their timeout plus margin are retried, or dead-lettered if that
+// was their last attempt
+func (q *Queue) requeueStale(ctx context.Context, margin time.Duration) (int64, error) {
+	result, err := q.db.ExecContext(ctx, `
+		UPDATE background_jobs
+		SET status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'queued' END,
+			finished_at = CASE WHEN attempts >= max_attempts THEN CURRENT_TIMESTAMP END,
+			run_at = CURRENT_TIMESTAMP,
+			last_error = 'the worker stopped during the attempt'
+		WHERE status = 'running'
+			AND locked_at < CURRENT_TIMESTAMP - make_interval(secs => COALESCE(timeout_seconds, $1) + $2)
+	`, DefaultTimeout.Seconds(), margin.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (*Job, error) {
+	job := &Job{}
+	var payload []byte
+	var uniqueKey, lockedBy, lastError sql.NullString
+	var timeout sql.NullInt64
+	var lockedAt, finishedAt sql.NullTime
+	if err := row.Scan(
+		&job.ID, &job.Kind, &payload, &job.Status, &uniqueKey, &job.Attempts, &job.MaxAttempts, &job.RunAt,
+		&lockedAt, &lockedBy, &lastError, &finishedAt, &job.CreatedAt, &job.UpdatedAt, &timeout,
+	); err != nil {
+		return nil, err
+	}
+	job.Payload = payload
+	job.UniqueKey = uniqueKey.String
+	job.LockedBy = lockedBy.String
+	job.LastError = lastError.String
+	job.TimeoutSeconds = int(timeout.Int64)
+	if lockedAt.Valid {
+		job.LockedAt = &lockedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
+
+// isInvalidID reports whether err is PostgreSQL rejecting an ID that is not a UUID
+func isInvalidID(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "22P02"
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/database"
+	"github.com/candidate-organizer/backend/migrations"
+)
+
+// newTestQueue connects to the database in TEST_DATABASE_URL, skipping the test
+// when it is not set. Jobs get a kind unique to the test and are deleted afterwards.
+func newTestQueue(t *testing.T) (*Queue, string) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := database.New(url, 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := database.Migrate(ctx, db.DB, migrations.FS, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	kind := fmt.Sprintf("test.%s.%d", t.Name(), time.Now().UnixNano())
+	t.Cleanup(func() {
+		db.ExecContext(ctx, `DELETE FROM background_jobs WHERE kind = $1`, kind)
+		db.Close()
+	})
+	return NewQueue(db.DB), kind
+}
+
+// claimOne claims the test's job, failing the test if there is none
+func claimOne(t *testing.T, q *Queue, kind string) *Job {
+	t.Helper()
+	jobs, err := q.claim(context.Background(), "test-worker", []string{kind}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("claimed %d jobs, want 1", len(jobs))
+	}
+	return jobs[0]
+}
+
+func TestQueueUniqueKey(t *testing.T) {
+	q, kind := newTestQueue(t)
+	ctx := context.Background()
+	opts := EnqueueOptions{UniqueKey: kind + ":digest"}
+
+	first, err := q.Enqueue(ctx, kind, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := q.Enqueue(ctx, kind, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("second enqueue created job %s, want the queued job %s", second.ID, first.ID)
+	}
+
+	// Once the job is dead another may take the key, and the dead one cannot be retried
+	job := claimOne(t, q, kind)
+	if err := q.fail(ctx, job, fmt.Errorf("boom"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(ctx, kind, nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Retry(ctx, job.ID); err != ErrDuplicate {
+		t.Errorf("Retry error = %v, want ErrDuplicate", err)
+	}
+}
+
+func TestQueueRequeueStale(t *testing.T) {
+	q, kind := newTestQueue(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		opts        EnqueueOptions
+		startedAgo  time.Duration
+		wantStatus  string
+		wantRequeue bool
+	}{
+		{"within its own timeout", EnqueueOptions{Timeout: 30 * time.Minute}, 10 * time.Minute, StatusRunning, false},
+		{"past its own timeout", EnqueueOptions{Timeout: time.Minute}, 3 * time.Minute, StatusQueued, true},
+		{"within the default timeout", EnqueueOptions{}, 2 * time.Minute, StatusRunning, false},
+		{"past the default timeout", EnqueueOptions{}, 7 * time.Minute, StatusQueued, true},
+		{"on its last attempt", EnqueueOptions{MaxAttempts: 1}, 7 * time.Minute, StatusDead, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := q.Enqueue(ctx, kind, nil, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			job := claimOne(t, q, kind)
+			if _, err := q.db.ExecContext(ctx, `UPDATE background_jobs SET locked_at = CURRENT_TIMESTAMP - $2 * INTERVAL '1 second' WHERE id = $1`,
+				job.ID, tt.startedAgo.Seconds()); err != nil {
+				t.Fatal(err)
+			}
+
+			n, err := q.requeueStale(ctx, staleLeaseMargin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (n > 0) != tt.wantRequeue {
+				t.Errorf("requeued %d jobs", n)
+			}
+			job, err = q.Get(ctx, job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", job.Status, tt.wantStatus)
+			}
+
+			// Leave nothing claimable for the next case
+			q.db.ExecContext(ctx, `DELETE FROM background_jobs WHERE id = $1`, job.ID)
+		})
+	}
+}
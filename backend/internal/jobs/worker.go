@@ -0,0 +1,208 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/metrics"
+	"github.com/candidate-organizer/backend/internal/tracing"
+)
+
+const (
+	// pollInterval is how often an idle worker looks for due jobs
+	pollInterval = time.Second
+	// staleLeaseMargin is how long a job may stay running past its timeout before
+	// another worker assumes its worker died and requeues it
+	staleLeaseMargin = time.Minute
+	// staleCheckInterval is how often running jobs are checked for an expired lease
+	staleCheckInterval = time.Minute
+	// shutdownGrace is how long running jobs may continue after shutdown starts
+	// before they are cancelled and put back on the queue
+	shutdownGrace = 5 * time.Second
+	// storeTimeout bounds recording the outcome of an attempt
+	storeTimeout = 10 * time.Second
+)
+
+// Handler performs a job. A returned error fails the attempt; the job is retried
+// with backoff unless the error is Permanent or it was the last attempt.
+type Handler func(ctx context.Context, job *Job) error
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as one that retrying cannot fix, so the job is
+// dead-lettered straight away
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// store is the part of Queue a worker uses
+type store interface {
+	claim(ctx context.Context, workerID string, kinds []string, limit int) ([]*Job, error)
+	complete(ctx context.Context, job *Job) error
+	fail(ctx context.Context, job *Job, cause error, retryAt *time.Time) error
+	release(ctx context.Context, job *Job) error
+	requeueStale(ctx context.Context, margin time.Duration) (int64, error)
+}
+
+// Worker claims jobs from the queue and runs their handlers
+type Worker struct {
+	queue       store
+	id          string
+	concurrency int
+	handlers    map[string]Handler
+}
+
+// NewWorker creates a worker that runs up to concurrency jobs at once
+func NewWorker(queue *Queue, concurrency int) *Worker {
+	hostname, _ := os.Hostname()
+	return &Worker{
+		queue:       queue,
+		id:          fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		concurrency: max(concurrency, 1),
+		handlers:    map[string]Handler{},
+	}
+}
+
+// Register sets the handler for a kind of job. Jobs of kinds without a handler
+// are left for other workers.
+func (w *Worker) Register(kind string, handler Handler) {
+	w.handlers[kind] = handler
+}
+
+// Handle registers a handler that receives the job's payload decoded as T
+func Handle[T any](w *Worker, kind string, fn func(ctx context.Context, payload T) error) {
+	w.Register(kind, func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// Run processes jobs until ctx is cancelled, then gives running jobs a short grace
+// period to finish before cancelling them and returning them to the queue
+func (w *Worker) Run(ctx context.Context) {
+	kinds := make([]string, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	slog.Info("Background job worker started", "worker", w.id, "concurrency", w.concurrency, "kinds", kinds)
+
+	// Jobs keep running when ctx is cancelled until the grace period is over
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+	slots := make(chan struct{}, w.concurrency)
+	var running sync.WaitGroup
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	staleCheck := time.NewTicker(staleCheckInterval)
+	defer staleCheck.Stop()
+
+	for ctx.Err() == nil {
+		if free := w.concurrency - len(slots); free > 0 && len(kinds) > 0 {
+			jobs, err := w.queue.claim(ctx, w.id, kinds, free)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("Failed to claim background jobs", "error", err)
+			}
+			for _, job := range jobs {
+				slots <- struct{}{}
+				running.Add(1)
+				go func() {
+					defer func() {
+						<-slots
+						running.Done()
+					}()
+					w.run(jobCtx, job)
+				}()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-poll.C:
+		case <-staleCheck.C:
+			if n, err := w.queue.requeueStale(ctx, staleLeaseMargin); err != nil {
+				slog.Error("Failed to requeue stale background jobs", "error", err)
+			} else if n > 0 {
+				slog.Warn("Requeued background jobs whose worker stopped", "count", n)
+			}
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownGrace):
+		cancelJobs()
+		<-done
+	}
+	slog.Info("Background job worker stopped", "worker", w.id)
+}
+
+// run performs one attempt at a job and records its outcome
+func (w *Worker) run(ctx context.Context, job *Job) {
+	logger := slog.Default().With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+	ctx = logging.WithLogger(ctx, logger)
+	ctx, span := tracing.StartJob(ctx, "job "+job.Kind)
+
+	started := time.Now()
+	err := w.call(ctx, job)
+	duration := time.Since(started)
+	tracing.End(span, err)
+
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), storeTimeout)
+	defer cancel()
+
+	var storeErr error
+	switch {
+	case err == nil:
+		metrics.ObserveBackgroundJob(job.Kind, "succeeded", duration)
+		storeErr = w.queue.complete(storeCtx, job)
+	case ctx.Err() != nil:
+		// Cancelled by shutdown; another worker picks the job up again
+		logger.Warn("Background job interrupted by shutdown", "error", err)
+		storeErr = w.queue.release(storeCtx, job)
+	case job.Attempts >= job.MaxAttempts || errors.As(err, new(permanentError)):
+		metrics.ObserveBackgroundJob(job.Kind, "dead", duration)
+		logger.Error("Background job failed permanently", "error", err)
+		storeErr = w.queue.fail(storeCtx, job, err, nil)
+	default:
+		metrics.ObserveBackgroundJob(job.Kind, "retry", duration)
+		retryAt := time.Now().Add(retryDelay(job.Attempts))
+		logger.Warn("Background job failed; it will be retried", "error", err, "retry_at", retryAt)
+		storeErr = w.queue.fail(storeCtx, job, err, &retryAt)
+	}
+	if storeErr != nil {
+		logger.Error("Failed to record background job outcome", "error", storeErr)
+	}
+}
+
+// call runs the job's handler with a timeout, turning panics into errors
+func (w *Worker) call(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, job.timeout())
+	defer cancel()
+	return w.handlers[job.Kind](ctx, job)
+}
@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeStore records the outcome the worker stores for each attempt
+type fakeStore struct {
+	store
+	outcome string
+	cause   error
+	retryAt *time.Time
+}
+
+func (s *fakeStore) complete(ctx context.Context, job *Job) error {
+	s.outcome = StatusSucceeded
+	return nil
+}
+
+func (s *fakeStore) fail(ctx context.Context, job *Job, cause error, retryAt *time.Time) error {
+	s.outcome, s.cause, s.retryAt = StatusDead, cause, retryAt
+	if retryAt != nil {
+		s.outcome = StatusQueued
+	}
+	return nil
+}
+
+func (s *fakeStore) release(ctx context.Context, job *Job) error {
+	s.outcome = "released"
+	return nil
+}
+
+func newTestWorker() (*Worker, *fakeStore) {
+	queue := &fakeStore{}
+	return &Worker{queue: queue, id: "test", concurrency: 1, handlers: map[string]Handler{}}, queue
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{5, 160 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour}, // 5120s is over the cap
+		{20, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			delay := retryDelay(tt.attempt)
+			if delay < tt.base || delay > tt.base+tt.base/5 {
+				t.Errorf("retryDelay(%d) = %s, want %s plus up to 20%%", tt.attempt, delay, tt.base)
+				break
+			}
+		}
+	}
+}
+
+func TestJobTimeout(t *testing.T) {
+	if got := (&Job{}).timeout(); got != DefaultTimeout {
+		t.Errorf("timeout without timeout_seconds = %s, want %s", got, DefaultTimeout)
+	}
+	if got := (&Job{TimeoutSeconds: 1800}).timeout(); got != 30*time.Minute {
+		t.Errorf("timeout = %s, want 30m", got)
+	}
+}
+
+func TestWorkerOutcomes(t *testing.T) {
+	failure := errors.New("smtp unavailable")
+	tests := []struct {
+		name     string
+		attempts int
+		handler  Handler
+		want     string
+	}{
+		{"success", 1, func(ctx context.Context, job *Job) error { return nil }, StatusSucceeded},
+		{"failure is retried", 1, func(ctx context.Context, job *Job) error { return failure }, StatusQueued},
+		{"last attempt is dead", 5, func(ctx context.Context, job *Job) error { return failure }, StatusDead},
+		{"permanent is dead", 1, func(ctx context.Context, job *Job) error { return Permanent(failure) }, StatusDead},
+		{"panic is retried", 1, func(ctx context.Context, job *Job) error { panic("nil map") }, StatusQueued},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, queue := newTestWorker()
+			w.Register("email.send", tt.handler)
+			before := time.Now()
+			w.run(context.Background(), &Job{ID: "j-1", Kind: "email.send", Attempts: tt.attempts, MaxAttempts: 5})
+
+			if queue.outcome != tt.want {
+				t.Fatalf("outcome = %q, want %q", queue.outcome, tt.want)
+			}
+			if tt.want == StatusQueued {
+				if delay := queue.retryAt.Sub(before); delay < retryBaseDelay || delay > 2*retryBaseDelay {
+					t.Errorf("retried after %s, want about %s", delay, retryBaseDelay)
+				}
+			}
+			if tt.want == StatusDead && !errors.Is(queue.cause, failure) {
+				t.Errorf("cause = %v, want %v", queue.cause, failure)
+			}
+		})
+	}
+}
+
+func TestWorkerReleasesJobsInterruptedByShutdown(t *testing.T) {
+	w, queue := newTestWorker()
+	ctx, cancel := context.WithCancel(context.Background())
+	w.Register("email.send", func(ctx context.Context, job *Job) error {
+		cancel()
+		return ctx.Err()
+	})
+	w.run(ctx, &Job{ID: "j-1", Kind: "email.send", Attempts: 5, MaxAttempts: 5})
+	if queue.outcome != "released" {
+		t.Errorf("outcome = %q, want the job released without using up an attempt", queue.outcome)
+	}
+}
+
+func TestWorkerBoundsAttemptsByJobTimeout(t *testing.T) {
+	w, _ := newTestWorker()
+	var deadline time.Time
+	w.Register("task.run", func(ctx context.Context, job *Job) error {
+		deadline, _ = ctx.Deadline()
+		return nil
+	})
+	w.run(context.Background(), &Job{ID: "j-1", Kind: "task.run", Attempts: 1, MaxAttempts: 1, TimeoutSeconds: 1800})
+	if remaining := time.Until(deadline); remaining < 29*time.Minute || remaining > 30*time.Minute {
+		t.Errorf("attempt deadline in %s, want 30m", remaining)
+	}
+}
+
+func TestHandle(t *testing.T) {
+	type payload struct {
+		To string `json:"to"`
+	}
+	w, queue := newTestWorker()
+	var got payload
+	Handle(w, "email.send", func(ctx context.Context, p payload) error {
+		got = p
+		return nil
+	})
+
+	w.run(context.Background(), &Job{ID: "j-1", Kind: "email.send", Payload: json.RawMessage(`{"to":"a@example.com"}`), Attempts: 1, MaxAttempts: 5})
+	if queue.outcome != StatusSucceeded || got.To != "a@example.com" {
+		t.Errorf("outcome = %q, payload = %+v", queue.outcome, got)
+	}
+
+	// A payload that cannot be decoded will not decode on a retry either
+	w.run(context.Background(), &Job{ID: "j-2", Kind: "email.send", Payload: json.RawMessage(`[]`), Attempts: 1, MaxAttempts: 5})
+	if queue.outcome != StatusDead {
+		t.Errorf("outcome for an invalid payload = %q, want %q", queue.outcome, StatusDead)
+	}
+}
@@ -0,0 +1,87 @@
+// Package logging sets up structured logging with log/slog and carries the
+// request-scoped logger through contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Log output formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New creates a logger writing in the given format. Every record passes through
+// Redact so that personal data does not reach the logs.
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: Redact}
+	switch format {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unsupported log format %q", format)
+}
+
+// ParseLevel reads a level name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+type loggerKey struct{}
+type requestInfoKey struct{}
+
+// WithLogger returns a context carrying the logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the context's logger, or the default logger outside a request
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestInfo collects details that are only known once the request has been
+// handled part way, for the request's access log line
+type RequestInfo struct {
+	mu     sync.Mutex
+	userID string
+}
+
+// WithRequestInfo returns a context carrying a new RequestInfo
+func WithRequestInfo(ctx context.Context) (context.Context, *RequestInfo) {
+	info := &RequestInfo{}
+	return context.WithValue(ctx, requestInfoKey{}, info), info
+}
+
+// UserID returns the ID of the authenticated user, if any
+func (i *RequestInfo) UserID() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.userID
+}
+
+// WithUser adds the authenticated user to the context's logger and to the
+// request's access log line
+func WithUser(ctx context.Context, userID string) context.Context {
+	if info, ok := ctx.Value(requestInfoKey{}).(*RequestInfo); ok {
+		info.mu.Lock()
+		info.userID = userID
+		info.mu.Unlock()
+	}
+	return WithLogger(ctx, FromContext(ctx).With("user_id", userID))
+}
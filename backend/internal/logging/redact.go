@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Placeholders for redacted values
+const (
+	redacted      = "[REDACTED]"
+	redactedEmail = "[EMAIL]"
+	redactedPhone = "[PHONE]"
+)
+
+// sensitiveKeys are attribute key fragments whose values are never logged
+var sensitiveKeys = []string{"email", "phone", "password", "secret", "token", "authorization", "cookie"}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d ().\-]{5,}\d`)
+	datePattern  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+	ipPattern    = regexp.MustCompile(`^\d{1,3}(\.\d{1,3}){3}$`)
+)
+
+// Redact is a slog.HandlerOptions.ReplaceAttr function. It hides the values of
+// sensitive attributes, such as an "email" attribute, and masks email addresses
+// and phone numbers that appear in messages and other string values, such as
+// those of errors.
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.SourceKey {
+		return a
+	}
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, RedactString(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactString(err.Error()))
+		}
+	}
+	return a
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, fragment := range sensitiveKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactString masks the email addresses and phone numbers in s
+func RedactString(s string) string {
+	s = emailPattern.ReplaceAllString(s, redactedEmail)
+
+	matches := phonePattern.FindAllStringIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if !isPhoneNumber(s, m[0], m[1]) {
+			continue
+		}
+		b.WriteString(s[last:m[0]])
+		b.WriteString(redactedPhone)
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// isPhoneNumber reports whether s[start:end] looks like a phone number rather than
+// part of an identifier (such as a UUID), a date, a time, an IP address or a
+// plain number such as a count, a duration or a decimal
+func isPhoneNumber(s string, start, end int) bool {
+	if start > 0 && isWordByte(s[start-1]) {
+		return false
+	}
+	// A full stop may end the sentence the number is in
+	if end < len(s) && isWordByte(s[end]) && !(s[end] == '.' && (end+1 == len(s) || s[end+1] == ' ')) {
+		return false
+	}
+	if datePattern.MatchString(s[start:end]) || ipPattern.MatchString(s[start:end]) {
+		return false
+	}
+	if s[start] != '+' && !hasPhoneSeparators(s[start:end]) {
+		return false
+	}
+
+	digits := 0
+	for i := start; i < end; i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+func isWordByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		c == '-' || c == '_' || c == '.' || c == ':' || c == '/'
+}
+
+// hasPhoneSeparators reports whether digit groups in s are separated the way
+// phone numbers are written: with spaces, dashes or parentheses, or with at
+// least two dots so that decimals such as 1234567.89 do not count
+func hasPhoneSeparators(s string) bool {
+	if strings.ContainsAny(s, " -()") {
+		return true
+	}
+	return strings.Count(s, ".") >= 2
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestRedactString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"email", "invited ada@example.com to the job", "invited [EMAIL] to the job"},
+		{"email with tag", "from ada.lovelace+jobs@mail.example.co.uk", "from [EMAIL]"},
+		{"international phone", "call +44 20 7946 0958 today", "call [PHONE] today"},
+		{"compact international phone", "call +442079460958", "call [PHONE]"},
+		{"dashed phone", "phone 555-123-4567", "phone [PHONE]"},
+		{"parenthesised area code", "phone (555) 123-4567.", "phone [PHONE]."},
+		{"dotted phone", "phone 555.123.4567", "phone [PHONE]"},
+		{"phone and email", "ada@example.com, +1 555 123 4567", "[EMAIL], [PHONE]"},
+		{"bare digits", "processed 12345678 rows", "processed 12345678 rows"},
+		{"long bare digits", "duration 1500000000ns", "duration 1500000000ns"},
+		{"unix timestamp", "expires at 1735689600", "expires at 1735689600"},
+		{"decimal", "took 1234567.89 ms", "took 1234567.89 ms"},
+		{"uuid", "candidate 123e4567-e89b-12d3-a456-426614174000", "candidate 123e4567-e89b-12d3-a456-426614174000"},
+		{"date", "on 2024-01-15 10:30:00", "on 2024-01-15 10:30:00"},
+		{"ip address", "from 192.168.100.200", "from 192.168.100.200"},
+		{"short number", "status 404 after 3 retries", "status 404 after 3 retries"},
+		{"too many digits", "ref 1234 5678 9012 3456 7", "ref 1234 5678 9012 3456 7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactString(tt.in); got != tt.want {
+				t.Errorf("RedactString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{"sensitive key", slog.String("email", "ada@example.com"), redacted},
+		{"sensitive key fragment", slog.String("refresh_token", "abc"), redacted},
+		{"sensitive key case", slog.String("Authorization", "Bearer abc"), redacted},
+		{"sensitive non-string", slog.Int("phone", 5551234), redacted},
+		{"string value", slog.String("msg", "sent to ada@example.com"), "sent to [EMAIL]"},
+		{"error value", slog.Any("error", errors.New("no user +1 555 123 4567")), "no user [PHONE]"},
+		{"plain value", slog.String("route", "/api/v1/candidates/{id}"), "/api/v1/candidates/{id}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Redact(nil, tt.attr)
+			if got.Key != tt.attr.Key || got.Value.String() != tt.want {
+				t.Errorf("Redact(%v) = %v, want %s=%s", tt.attr, got, tt.attr.Key, tt.want)
+			}
+		})
+	}
+
+	// Levels and times are left alone
+	level := slog.Any(slog.LevelKey, slog.LevelInfo)
+	if got := Redact(nil, level); !got.Equal(level) {
+		t.Errorf("Redact(level) = %v", got)
+	}
+}
@@ -0,0 +1,161 @@
+// Package mergepatch applies JSON merge patches (RFC 7396), which change only the
+// fields they name: a member replaces the field's value, or removes it if it is
+// null, and objects are merged recursively.
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// ContentType is the media type of a merge patch. Requests sent as
+// application/json are accepted too.
+const ContentType = "application/merge-patch+json"
+
+// maxPatchSize bounds the request body of a patch
+const maxPatchSize = 1 << 20
+
+var (
+	// ErrUnsupportedMediaType is returned for a request that is not a merge patch
+	ErrUnsupportedMediaType = errors.New("request body must be " + ContentType)
+	// ErrNotObject is returned for a patch that is not a JSON object
+	ErrNotObject = errors.New("merge patch must be a JSON object")
+)
+
+// FieldError rejects a patch because of one of its fields
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Reason
+}
+
+// Patch is a decoded merge patch
+type Patch map[string]interface{}
+
+// Has reports whether the patch sets or removes field
+func (p Patch) Has(field string) bool {
+	_, ok := p[field]
+	return ok
+}
+
+// Read decodes the merge patch in a request body
+func Read(r *http.Request) (Patch, error) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != ContentType && mediaType != "application/json") {
+			return nil, ErrUnsupportedMediaType
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize))
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// Decode parses a merge patch
+func Decode(data []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil || patch == nil {
+		return nil, ErrNotObject
+	}
+	return patch, nil
+}
+
+// Apply returns target merged with patch. Neither is modified.
+func Apply(target, patch interface{}) interface{} {
+	patchObject, ok := asObject(patch)
+	if !ok {
+		return patch
+	}
+	targetObject, _ := asObject(target)
+	merged := make(map[string]interface{}, len(targetObject)+len(patchObject))
+	for name, value := range targetObject {
+		merged[name] = value
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(merged, name)
+			continue
+		}
+		merged[name] = Apply(merged[name], value)
+	}
+	return merged
+}
+
+// Merge applies patch to the record v points to, allowing only the writable
+// fields, named by their JSON names. The record must survive a round trip
+// through JSON; fields that the patch removes are left at their zero value. It
+// returns the fields whose values changed, sorted, and leaves v unchanged if the
+// patch is rejected.
+func Merge(v interface{}, patch Patch, writable ...string) ([]string, error) {
+	fields := make([]string, 0, len(patch))
+	for field := range patch {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if !slices.Contains(writable, field) {
+			return nil, &FieldError{Field: field, Reason: "cannot be changed"}
+		}
+	}
+
+	before, err := toObject(v)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(Apply(before, map[string]interface{}(patch)))
+	if err != nil {
+		return nil, err
+	}
+	record := reflect.New(reflect.TypeOf(v).Elem())
+	if err := json.Unmarshal(data, record.Interface()); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, &FieldError{Field: typeErr.Field, Reason: "must not be a " + typeErr.Value}
+		}
+		return nil, err
+	}
+	after, err := toObject(record.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, field := range fields {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			changed = append(changed, field)
+		}
+	}
+	reflect.ValueOf(v).Elem().Set(record.Elem())
+	return changed, nil
+}
+
+// toObject returns v as a decoded JSON object
+func toObject(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]interface{}
+	return object, json.Unmarshal(data, &object)
+}
+
+func asObject(v interface{}) (map[string]interface{}, bool) {
+	switch object := v.(type) {
+	case map[string]interface{}:
+		return object, true
+	case Patch:
+		return object, true
+	}
+	return nil, false
+}
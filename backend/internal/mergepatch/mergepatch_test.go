@@ -0,0 +1,145 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	// Examples from RFC 7396, appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		var target, patch, want interface{}
+		for _, v := range []struct {
+			s   string
+			dst *interface{}
+		}{{tt.target, &target}, {tt.patch, &patch}, {tt.want, &want}} {
+			if err := json.Unmarshal([]byte(v.s), v.dst); err != nil {
+				t.Fatal(err)
+			}
+		}
+		targetBefore, _ := json.Marshal(target)
+
+		got := Apply(target, patch)
+		if !reflect.DeepEqual(got, want) {
+			gotJSON, _ := json.Marshal(got)
+			t.Errorf("Apply(%s, %s) = %s, want %s", tt.target, tt.patch, gotJSON, tt.want)
+		}
+		if targetAfter, _ := json.Marshal(target); string(targetAfter) != string(targetBefore) {
+			t.Errorf("Apply(%s, %s) modified the target", tt.target, tt.patch)
+		}
+	}
+}
+
+type record struct {
+	Name   string                 `json:"name"`
+	Count  int                    `json:"count"`
+	Tags   []string               `json:"tags"`
+	Data   map[string]interface{} `json:"data"`
+	Secret string                 `json:"secret"`
+}
+
+func TestMerge(t *testing.T) {
+	r := &record{Name: "a", Count: 2, Tags: []string{"x"}, Data: map[string]interface{}{"k": "v", "n": "m"}, Secret: "s"}
+
+	patch, err := Decode([]byte(`{"name": "a", "count": 3, "tags": null, "data": {"k": "w", "n": null}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := Merge(r, patch, "name", "count", "tags", "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &record{Name: "a", Count: 3, Data: map[string]interface{}{"k": "w"}, Secret: "s"}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("record = %+v, want %+v", r, want)
+	}
+	// name was sent with its current value, so it did not change
+	if !reflect.DeepEqual(changed, []string{"count", "data", "tags"}) {
+		t.Errorf("changed = %v", changed)
+	}
+}
+
+func TestMergeRejects(t *testing.T) {
+	tests := []struct {
+		name, patch, field string
+	}{
+		{"field not writable", `{"secret": "t"}`, "secret"},
+		{"field not writable in other case", `{"Secret": "t"}`, "Secret"},
+		{"unknown field", `{"other": 1}`, "other"},
+		{"wrong type", `{"count": "three"}`, "count"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &record{Name: "a", Count: 2, Secret: "s"}
+			patch, err := Decode([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = Merge(r, patch, "name", "count")
+			fieldErr, ok := err.(*FieldError)
+			if !ok || fieldErr.Field != tt.field {
+				t.Errorf("error = %v, want a FieldError for %s", err, tt.field)
+			}
+			if !reflect.DeepEqual(r, &record{Name: "a", Count: 2, Secret: "s"}) {
+				t.Errorf("a rejected patch changed the record to %+v", r)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name, contentType, body string
+		wantErr                 error
+	}{
+		{"merge patch", "application/merge-patch+json", `{"a": 1}`, nil},
+		{"json", "application/json; charset=utf-8", `{"a": 1}`, nil},
+		{"no content type", "", `{"a": 1}`, nil},
+		{"other media type", "application/json-patch+json", `[{"op": "remove", "path": "/a"}]`, ErrUnsupportedMediaType},
+		{"array", ContentType, `[1]`, ErrNotObject},
+		{"null", ContentType, `null`, ErrNotObject},
+		{"invalid json", ContentType, `{"a":`, ErrNotObject},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			patch, err := Read(req)
+			if err != tt.wantErr {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !patch.Has("a") {
+				t.Errorf("patch = %v", patch)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	aiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ai_request_duration_seconds",
+		Help:      "Duration of AI provider requests by provider, model, operation and outcome.",
+		Buckets:   []float64{.25, .5, 1, 2, 5, 10, 20, 30, 60},
+	}, []string{"provider", "model", "operation", "outcome"})
+
+	aiTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_tokens_total",
+		Help:      "Tokens used with AI providers by provider, model, operation and type (prompt or completion).",
+	}, []string{"provider", "model", "operation", "type"})
+
+	aiCost = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_cost_usd_total",
+		Help:      "Estimated cost of AI provider requests in US dollars.",
+	}, []string{"provider", "model", "operation"})
+)
+
+// AIUsage describes one request to an AI provider
+type AIUsage struct {
+	Provider         string // e.g. "openai"
+	Model            string
+	Operation        string // e.g. "summary" or "chat"
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64 // Estimated from the provider's pricing
+	Duration         time.Duration
+	Err              error
+}
+
+// ObserveAIRequest records an AI provider request. AI clients call it once per
+// request, including failed ones.
+func ObserveAIRequest(u AIUsage) {
+	outcome := "success"
+	if u.Err != nil {
+		outcome = "error"
+	}
+	aiRequestDuration.WithLabelValues(u.Provider, u.Model, u.Operation, outcome).Observe(u.Duration.Seconds())
+	aiTokens.WithLabelValues(u.Provider, u.Model, u.Operation, "prompt").Add(float64(u.PromptTokens))
+	aiTokens.WithLabelValues(u.Provider, u.Model, u.Operation, "completion").Add(float64(u.CompletionTokens))
+	aiCost.WithLabelValues(u.Provider, u.Model, u.Operation).Add(u.CostUSD)
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// businessQueryTimeout bounds the query run on each scrape
+const businessQueryTimeout = 5 * time.Second
+
+var (
+	candidatesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "candidates"),
+		"Number of candidates by status.",
+		[]string{"status"}, nil,
+	)
+	jobsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "jobs"),
+		"Number of job postings by status; status=\"open\" is the open jobs.",
+		[]string{"status"}, nil,
+	)
+	businessRefreshedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "business_metrics_refreshed_timestamp_seconds"),
+		"When the candidate and job counts were last refreshed, as a Unix timestamp.",
+		nil, nil,
+	)
+)
+
+// BusinessCollector reports candidate and job counts. Counting is done by Refresh,
+// which the scheduler runs on one instance; every instance reports the stored counts.
+type BusinessCollector struct {
+	candidateRepo repository.CandidateRepository
+	jobRepo       repository.JobRepository
+	snapshotRepo  repository.MetricSnapshotRepository
+}
+
+// NewBusinessCollector creates a BusinessCollector
+func NewBusinessCollector(candidateRepo repository.CandidateRepository, jobRepo repository.JobRepository, snapshotRepo repository.MetricSnapshotRepository) *BusinessCollector {
+	return &BusinessCollector{candidateRepo: candidateRepo, jobRepo: jobRepo, snapshotRepo: snapshotRepo}
+}
+
+// Refresh counts everything, including confidential jobs and their candidates,
+// and stores the counts
+func (c *BusinessCollector) Refresh(ctx context.Context) error {
+	ctx = repository.WithSystemAccess(ctx)
+
+	candidates, err := c.candidateRepo.CountByStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count candidates: %w", err)
+	}
+	if err := c.snapshotRepo.Replace(ctx, "candidates", candidates); err != nil {
+		return err
+	}
+
+	jobs, err := c.jobRepo.CountByStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count jobs: %w", err)
+	}
+	return c.snapshotRepo.Replace(ctx, "jobs", jobs)
+}
+
+func (c *BusinessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- candidatesDesc
+	ch <- jobsDesc
+	ch <- businessRefreshedDesc
+}
+
+// Collect reports the counts stored by the last Refresh. A failed read is logged
+// and left out of the scrape.
+func (c *BusinessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), businessQueryTimeout)
+	defer cancel()
+
+	snapshots, err := c.snapshotRepo.List(ctx)
+	if err != nil {
+		slog.Error("Failed to read business metrics", "error", err)
+		return
+	}
+
+	var refreshed time.Time
+	for _, s := range snapshots {
+		var desc *prometheus.Desc
+		switch s.Metric {
+		case "candidates":
+			desc = candidatesDesc
+		case "jobs":
+			desc = jobsDesc
+		default:
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, s.Value, s.Label)
+		if s.RefreshedAt.After(refreshed) {
+			refreshed = s.RefreshedAt
+		}
+	}
+	if !refreshed.IsZero() {
+		ch <- prometheus.MustNewConstMetric(businessRefreshedDesc, prometheus.GaugeValue, float64(refreshed.Unix()))
+	}
+}
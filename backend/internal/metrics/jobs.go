@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var backgroundJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "background_job_duration_seconds",
+	Help:      "Duration of background job attempts by kind and outcome (succeeded, retry or dead).",
+	Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+}, []string{"kind", "outcome"})
+
+// ObserveBackgroundJob records one attempt at a background job
+func ObserveBackgroundJob(kind, outcome string, duration time.Duration) {
+	backgroundJobDuration.WithLabelValues(kind, outcome).Observe(duration.Seconds())
+}
@@ -4,13 +4,44 @@ import "time"
 
 // User represents a user in the system
 type User struct {
-	ID              string    `json:"id"`
-	Email           string    `json:"email"`
-	Name            string    `json:"name"`
-	Role            string    `json:"role"` // "admin" or "user"
-	WorkspaceDomain string    `json:"workspace_domain"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              string     `json:"id"`
+	Email           string     `json:"email"`
+	Name            string     `json:"name"`
+	Role            string     `json:"role"`        // Name of a role, e.g. "admin" or "recruiter"
+	RoleSource      string     `json:"role_source"` // "manual", or "group" when derived from IdP groups
+	Permissions     []string   `json:"permissions"` // Resolved from the user's role
+	WorkspaceDomain string     `json:"workspace_domain"`
+	Status          string     `json:"status"` // "active", "deactivated" or "invited"
+	DeactivatedAt   *time.Time `json:"deactivated_at,omitempty"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"` // Soft-deleted users keep their history
+	ServiceAccount  bool       `json:"service_account"`      // Authenticates with API keys only
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the user may sign in and use the API
+func (u *User) IsActive() bool {
+	return u.Status == "active" && u.DeletedAt == nil
+}
+
+// HasPermission reports whether the user's role grants the named permission
+func (u *User) HasPermission(permission string) bool {
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Role represents a named set of permissions that can be assigned to users
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	IsSystem    bool      `json:"is_system"` // Built-in roles cannot be deleted
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // JobPosting represents a job posting
@@ -22,11 +53,24 @@ type JobPosting struct {
 	Location      string    `json:"location"`
 	SalaryRange   string    `json:"salary_range"`
 	Status        string    `json:"status"` // "open", "closed", "draft"
+	Confidential  bool      `json:"confidential"`
+	Version       int       `json:"version"` // Bumped on every update; sent as the ETag
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 	CreatedBy     string    `json:"created_by"`
 }
 
+// JobTeamMember represents a user's membership of a job posting's hiring team
+type JobTeamMember struct {
+	JobPostingID string    `json:"job_posting_id"`
+	UserID       string    `json:"user_id"`
+	UserName     string    `json:"user_name"`  // Denormalized for convenience
+	UserEmail    string    `json:"user_email"` // Denormalized for convenience
+	TeamRole     string    `json:"team_role"`  // "owner", "recruiter", "interviewer"
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
 // Candidate represents a job candidate
 type Candidate struct {
 	ID                string            `json:"id"`
@@ -38,9 +82,11 @@ type Candidate struct {
 	Status            string            `json:"status"` // "applied", "screened", "interviewing", "offered", "rejected"
 	SalaryExpectation string            `json:"salary_expectation,omitempty"` // Only visible to admins
 	JobPostingID      string            `json:"job_posting_id"`
+	Version           int               `json:"version"` // Bumped on every update; sent as the ETag
 	CreatedAt         time.Time         `json:"created_at"`
 	UpdatedAt         time.Time         `json:"updated_at"`
 	CreatedBy         string            `json:"created_by"`
+	ErasedAt          *time.Time        `json:"erased_at,omitempty"` // Personal data was erased on request
 }
 
 // Comment represents a comment on a candidate
@@ -50,6 +96,7 @@ type Comment struct {
 	UserID      string    `json:"user_id"`
 	UserName    string    `json:"user_name"` // Denormalized for convenience
 	Content     string    `json:"content"`
+	Version     int       `json:"version"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -60,6 +107,277 @@ type CandidateAttribute struct {
 	CandidateID    string    `json:"candidate_id"`
 	AttributeKey   string    `json:"attribute_key"`
 	AttributeValue string    `json:"attribute_value"`
+	Version        int       `json:"version"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
+
+// Offer represents an employment offer extended to a candidate
+type Offer struct {
+	ID                string     `json:"id"`
+	CandidateID       string     `json:"candidate_id"`
+	JobPostingID      string     `json:"job_posting_id"`
+	TemplateID        string     `json:"template_id,omitempty"`
+	Status            string     `json:"status"` // "draft", "pending_approval", "approved", "sent", "accepted", "declined"
+	JobTitle          string     `json:"job_title"`
+	BaseSalary        string     `json:"base_salary,omitempty"`        // Only visible with salary.read or to approvers
+	Currency          string     `json:"currency,omitempty"`           // Only visible with salary.read or to approvers
+	Bonus             string     `json:"bonus,omitempty"`              // Only visible with salary.read or to approvers
+	Equity            string     `json:"equity,omitempty"`             // Only visible with salary.read or to approvers
+	CompensationNotes string     `json:"compensation_notes,omitempty"` // Only visible with salary.read or to approvers
+	StartDate         *time.Time `json:"start_date,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+	RespondedAt       *time.Time `json:"responded_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	CreatedBy         string     `json:"created_by"`
+}
+
+// OfferApproval represents one step in an offer's approval chain
+type OfferApproval struct {
+	ID           string     `json:"id"`
+	OfferID      string     `json:"offer_id"`
+	ApproverID   string     `json:"approver_id"`
+	ApproverName string     `json:"approver_name"` // Denormalized for convenience
+	Step         int        `json:"step"`
+	Decision     string     `json:"decision"` // "pending", "approved", "rejected"
+	Comment      string     `json:"comment"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// OfferTemplate represents a reusable offer letter template
+type OfferTemplate struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Body      string    `json:"body"` // html/template source
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy string    `json:"created_by"`
+}
+
+// AuditEvent records an action taken against an entity
+type AuditEvent struct {
+	ID         string                 `json:"id"`
+	ActorID    string                 `json:"actor_id"`
+	ActorName  string                 `json:"actor_name"` // Denormalized for convenience
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   string                 `json:"entity_id"`
+	APIKeyID   string                 `json:"api_key_id,omitempty"` // Set when the actor used an API key
+	Details    map[string]interface{} `json:"details,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// Invitation allows a pre-created user to sign in for the first time
+type Invitation struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	Email         string     `json:"email"`
+	Name          string     `json:"name"`   // Denormalized for convenience
+	Role          string     `json:"role"`   // Denormalized for convenience
+	Status        string     `json:"status"` // Derived: "pending", "accepted", "revoked" or "expired"
+	InvitedBy     string     `json:"invited_by"`
+	InvitedByName string     `json:"invited_by_name"` // Denormalized for convenience
+	ExpiresAt     time.Time  `json:"expires_at"`
+	AcceptedAt    *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// GroupRoleMapping assigns a role to members of an identity provider group
+type GroupRoleMapping struct {
+	ID        string    `json:"id"`
+	Provider  string    `json:"provider"` // "google", an OIDC provider name, or "*" for any provider
+	GroupName string    `json:"group_name"`
+	Role      string    `json:"role"`
+	Priority  int       `json:"priority"` // The highest priority matching mapping wins
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+// Session is a server-side login session. Access tokens carry the session ID so
+// that sessions can be revoked before their tokens expire.
+type Session struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	UserAgent     string     `json:"user_agent"`
+	IPAddress     string     `json:"ip_address"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Current       bool       `json:"current"` // Set when listing the caller's own sessions
+}
+
+// IsActive reports whether the session has not been revoked or expired
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// APIKey authenticates scripts and service accounts. Only a hash of the key is stored.
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // First characters of the key, to recognise it
+	Scopes     []string   `json:"scopes"` // Permissions the key may use, limited by its user's role
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsActive reports whether the key may be used
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
+
+// SigningKey is a key pair used to sign access tokens. The newest key whose
+// activation time has passed signs; every unexpired key is published and verifies.
+type SigningKey struct {
+	ID          string     `json:"id"` // The kid header of tokens signed with the key
+	Algorithm   string     `json:"algorithm"`
+	PrivateKey  string     `json:"-"` // PKCS #8, PEM encoded
+	PublicKey   string     `json:"public_key"`
+	ActivatesAt time.Time  `json:"activates_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TaskRun records one run of a scheduled task
+type TaskRun struct {
+	ID           string     `json:"id"`
+	TaskName     string     `json:"task_name"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"` // Nil when an admin triggered the run
+	TriggeredBy  string     `json:"triggered_by,omitempty"`
+	Instance     string     `json:"instance"`
+	Status       string     `json:"status"` // "running", "succeeded" or "failed"
+	Summary      string     `json:"summary,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// MetricSnapshot is a stored value of a business metric, such as the number of
+// candidates with one status
+type MetricSnapshot struct {
+	Metric      string
+	Label       string
+	Value       float64
+	RefreshedAt time.Time
+}
+
+// JobActivity summarises recent activity on a job for one member of its hiring team
+type JobActivity struct {
+	UserID          string
+	UserEmail       string
+	UserName        string
+	JobID           string
+	JobTitle        string
+	NewCandidates   int
+	NewComments     int // Comments by other users
+	StaleCandidates int // Active candidates not updated recently
+}
+
+// StaleCandidate is an active candidate that has not been updated recently, with
+// a job owner to remind
+type StaleCandidate struct {
+	CandidateID   string
+	CandidateName string
+	Status        string
+	JobID         string
+	JobTitle      string
+	OwnerID       string
+	OwnerEmail    string
+	OwnerName     string
+	UpdatedAt     time.Time
+}
+
+// AISummary is a generated summary of a candidate, optionally for one job
+type AISummary struct {
+	ID           string    `json:"id"`
+	CandidateID  string    `json:"candidate_id"`
+	JobPostingID string    `json:"job_posting_id,omitempty"`
+	Summary      string    `json:"summary"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CandidateErasure counts the records removed or anonymized when a candidate's
+// personal data was erased
+type CandidateErasure struct {
+	CandidateID   string    `json:"candidate_id"`
+	Comments      int64     `json:"comments_deleted"`
+	Attributes    int64     `json:"attributes_deleted"`
+	Summaries     int64     `json:"summaries_deleted"`
+	Offers        int64     `json:"offers_anonymized"`
+	AuditEvents   int64     `json:"audit_events_scrubbed"`
+	ResumeDeleted bool      `json:"resume_deleted"`
+	ErasedAt      time.Time `json:"erased_at"`
+}
+
+// RetentionPolicy removes personal data from candidates in a status once they
+// have been inactive for a number of days
+type RetentionPolicy struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	CandidateStatus string    `json:"candidate_status"`
+	Action          string    `json:"action"` // "anonymize" or "delete_resume"
+	AfterDays       int       `json:"after_days"`
+	Mode            string    `json:"mode"` // "disabled", "dry_run" or "enforce"
+	CreatedBy       string    `json:"created_by,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// CandidateConsent records a candidate's agreement to be kept in the talent pool
+// until a date, exempting them from retention policies
+type CandidateConsent struct {
+	ID             string     `json:"id"`
+	CandidateID    string     `json:"candidate_id"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	Note           string     `json:"note,omitempty"`
+	RecordedBy     string     `json:"recorded_by,omitempty"`
+	RecordedByName string     `json:"recorded_by_name,omitempty"` // Denormalized for convenience
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// RetentionCandidate is a candidate a retention policy applies to
+type RetentionCandidate struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Status       string    `json:"status"`
+	ResumeURL    string    `json:"-"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// RetentionReport describes what a retention policy did, or would do in a dry run
+type RetentionReport struct {
+	PolicyID   string                `json:"policy_id"`
+	PolicyName string                `json:"policy_name"`
+	Action     string                `json:"action"`
+	DryRun     bool                  `json:"dry_run"`
+	Candidates []*RetentionCandidate `json:"candidates"`
+	Applied    int                   `json:"applied"`
+	Failed     int                   `json:"failed"`
+}
+
+// DataKey encrypts sensitive candidate columns, or computes blind indexes of
+// them. It is stored wrapped by a master key and only unwrapped in memory.
+type DataKey struct {
+	ID          string     `json:"id"`
+	Purpose     string     `json:"purpose"` // "data" or "blind_index"
+	WrappedKey  []byte     `json:"-"`
+	MasterKeyID string     `json:"master_key_id"`
+	Candidates  int        `json:"candidates"` // Candidates encrypted with the key
+	CreatedAt   time.Time  `json:"created_at"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty"`
+}
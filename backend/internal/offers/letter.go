@@ -0,0 +1,143 @@
+package offers
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// DefaultTemplate is the offer letter used when an offer has no template assigned
+const DefaultTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Offer of Employment - {{.CandidateName}}</title>
+</head>
+<body>
+<h1>Offer of Employment</h1>
+<p>Dear {{.CandidateName}},</p>
+<p>We are delighted to offer you the position of <strong>{{.JobTitle}}</strong>.</p>
+<h2>Compensation</h2>
+<ul>
+<li>Base salary: {{.BaseSalary}} {{.Currency}}</li>
+{{if .Bonus}}<li>Bonus: {{.Bonus}}</li>{{end}}
+{{if .Equity}}<li>Equity: {{.Equity}}</li>{{end}}
+</ul>
+{{if .CompensationNotes}}<p>{{.CompensationNotes}}</p>{{end}}
+{{if .StartDate}}<p>Your anticipated start date is {{date .StartDate}}.</p>{{end}}
+{{if .ExpiresAt}}<p>This offer expires on {{date .ExpiresAt}}.</p>{{end}}
+<p>We look forward to welcoming you to the team.</p>
+</body>
+</html>
+`
+
+// LetterData holds the values available to offer letter templates
+type LetterData struct {
+	CandidateName     string
+	CandidateEmail    string
+	JobTitle          string
+	BaseSalary        string
+	Currency          string
+	Bonus             string
+	Equity            string
+	CompensationNotes string
+	StartDate         *time.Time
+	ExpiresAt         *time.Time
+}
+
+// NewLetterData builds template data from an offer and its candidate
+func NewLetterData(offer *models.Offer, candidate *models.Candidate) LetterData {
+	return LetterData{
+		CandidateName:     candidate.Name,
+		CandidateEmail:    candidate.Email,
+		JobTitle:          offer.JobTitle,
+		BaseSalary:        offer.BaseSalary,
+		Currency:          offer.Currency,
+		Bonus:             offer.Bonus,
+		Equity:            offer.Equity,
+		CompensationNotes: offer.CompensationNotes,
+		StartDate:         offer.StartDate,
+		ExpiresAt:         offer.ExpiresAt,
+	}
+}
+
+var templateFuncs = template.FuncMap{
+	"date": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("January 2, 2006")
+	},
+}
+
+// ParseTemplate parses an offer letter template, reporting syntax errors
+func ParseTemplate(body string) (*template.Template, error) {
+	tmpl, err := template.New("offer").Funcs(templateFuncs).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid offer letter template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderHTML renders an offer letter as HTML. An empty body uses DefaultTemplate.
+func RenderHTML(body string, data LetterData) ([]byte, error) {
+	if body == "" {
+		body = DefaultTemplate
+	}
+
+	tmpl, err := ParseTemplate(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render offer letter: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderPDF renders an offer letter as a PDF document containing the letter's text
+func RenderPDF(body string, data LetterData) ([]byte, error) {
+	rendered, err := RenderHTML(body, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	title := fmt.Sprintf("Offer of Employment - %s", data.CandidateName)
+	if err := writePDF(&buf, title, htmlToLines(string(rendered))); err != nil {
+		return nil, fmt.Errorf("failed to render offer letter PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	headPattern      = regexp.MustCompile(`(?is)<head.*?</head>`)
+	blockEndPattern  = regexp.MustCompile(`(?i)</(p|h[1-6]|li|ul|ol|div|tr)>|<br\s*/?>`)
+	listItemPattern  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToLines converts rendered letter HTML into plain text lines suitable for the PDF writer
+func htmlToLines(s string) []string {
+	s = headPattern.ReplaceAllString(s, "")
+	s = listItemPattern.ReplaceAllString(s, "- ")
+	s = blockEndPattern.ReplaceAllString(s, "\n\n")
+	s = tagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	s = blankLinePattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.Split(strings.TrimSpace(s), "\n")
+}
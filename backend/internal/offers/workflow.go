@@ -0,0 +1,53 @@
+package offers
+
+import "fmt"
+
+// Offer statuses
+const (
+	StatusDraft           = "draft"
+	StatusPendingApproval = "pending_approval"
+	StatusApproved        = "approved"
+	StatusSent            = "sent"
+	StatusAccepted        = "accepted"
+	StatusDeclined        = "declined"
+	StatusExpired         = "expired" // Sent but not answered before expires_at
+)
+
+// Approval decisions
+const (
+	DecisionPending  = "pending"
+	DecisionApproved = "approved"
+	DecisionRejected = "rejected"
+)
+
+// transitions lists the statuses an offer may move to from each status.
+// A rejected approval sends the offer back to draft for revision.
+var transitions = map[string][]string{
+	StatusDraft:           {StatusPendingApproval},
+	StatusPendingApproval: {StatusApproved, StatusDraft},
+	StatusApproved:        {StatusSent, StatusDraft},
+	StatusSent:            {StatusAccepted, StatusDeclined, StatusExpired},
+}
+
+// CanTransition reports whether an offer may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns an error if an offer may not move from one status to another
+func ValidateTransition(from, to string) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("offer cannot move from '%s' to '%s'", from, to)
+	}
+	return nil
+}
+
+// IsTerminal reports whether no further transitions are possible from the status
+func IsTerminal(status string) bool {
+	return len(transitions[status]) == 0
+}
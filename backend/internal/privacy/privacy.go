@@ -0,0 +1,288 @@
+// Package privacy answers data subject requests about candidates, as required by
+// the GDPR and CCPA: exporting everything held about a candidate, and erasing
+// their personal data while keeping aggregate counts intact.
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/internal/storage"
+)
+
+// ErrNotFound is returned for a candidate that does not exist
+var ErrNotFound = errors.New("candidate not found")
+
+// errForeignResume is returned by resumeKey for a key outside the candidate's own prefix
+var errForeignResume = errors.New("resume key is outside the candidate's storage prefix")
+
+// Service exports and erases candidates' personal data. Requests cover every
+// candidate, whatever the job's hiring team.
+type Service struct {
+	candidateRepo repository.CandidateRepository
+	attributeRepo repository.AttributeRepository
+	commentRepo   repository.CommentRepository
+	offerRepo     repository.OfferRepository
+	auditRepo     repository.AuditRepository
+	subjectRepo   repository.DataSubjectRepository
+	consentRepo   repository.ConsentRepository
+	retentionRepo repository.RetentionRepository
+	store         storage.Store
+}
+
+// NewService creates a Service. Resumes are read from and deleted in store, which
+// may be nil when resumes are only kept as links.
+func NewService(
+	candidateRepo repository.CandidateRepository,
+	attributeRepo repository.AttributeRepository,
+	commentRepo repository.CommentRepository,
+	offerRepo repository.OfferRepository,
+	auditRepo repository.AuditRepository,
+	subjectRepo repository.DataSubjectRepository,
+	consentRepo repository.ConsentRepository,
+	retentionRepo repository.RetentionRepository,
+	store storage.Store,
+) *Service {
+	return &Service{
+		candidateRepo: candidateRepo,
+		attributeRepo: attributeRepo,
+		commentRepo:   commentRepo,
+		offerRepo:     offerRepo,
+		auditRepo:     auditRepo,
+		subjectRepo:   subjectRepo,
+		consentRepo:   consentRepo,
+		retentionRepo: retentionRepo,
+		store:         store,
+	}
+}
+
+// manifest describes the contents of an export
+type manifest struct {
+	CandidateID string    `json:"candidate_id"`
+	ExportedAt  time.Time `json:"exported_at"`
+	Files       []string  `json:"files"`
+	Notes       []string  `json:"notes,omitempty"`
+}
+
+type exportOffer struct {
+	*models.Offer
+	Approvals []*models.OfferApproval `json:"approvals"`
+}
+
+// Export writes a zip archive of everything held about a candidate: the record,
+// attributes, comments, AI summaries, offers, talent pool consents, the audit history of the candidate
+// and their offers, and the resume file. A manifest.json lists the contents.
+func (s *Service) Export(ctx context.Context, candidateID string, w io.Writer) error {
+	ctx = repository.WithSystemAccess(ctx)
+	candidate, err := s.candidateRepo.GetByID(ctx, candidateID)
+	if err != nil {
+		return err
+	}
+	if candidate == nil {
+		return ErrNotFound
+	}
+
+	attributes, err := s.attributeRepo.ListByCandidate(ctx, candidateID)
+	if err != nil {
+		return err
+	}
+	comments, err := s.commentRepo.ListByCandidate(ctx, candidateID)
+	if err != nil {
+		return err
+	}
+	summaries, err := s.subjectRepo.ListSummaries(ctx, candidateID)
+	if err != nil {
+		return err
+	}
+	consents, err := s.consentRepo.ListByCandidate(ctx, candidateID)
+	if err != nil {
+		return err
+	}
+	history, err := s.auditRepo.ListByEntity(ctx, "candidate", candidateID)
+	if err != nil {
+		return err
+	}
+	offerList, err := s.offerRepo.ListByCandidate(ctx, candidateID)
+	if err != nil {
+		return err
+	}
+	offers := make([]*exportOffer, 0, len(offerList))
+	for _, offer := range offerList {
+		approvals, err := s.offerRepo.ListApprovals(ctx, offer.ID)
+		if err != nil {
+			return err
+		}
+		offers = append(offers, &exportOffer{Offer: offer, Approvals: nonNil(approvals)})
+
+		events, err := s.auditRepo.ListByEntity(ctx, "offer", offer.ID)
+		if err != nil {
+			return err
+		}
+		history = append(history, events...)
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].CreatedAt.Before(history[j].CreatedAt) })
+
+	m := &manifest{CandidateID: candidateID, ExportedAt: time.Now().UTC()}
+	zw := zip.NewWriter(w)
+	for _, file := range []struct {
+		name string
+		v    interface{}
+	}{
+		{"candidate.json", candidate},
+		{"attributes.json", nonNil(attributes)},
+		{"comments.json", nonNil(comments)},
+		{"summaries.json", nonNil(summaries)},
+		{"offers.json", offers},
+		{"consents.json", nonNil(consents)},
+		{"history.json", nonNil(history)},
+	} {
+		if err := writeJSON(zw, file.name, file.v); err != nil {
+			return err
+		}
+		m.Files = append(m.Files, file.name)
+	}
+
+	switch key, err := resumeKey(candidate.ID, candidate.ResumeURL); {
+	case candidate.ResumeURL == "":
+	case err != nil:
+		m.Notes = append(m.Notes, "The resume file could not be included because it is not stored under this candidate")
+	case key == "":
+		m.Notes = append(m.Notes, fmt.Sprintf("The resume is held elsewhere, at %s", candidate.ResumeURL))
+	case s.store == nil:
+		m.Notes = append(m.Notes, "The resume file could not be included because resume storage is not configured")
+	default:
+		name, err := s.writeResume(ctx, zw, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			m.Notes = append(m.Notes, "The resume file is no longer stored")
+		} else if err != nil {
+			return err
+		} else {
+			m.Files = append(m.Files, name)
+		}
+	}
+
+	if err := writeJSON(zw, "manifest.json", m); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeResume copies the stored resume into the archive, returning its name there
+func (s *Service) writeResume(ctx context.Context, zw *zip.Writer, key string) (string, error) {
+	r, err := s.store.Open(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	name := "resume/" + path.Base(key)
+	f, err := zw.Create(name)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, r)
+	return name, err
+}
+
+// Erase irreversibly erases a candidate's personal data: the resume file is
+// deleted, then the database records are deleted or anonymized as described at
+// repository.DataSubjectRepository.Erase. Erasing a candidate again is harmless.
+func (s *Service) Erase(ctx context.Context, candidateID string) (*models.CandidateErasure, error) {
+	ctx = repository.WithSystemAccess(ctx)
+	candidate, err := s.candidateRepo.GetByID(ctx, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	if candidate == nil {
+		return nil, ErrNotFound
+	}
+
+	// The file goes first: once the record is anonymized its key is lost
+	resumeDeleted, err := s.deleteResume(ctx, candidate.ID, candidate.ResumeURL)
+	if err != nil {
+		return nil, err
+	}
+
+	erasure, err := s.subjectRepo.Erase(ctx, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	if erasure == nil {
+		return nil, ErrNotFound
+	}
+	erasure.ResumeDeleted = resumeDeleted
+	return erasure, nil
+}
+
+// AuditDetails describes an erasure for the audit trail
+func AuditDetails(erasure *models.CandidateErasure, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"reason":                reason,
+		"comments_deleted":      erasure.Comments,
+		"attributes_deleted":    erasure.Attributes,
+		"summaries_deleted":     erasure.Summaries,
+		"offers_anonymized":     erasure.Offers,
+		"audit_events_scrubbed": erasure.AuditEvents,
+		"resume_deleted":        erasure.ResumeDeleted,
+	}
+}
+
+// deleteResume deletes a candidate's stored resume file, reporting whether there
+// was one. A resume URL naming a key outside the candidate's prefix is left alone.
+func (s *Service) deleteResume(ctx context.Context, candidateID, resumeURL string) (bool, error) {
+	key, err := resumeKey(candidateID, resumeURL)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Not deleting a resume stored outside the candidate's prefix", "candidate_id", candidateID)
+		return false, nil
+	}
+	if key == "" || s.store == nil {
+		return false, nil
+	}
+	if err := s.store.Delete(ctx, key); err != nil {
+		return false, fmt.Errorf("deleting resume: %w", err)
+	}
+	return true, nil
+}
+
+// resumeKey returns the storage key of a candidate's resume, or "" when there is
+// none or the resume URL points somewhere else. Resume URLs can be set through
+// the API, so a key outside storage.CandidatePrefix, which could name another
+// candidate's file, is refused with errForeignResume.
+func resumeKey(candidateID, resumeURL string) (string, error) {
+	if resumeURL == "" || strings.Contains(resumeURL, "://") {
+		return "", nil
+	}
+	if path.Clean(resumeURL) != resumeURL || !strings.HasPrefix(resumeURL, storage.CandidatePrefix(candidateID)) {
+		return "", errForeignResume
+	}
+	return resumeURL, nil
+}
+
+// nonNil returns an empty slice for nil, so that lists are written as [] not null
+func nonNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
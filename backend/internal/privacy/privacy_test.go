@@ -0,0 +1,67 @@
+package privacy
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+const candidateID = "7d444840-9dc0-11d1-b245-5ffdce74fad2"
+
+func TestResumeKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		resumeURL string
+		want      string
+		wantErr   bool
+	}{
+		{"none", "", "", false},
+		{"link", "https://files.example.com/resume.pdf", "", false},
+		{"own file", "candidates/" + candidateID + "/resume.pdf", "candidates/" + candidateID + "/resume.pdf", false},
+		{"another candidate's file", "candidates/11111111-2222-3333-4444-555555555555/resume.pdf", "", true},
+		{"escapes the prefix", "candidates/" + candidateID + "/../other/resume.pdf", "", true},
+		{"prefix of another ID", "candidates/" + candidateID + "0/resume.pdf", "", true},
+		{"outside candidates", "backups/db.sql", "", true},
+		{"absolute path", "/etc/passwd", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resumeKey(candidateID, tt.resumeURL)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("resumeKey(%q) = %q, %v; want %q, wantErr %v", tt.resumeURL, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeStore records the keys deleted from it
+type fakeStore struct {
+	deleted []string
+}
+
+func (s *fakeStore) Put(ctx context.Context, key string, r io.Reader) error { return nil }
+
+func (s *fakeStore) Open(ctx context.Context, key string) (io.ReadCloser, error) { return nil, nil }
+
+func (s *fakeStore) Delete(ctx context.Context, key string) error {
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func TestDeleteResumeKeepsOtherCandidatesFiles(t *testing.T) {
+	store := &fakeStore{}
+	s := &Service{store: store}
+	ctx := context.Background()
+
+	deleted, err := s.deleteResume(ctx, candidateID, "candidates/11111111-2222-3333-4444-555555555555/resume.pdf")
+	if err != nil || deleted || len(store.deleted) != 0 {
+		t.Errorf("deleted another candidate's resume: %v, %v, %q", deleted, err, store.deleted)
+	}
+
+	own := "candidates/" + candidateID + "/resume.pdf"
+	deleted, err = s.deleteResume(ctx, candidateID, own)
+	if err != nil || !deleted || len(store.deleted) != 1 || store.deleted[0] != own {
+		t.Errorf("own resume: %v, %v, %q", deleted, err, store.deleted)
+	}
+}
@@ -0,0 +1,154 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+)
+
+// Retention policy actions
+const (
+	ActionAnonymize    = "anonymize"     // Erase the candidate's personal data, as for an erasure request
+	ActionDeleteResume = "delete_resume" // Delete the resume file and the data parsed from it
+)
+
+// Retention policy modes
+const (
+	ModeDisabled = "disabled"
+	ModeDryRun   = "dry_run" // Report the candidates the policy applies to without changing them
+	ModeEnforce  = "enforce"
+)
+
+// CandidateStatuses are the statuses a retention policy can apply to
+var CandidateStatuses = []string{"applied", "screened", "interviewing", "offered", "rejected"}
+
+// retentionBatch bounds how many candidates a policy handles in one run, so that
+// a new policy works through a backlog over several runs
+const retentionBatch = 500
+
+// DueCandidates returns the candidates a policy applies to now, up to the number
+// one run handles, without changing anything
+func (s *Service) DueCandidates(ctx context.Context, policy *models.RetentionPolicy) ([]*models.RetentionCandidate, error) {
+	return s.retentionRepo.ListDue(repository.WithSystemAccess(ctx), policy, time.Now(), retentionBatch)
+}
+
+// ApplyRetention runs every policy that is not disabled. Policies in dry run
+// mode, or every policy when dryRun is set, only report the candidates they
+// apply to. Each change to a candidate and each run of a policy is audited.
+// Errors from one policy do not stop the others.
+func (s *Service) ApplyRetention(ctx context.Context, dryRun bool) ([]*models.RetentionReport, error) {
+	ctx = repository.WithSystemAccess(ctx)
+	policies, err := s.retentionRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []*models.RetentionReport
+	var errs []error
+	for _, policy := range policies {
+		if policy.Mode == ModeDisabled {
+			continue
+		}
+		report, err := s.applyPolicy(ctx, policy, dryRun || policy.Mode != ModeEnforce)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("policy %s: %w", policy.Name, err))
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports, errors.Join(errs...)
+}
+
+// applyPolicy applies one policy to the candidates it is due for
+func (s *Service) applyPolicy(ctx context.Context, policy *models.RetentionPolicy, dryRun bool) (*models.RetentionReport, error) {
+	candidates, err := s.DueCandidates(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+	report := &models.RetentionReport{
+		PolicyID:   policy.ID,
+		PolicyName: policy.Name,
+		Action:     policy.Action,
+		DryRun:     dryRun,
+		Candidates: candidates,
+	}
+
+	if dryRun {
+		ids := make([]string, len(candidates))
+		for i, c := range candidates {
+			ids[i] = c.ID
+		}
+		s.audit(ctx, "retention_policy.dry_run", "retention_policy", policy.ID, map[string]interface{}{
+			"action":        policy.Action,
+			"candidate_ids": ids,
+		})
+		return report, nil
+	}
+
+	logger := logging.FromContext(ctx).With("policy", policy.Name)
+	for _, c := range candidates {
+		if err := s.applyToCandidate(ctx, policy, c); err != nil {
+			logger.Error("Failed to apply retention policy", "candidate_id", c.ID, "error", err)
+			report.Failed++
+			continue
+		}
+		report.Applied++
+	}
+	s.audit(ctx, "retention_policy.applied", "retention_policy", policy.ID, map[string]interface{}{
+		"action":  policy.Action,
+		"applied": report.Applied,
+		"failed":  report.Failed,
+	})
+	return report, nil
+}
+
+// applyToCandidate takes the policy's action on one candidate and audits it
+func (s *Service) applyToCandidate(ctx context.Context, policy *models.RetentionPolicy, c *models.RetentionCandidate) error {
+	switch policy.Action {
+	case ActionAnonymize:
+		erasure, err := s.Erase(ctx, c.ID)
+		if err != nil {
+			return err
+		}
+		details := AuditDetails(erasure, fmt.Sprintf("Retention policy %q", policy.Name))
+		details["policy_id"] = policy.ID
+		s.audit(ctx, "candidate.erased", "candidate", c.ID, details)
+
+	case ActionDeleteResume:
+		fileDeleted, err := s.deleteResume(ctx, c.ID, c.ResumeURL)
+		if err != nil {
+			return err
+		}
+		if err := s.retentionRepo.DeleteResume(ctx, c.ID); err != nil {
+			return err
+		}
+		s.audit(ctx, "candidate.resume_deleted", "candidate", c.ID, map[string]interface{}{
+			"policy_id":    policy.ID,
+			"policy":       policy.Name,
+			"file_deleted": fileDeleted,
+		})
+
+	default:
+		return fmt.Errorf("unknown retention action %q", policy.Action)
+	}
+	return nil
+}
+
+// audit records an action taken by a retention policy, which has no actor
+func (s *Service) audit(ctx context.Context, action, entityType, entityID string, details map[string]interface{}) {
+	details["source"] = "retention"
+	event := &models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Details:    details,
+	}
+	if err := s.auditRepo.Record(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", action, "error", err)
+	}
+}
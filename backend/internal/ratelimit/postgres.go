@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/tracing"
+)
+
+// pruneInterval is how often buckets idle for longer than pruneAfter are deleted
+const (
+	pruneInterval = 10 * time.Minute
+	pruneAfter    = 24 * time.Hour
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so that all
+// instances share them. Each Take is a single atomic statement.
+type PostgresStore struct {
+	db *sql.DB
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// NewPostgresStore creates a PostgresStore
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db, lastPruned: time.Now()}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.pruneIfDue()
+
+	// $2 is the capacity and $3 the refill rate per second. The upsert locks the
+	// row, so concurrent requests for the same key are applied one after another.
+	query := `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::DOUBLE PRECISION - 1, TRUE, CURRENT_TIMESTAMP)
+		ON CONFLICT (key) DO UPDATE SET
+			allowed = LEAST($2::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - b.updated_at) * $3::DOUBLE PRECISION) >= 1,
+			tokens = LEAST($2::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - b.updated_at) * $3::DOUBLE PRECISION)
+				- CASE WHEN LEAST($2::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - b.updated_at) * $3::DOUBLE PRECISION) >= 1 THEN 1 ELSE 0 END,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING tokens, allowed
+	`
+	var tokens float64
+	var allowed bool
+	if err := s.db.QueryRowContext(ctx, query, key, float64(limit.Burst), limit.rate()).Scan(&tokens, &allowed); err != nil {
+		return Result{}, err
+	}
+	return newResult(allowed, tokens, limit), nil
+}
+
+// pruneIfDue deletes idle buckets in the background every pruneInterval
+func (s *PostgresStore) pruneIfDue() {
+	s.mu.Lock()
+	due := time.Since(s.lastPruned) >= pruneInterval
+	if due {
+		s.lastPruned = time.Now()
+	}
+	s.mu.Unlock()
+	if !due {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		ctx, span := tracing.StartJob(ctx, "rate_limits.prune")
+		query := `DELETE FROM rate_limit_buckets WHERE updated_at < $1`
+		_, err := s.db.ExecContext(ctx, query, time.Now().Add(-pruneAfter))
+		if err != nil {
+			slog.Error("Failed to prune rate limit buckets", "error", err)
+		}
+		tracing.End(span, err)
+	}()
+}
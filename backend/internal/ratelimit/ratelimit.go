@@ -0,0 +1,105 @@
+// Package ratelimit implements token bucket rate limiting with in-memory and
+// PostgreSQL-backed bucket stores.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket: it holds up to Burst tokens and refills at Burst per Period.
+// Each request takes one token.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Unlimited reports whether the limit is disabled
+func (l Limit) Unlimited() bool {
+	return l.Burst <= 0 || l.Period <= 0
+}
+
+// rate returns the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// String formats the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	switch l.Period {
+	case time.Second:
+		return fmt.Sprintf("%d/s", l.Burst)
+	case time.Minute:
+		return fmt.Sprintf("%d/m", l.Burst)
+	case time.Hour:
+		return fmt.Sprintf("%d/h", l.Burst)
+	}
+	return fmt.Sprintf("%d/%s", l.Burst, l.Period)
+}
+
+// ParseLimit reads a limit such as "60/m" (60 requests a minute, in bursts of up to 60).
+// The units are s, m and h. "off" or "0" disables the limit.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "off" || value == "0" {
+		return Limit{}, nil
+	}
+
+	count, unit, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected e.g. 60/m", value)
+	}
+	burst, err := strconv.Atoi(count)
+	if err != nil || burst < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected e.g. 60/m", value)
+	}
+
+	periods := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	period, ok := periods[unit]
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit unit in %q, expected s, m or h", value)
+	}
+	return Limit{Burst: burst, Period: period}, nil
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed    bool
+	Remaining  int           // Whole tokens left in the bucket
+	RetryAfter time.Duration // When denied, how long until a token is available
+	Reset      time.Duration // How long until the bucket is full again
+}
+
+// Store holds token buckets
+type Store interface {
+	// Take takes a token from the bucket for key, creating a full bucket if there is none
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// newResult describes a bucket left with tokens after a request
+func newResult(allowed bool, tokens float64, limit Limit) Result {
+	rate := limit.rate()
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(limit.Burst) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Limit
+		wantErr bool
+	}{
+		{"60/m", Limit{Burst: 60, Period: time.Minute}, false},
+		{" 10/S ", Limit{Burst: 10, Period: time.Second}, false},
+		{"1000/h", Limit{Burst: 1000, Period: time.Hour}, false},
+		{"off", Limit{}, false},
+		{"OFF", Limit{}, false},
+		{"0", Limit{}, false},
+		{"0/m", Limit{Period: time.Minute}, false},
+		{"", Limit{}, true},
+		{"60", Limit{}, true},
+		{"ten/m", Limit{}, true},
+		{"-1/m", Limit{}, true},
+		{"60/d", Limit{}, true},
+		{"60/", Limit{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseLimit(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitString(t *testing.T) {
+	tests := []struct {
+		limit     Limit
+		want      string
+		roundTrip bool // ParseLimit reads the string back as the same limit
+	}{
+		{Limit{Burst: 60, Period: time.Minute}, "60/m", true},
+		{Limit{Burst: 10, Period: time.Second}, "10/s", true},
+		{Limit{Burst: 5, Period: time.Hour}, "5/h", true},
+		{Limit{}, "off", true},
+		{Limit{Period: time.Minute}, "off", false},
+		{Limit{Burst: 3, Period: 2 * time.Minute}, "3/2m0s", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limit.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.limit, got, tt.want)
+		}
+		if !tt.roundTrip {
+			continue
+		}
+		if parsed, err := ParseLimit(tt.want); err != nil || parsed != tt.limit {
+			t.Errorf("ParseLimit(%q) = %+v, %v; want %+v", tt.want, parsed, err, tt.limit)
+		}
+	}
+}
+
+// age moves the bucket for key d into the past, as if d had gone by
+func age(s *MemoryStore, key string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buckets[key].updated = s.buckets[key].updated.Add(-d)
+}
+
+// approx reports whether d is within 100ms of want; the store reads the real clock
+func approx(d, want time.Duration) bool {
+	return d > want-100*time.Millisecond && d <= want
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Burst: 2, Period: time.Minute} // A token every 30 seconds
+	s := NewMemoryStore()
+
+	steps := []struct {
+		name          string
+		elapsed       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+		wantReset     time.Duration
+	}{
+		{"first request", 0, true, 1, 0, 30 * time.Second},
+		{"second request", 0, true, 0, 0, time.Minute},
+		{"bucket empty", 0, false, 0, 30 * time.Second, time.Minute},
+		{"half a token later", 15 * time.Second, false, 0, 15 * time.Second, 45 * time.Second},
+		{"a token later", 15 * time.Second, true, 0, 0, time.Minute},
+		{"refilled", 5 * time.Minute, true, 1, 0, 30 * time.Second},
+	}
+
+	for _, step := range steps {
+		if step.elapsed > 0 {
+			age(s, "ip:1", step.elapsed)
+		}
+		result, err := s.Take(ctx, "ip:1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != step.wantAllowed || result.Remaining != step.wantRemaining {
+			t.Errorf("%s: allowed %v with %d remaining, want %v with %d", step.name, result.Allowed, result.Remaining, step.wantAllowed, step.wantRemaining)
+		}
+		if !approx(result.RetryAfter, step.wantRetry) {
+			t.Errorf("%s: retry after %s, want %s", step.name, result.RetryAfter, step.wantRetry)
+		}
+		if !approx(result.Reset, step.wantReset) {
+			t.Errorf("%s: reset in %s, want %s", step.name, result.Reset, step.wantReset)
+		}
+	}
+
+	// Other callers have their own bucket, and a changed limit starts a new one
+	if result, _ := s.Take(ctx, "ip:2", limit); !result.Allowed || result.Remaining != 1 {
+		t.Errorf("another key: %+v", result)
+	}
+	s.Take(ctx, "ip:1", limit)
+	if result, _ := s.Take(ctx, "ip:1", Limit{Burst: 5, Period: time.Minute}); !result.Allowed || result.Remaining != 4 {
+		t.Errorf("after the limit changed: %+v", result)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Burst: 2, Period: time.Minute}
+	s := NewMemoryStore()
+
+	s.Take(ctx, "drained", limit)
+	s.Take(ctx, "drained", limit)
+	s.Take(ctx, "refilled", limit)
+	age(s, "refilled", time.Minute)
+
+	// The next Take after sweepInterval drops buckets that are full again
+	s.mu.Lock()
+	s.lastSweep = s.lastSweep.Add(-sweepInterval)
+	s.mu.Unlock()
+	s.Take(ctx, "new", limit)
+
+	if _, ok := s.buckets["refilled"]; ok {
+		t.Error("full bucket was not swept")
+	}
+	if _, ok := s.buckets["drained"]; !ok {
+		t.Error("drained bucket was swept, which would reset it")
+	}
+	if result, _ := s.Take(ctx, "drained", limit); result.Allowed {
+		t.Error("drained bucket allowed a request after the sweep")
+	}
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrAccessDenied is returned when the caller's access scope does not allow modifying a record
+var ErrAccessDenied = errors.New("access denied")
+
+// ErrLastOwner is returned when a change would leave a job without an owner
+var ErrLastOwner = errors.New("a job must keep at least one owner")
+
+// Hiring team roles
+const (
+	TeamRoleOwner       = "owner"
+	TeamRoleRecruiter   = "recruiter"
+	TeamRoleInterviewer = "interviewer"
+)
+
+// AccessScope describes which job-scoped records the caller may see and modify.
+// Repositories read it from the context so that every query is filtered,
+// whichever endpoint calls it.
+type AccessScope struct {
+	UserID  string
+	AllJobs bool // Bypasses hiring team checks, e.g. for admins and system tasks
+}
+
+type accessScopeKey struct{}
+
+// WithAccessScope returns a context carrying the caller's access scope
+func WithAccessScope(ctx context.Context, scope AccessScope) context.Context {
+	return context.WithValue(ctx, accessScopeKey{}, scope)
+}
+
+// WithSystemAccess returns a context that may see and modify every record.
+// It is intended for background tasks and operational tooling.
+func WithSystemAccess(ctx context.Context) context.Context {
+	return WithAccessScope(ctx, AccessScope{AllJobs: true})
+}
+
+// accessScopeFrom returns the scope stored in the context. A context without a
+// scope may only see non-confidential jobs and cannot modify team-owned records.
+func accessScopeFrom(ctx context.Context) AccessScope {
+	scope, _ := ctx.Value(accessScopeKey{}).(AccessScope)
+	return scope
+}
+
+// jobVisibleClause returns a SQL condition restricting the job aliased as jobAlias
+// to those the scope may see. The user ID, when needed, is bound as $argPos.
+func jobVisibleClause(scope AccessScope, jobAlias string, argPos int) (string, []interface{}) {
+	if scope.AllJobs {
+		return "TRUE", nil
+	}
+	if scope.UserID == "" {
+		return fmt.Sprintf("NOT %s.confidential", jobAlias), nil
+	}
+	return fmt.Sprintf(`(NOT %[1]s.confidential OR EXISTS (
+			SELECT 1 FROM job_team_members m WHERE m.job_posting_id = %[1]s.id AND m.user_id = $%[2]d
+		))`, jobAlias, argPos), []interface{}{scope.UserID}
+}
+
+// jobTeamRoleClause returns a SQL condition requiring the scope's user to hold one of the
+// given team roles on the job whose ID expression is jobIDExpr. The user ID is bound as $argPos.
+func jobTeamRoleClause(scope AccessScope, jobIDExpr string, argPos int, roles ...string) (string, []interface{}) {
+	if scope.AllJobs {
+		return "TRUE", nil
+	}
+	if scope.UserID == "" {
+		return "FALSE", nil
+	}
+
+	roleList := ""
+	for i, role := range roles {
+		if i > 0 {
+			roleList += ", "
+		}
+		roleList += "'" + role + "'"
+	}
+	return fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM job_team_members m
+			WHERE m.job_posting_id = %s AND m.user_id = $%d AND m.team_role IN (%s)
+		)`, jobIDExpr, argPos, roleList), []interface{}{scope.UserID}
+}
+
+// candidateVisibleClause returns a SQL condition restricting the candidate aliased as
+// candidateAlias to those the scope may see. Candidates not attached to a job are visible to all.
+func candidateVisibleClause(scope AccessScope, candidateAlias string, argPos int) (string, []interface{}) {
+	if scope.AllJobs {
+		return "TRUE", nil
+	}
+	jobClause, args := jobVisibleClause(scope, "vj", argPos)
+	return fmt.Sprintf(`(%[1]s.job_posting_id IS NULL OR EXISTS (
+			SELECT 1 FROM job_postings vj WHERE vj.id = %[1]s.job_posting_id AND %[2]s
+		))`, candidateAlias, jobClause), args
+}
+
+// candidateEditableClause returns a SQL condition restricting the candidate aliased as
+// candidateAlias to those the scope may modify: unassigned candidates, or candidates
+// on jobs where the user is an owner or recruiter.
+func candidateEditableClause(scope AccessScope, candidateAlias string, argPos int) (string, []interface{}) {
+	if scope.AllJobs {
+		return "TRUE", nil
+	}
+	teamClause, args := jobTeamRoleClause(scope, candidateAlias+".job_posting_id", argPos, TeamRoleOwner, TeamRoleRecruiter)
+	return fmt.Sprintf("(%s.job_posting_id IS NULL OR %s)", candidateAlias, teamClause), args
+}
+
+// jobRefVisibleClause returns a SQL condition requiring the job whose ID
+// expression is jobIDExpr, unless it is NULL, to be visible to the scope
+func jobRefVisibleClause(scope AccessScope, jobIDExpr string, argPos int) (string, []interface{}) {
+	if scope.AllJobs {
+		return "TRUE", nil
+	}
+	jobClause, args := jobVisibleClause(scope, "rj", argPos)
+	return fmt.Sprintf(`(%[1]s IS NULL OR EXISTS (
+			SELECT 1 FROM job_postings rj WHERE rj.id = %[1]s AND %[2]s
+		))`, jobIDExpr, jobClause), args
+}
+
+// candidateRefEditableClause returns a SQL condition restricting rows that
+// reference a candidate (attributes) through candidateIDExpr to candidates the
+// scope may modify
+func candidateRefEditableClause(scope AccessScope, candidateIDExpr string, argPos int) (string, []interface{}) {
+	if scope.AllJobs {
+		return "TRUE", nil
+	}
+	candidateClause, args := candidateEditableClause(scope, "ec", argPos)
+	return fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM candidates ec WHERE ec.id = %s AND %s
+		)`, candidateIDExpr, candidateClause), args
+}
+
+// candidateRefVisibleClause returns a SQL condition restricting rows that reference a
+// candidate (offers, comments, attributes) through candidateIDExpr to visible candidates
+func candidateRefVisibleClause(scope AccessScope, candidateIDExpr string, argPos int) (string, []interface{}) {
+	if scope.AllJobs {
+		return "TRUE", nil
+	}
+	candidateClause, args := candidateVisibleClause(scope, "vc", argPos)
+	return fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM candidates vc WHERE vc.id = %s AND %s
+		)`, candidateIDExpr, candidateClause), args
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+// APIKeyRepository defines the interface for API key operations.
+// Keys are stored as hashes only.
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey, keyHash string) error
+	GetByID(ctx context.Context, id string) (*models.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ListByUser(ctx context.Context, userID string) ([]*models.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+	Touch(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type apiKeyKey struct{}
+
+// WithAPIKey returns a context recording that the request authenticated with the API key
+func WithAPIKey(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, keyID)
+}
+
+// APIKeyFromContext returns the ID of the API key the request authenticated with, if any
+func APIKeyFromContext(ctx context.Context) string {
+	keyID, _ := ctx.Value(apiKeyKey{}).(string)
+	return keyID
+}
+
+// PostgresAPIKeyRepository implements APIKeyRepository for PostgreSQL
+type PostgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresAPIKeyRepository creates a new PostgresAPIKeyRepository
+func NewPostgresAPIKeyRepository(db *sql.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_by, created_at`
+
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, key *models.APIKey, keyHash string) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		key.UserID, key.Name, key.Prefix, keyHash, pq.Array(key.Scopes), key.ExpiresAt, nullStringOrNil(key.CreatedBy),
+	).Scan(&key.ID, &key.CreatedAt)
+}
+
+func (r *PostgresAPIKeyRepository) GetByID(ctx context.Context, id string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// GetByHash returns the key with the given hash, or nil if there is none
+func (r *PostgresAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// ListByUser returns the user's keys, including revoked and expired ones, newest first
+func (r *PostgresAPIKeyRepository) ListByUser(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, id string) error {
+	query := `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// DeleteExpired deletes keys that expired or were revoked before the given time.
+// Audit events made with them stay, without the key reference.
+func (r *PostgresAPIKeyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM api_keys WHERE expires_at < $1 OR revoked_at < $1`
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Touch records that the key was used. It writes at most once a minute per key
+// so that busy scripts do not turn every request into a write.
+func (r *PostgresAPIKeyRepository) Touch(ctx context.Context, id string) error {
+	query := `
+		UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+	`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	var createdBy sql.NullString
+	if err := row.Scan(
+		&key.ID, &key.UserID, &key.Name, &key.Prefix, pq.Array(&key.Scopes),
+		&expiresAt, &lastUsedAt, &revokedAt, &createdBy, &key.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	key.ExpiresAt = timePtr(expiresAt)
+	key.LastUsedAt = timePtr(lastUsedAt)
+	key.RevokedAt = timePtr(revokedAt)
+	if createdBy.Valid {
+		key.CreatedBy = createdBy.String
+	}
+	return key, nil
+}
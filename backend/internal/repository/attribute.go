@@ -7,13 +7,17 @@ import (
 	"github.com/candidate-organizer/backend/internal/models"
 )
 
-// AttributeRepository defines the interface for candidate attribute operations
+// AttributeRepository defines the interface for candidate attribute operations.
+// Reads and writes are filtered by the AccessScope carried in the context:
+// reads need a visible candidate and writes an editable one.
 type AttributeRepository interface {
 	Create(ctx context.Context, attribute *models.CandidateAttribute) error
 	GetByID(ctx context.Context, id string) (*models.CandidateAttribute, error)
 	ListByCandidate(ctx context.Context, candidateID string) ([]*models.CandidateAttribute, error)
 	Update(ctx context.Context, attribute *models.CandidateAttribute) error
+	UpdateIfVersion(ctx context.Context, attribute *models.CandidateAttribute, version int) error
 	Delete(ctx context.Context, id string) error
+	DeleteIfVersion(ctx context.Context, id string, version int) error
 	DeleteByKey(ctx context.Context, candidateID, attributeKey string) error
 }
 
@@ -27,27 +31,35 @@ func NewPostgresAttributeRepository(db *sql.DB) *PostgresAttributeRepository {
 	return &PostgresAttributeRepository{db: db}
 }
 
+// Create inserts the attribute if the caller may edit the candidate, returning
+// ErrAccessDenied otherwise
 func (r *PostgresAttributeRepository) Create(ctx context.Context, attribute *models.CandidateAttribute) error {
+	editable, args := candidateRefEditableClause(accessScopeFrom(ctx), "$1::uuid", 4)
 	query := `
 		INSERT INTO candidate_attributes (candidate_id, attribute_key, attribute_value)
-		VALUES ($1, $2, $3)
-		RETURNING id, created_at, updated_at
+		SELECT $1, $2, $3
+		WHERE ` + editable + `
+		RETURNING id, version, created_at, updated_at
 	`
-	return r.db.QueryRowContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{
 		attribute.CandidateID, attribute.AttributeKey, attribute.AttributeValue,
-	).Scan(&attribute.ID, &attribute.CreatedAt, &attribute.UpdatedAt)
+	}, args...)...).Scan(&attribute.ID, &attribute.Version, &attribute.CreatedAt, &attribute.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAccessDenied
+	}
+	return err
 }
 
 func (r *PostgresAttributeRepository) GetByID(ctx context.Context, id string) (*models.CandidateAttribute, error) {
+	visible, args := candidateRefVisibleClause(accessScopeFrom(ctx), "candidate_attributes.candidate_id", 2)
 	query := `
-		SELECT id, candidate_id, attribute_key, attribute_value, created_at, updated_at
+		SELECT id, candidate_id, attribute_key, attribute_value, version, created_at, updated_at
 		FROM candidate_attributes
-		WHERE id = $1
-	`
+		WHERE id = $1 AND ` + visible
 	attribute := &models.CandidateAttribute{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...).Scan(
 		&attribute.ID, &attribute.CandidateID, &attribute.AttributeKey,
-		&attribute.AttributeValue, &attribute.CreatedAt, &attribute.UpdatedAt,
+		&attribute.AttributeValue, &attribute.Version, &attribute.CreatedAt, &attribute.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -56,13 +68,14 @@ func (r *PostgresAttributeRepository) GetByID(ctx context.Context, id string) (*
 }
 
 func (r *PostgresAttributeRepository) ListByCandidate(ctx context.Context, candidateID string) ([]*models.CandidateAttribute, error) {
+	visible, args := candidateRefVisibleClause(accessScopeFrom(ctx), "candidate_attributes.candidate_id", 2)
 	query := `
-		SELECT id, candidate_id, attribute_key, attribute_value, created_at, updated_at
+		SELECT id, candidate_id, attribute_key, attribute_value, version, created_at, updated_at
 		FROM candidate_attributes
-		WHERE candidate_id = $1
+		WHERE candidate_id = $1 AND ` + visible + `
 		ORDER BY attribute_key ASC
 	`
-	rows, err := r.db.QueryContext(ctx, query, candidateID)
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{candidateID}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +86,7 @@ func (r *PostgresAttributeRepository) ListByCandidate(ctx context.Context, candi
 		attribute := &models.CandidateAttribute{}
 		if err := rows.Scan(
 			&attribute.ID, &attribute.CandidateID, &attribute.AttributeKey,
-			&attribute.AttributeValue, &attribute.CreatedAt, &attribute.UpdatedAt,
+			&attribute.AttributeValue, &attribute.Version, &attribute.CreatedAt, &attribute.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -82,26 +95,72 @@ func (r *PostgresAttributeRepository) ListByCandidate(ctx context.Context, candi
 	return attributes, rows.Err()
 }
 
+// Update saves the attribute if the caller may edit the candidate, returning
+// ErrAccessDenied otherwise
 func (r *PostgresAttributeRepository) Update(ctx context.Context, attribute *models.CandidateAttribute) error {
+	return r.update(ctx, attribute, 0)
+}
+
+// UpdateIfVersion saves the attribute like Update, but only if it is still at
+// version, returning ErrVersionConflict if it has changed since
+func (r *PostgresAttributeRepository) UpdateIfVersion(ctx context.Context, attribute *models.CandidateAttribute, version int) error {
+	err := r.update(ctx, attribute, version)
+	if err == ErrAccessDenied {
+		return versionConflict(ctx, r.db, "candidate_attributes", attribute.ID, version, err)
+	}
+	return err
+}
+
+// update saves the attribute if it is at version, or at any version if version is 0
+func (r *PostgresAttributeRepository) update(ctx context.Context, attribute *models.CandidateAttribute, version int) error {
+	editable, args := candidateRefEditableClause(accessScopeFrom(ctx), "candidate_attributes.candidate_id", 5)
 	query := `
 		UPDATE candidate_attributes
 		SET attribute_key = $1, attribute_value = $2
-		WHERE id = $3
-		RETURNING updated_at
+		WHERE id = $3 AND ($4 = 0 OR version = $4) AND ` + editable + `
+		RETURNING version, updated_at
 	`
-	return r.db.QueryRowContext(ctx, query,
-		attribute.AttributeKey, attribute.AttributeValue, attribute.ID,
-	).Scan(&attribute.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{
+		attribute.AttributeKey, attribute.AttributeValue, attribute.ID, version,
+	}, args...)...).Scan(&attribute.Version, &attribute.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAccessDenied
+	}
+	return err
 }
 
+// Delete removes the attribute if the caller may edit the candidate, returning
+// ErrAccessDenied otherwise
 func (r *PostgresAttributeRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM candidate_attributes WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	return r.delete(ctx, id, 0)
+}
+
+// DeleteIfVersion removes the attribute like Delete, but only if it is still at
+// version, returning ErrVersionConflict if it has changed since
+func (r *PostgresAttributeRepository) DeleteIfVersion(ctx context.Context, id string, version int) error {
+	err := r.delete(ctx, id, version)
+	if err == ErrAccessDenied {
+		return versionConflict(ctx, r.db, "candidate_attributes", id, version, err)
+	}
 	return err
 }
 
+// delete removes the attribute if it is at version, or at any version if version is 0
+func (r *PostgresAttributeRepository) delete(ctx context.Context, id string, version int) error {
+	editable, args := candidateRefEditableClause(accessScopeFrom(ctx), "candidate_attributes.candidate_id", 3)
+	query := `DELETE FROM candidate_attributes WHERE id = $1 AND ($2 = 0 OR version = $2) AND ` + editable
+	result, err := r.db.ExecContext(ctx, query, append([]interface{}{id, version}, args...)...)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
+
+// DeleteByKey removes the candidate's attribute with the key, if the caller may
+// edit the candidate
 func (r *PostgresAttributeRepository) DeleteByKey(ctx context.Context, candidateID, attributeKey string) error {
-	query := `DELETE FROM candidate_attributes WHERE candidate_id = $1 AND attribute_key = $2`
-	_, err := r.db.ExecContext(ctx, query, candidateID, attributeKey)
+	editable, args := candidateRefEditableClause(accessScopeFrom(ctx), "$1::uuid", 3)
+	query := `DELETE FROM candidate_attributes WHERE candidate_id = $1 AND attribute_key = $2 AND ` + editable
+	_, err := r.db.ExecContext(ctx, query, append([]interface{}{candidateID, attributeKey}, args...)...)
 	return err
 }
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// AuditRepository defines the interface for audit trail operations
+type AuditRepository interface {
+	Record(ctx context.Context, event *models.AuditEvent) error
+	ListByEntity(ctx context.Context, entityType, entityID string) ([]*models.AuditEvent, error)
+}
+
+// PostgresAuditRepository implements AuditRepository for PostgreSQL
+type PostgresAuditRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresAuditRepository creates a new PostgresAuditRepository
+func NewPostgresAuditRepository(db *sql.DB) *PostgresAuditRepository {
+	return &PostgresAuditRepository{db: db}
+}
+
+// Record stores the event. Events recorded while handling a request made with an
+// API key are attributed to that key as well as to its user.
+func (r *PostgresAuditRepository) Record(ctx context.Context, event *models.AuditEvent) error {
+	if event.APIKeyID == "" {
+		event.APIKeyID = APIKeyFromContext(ctx)
+	}
+
+	detailsJSON, err := json.Marshal(event.Details)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_events (actor_id, action, entity_type, entity_id, details, api_key_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		nullStringOrNil(event.ActorID), event.Action, event.EntityType, event.EntityID, detailsJSON,
+		nullStringOrNil(event.APIKeyID),
+	).Scan(&event.ID, &event.CreatedAt)
+}
+
+func (r *PostgresAuditRepository) ListByEntity(ctx context.Context, entityType, entityID string) ([]*models.AuditEvent, error) {
+	query := `
+		SELECT a.id, a.actor_id, COALESCE(u.name, ''), a.action, a.entity_type, a.entity_id, a.details, a.api_key_id, a.created_at
+		FROM audit_events a
+		LEFT JOIN users u ON a.actor_id = u.id
+		WHERE a.entity_type = $1 AND a.entity_id = $2
+		ORDER BY a.created_at ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	for rows.Next() {
+		event := &models.AuditEvent{}
+		var actorID, apiKeyID sql.NullString
+		var detailsJSON []byte
+
+		if err := rows.Scan(
+			&event.ID, &actorID, &event.ActorName, &event.Action,
+			&event.EntityType, &event.EntityID, &detailsJSON, &apiKeyID, &event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if actorID.Valid {
+			event.ActorID = actorID.String
+		}
+		if apiKeyID.Valid {
+			event.APIKeyID = apiKeyID.String
+		}
+
+		if len(detailsJSON) > 0 {
+			if err := json.Unmarshal(detailsJSON, &event.Details); err != nil {
+				return nil, err
+			}
+		}
+
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -4,156 +4,448 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-// CandidateRepository defines the interface for candidate operations
+// CandidateRepository defines the interface for candidate operations.
+// Reads and writes are filtered by the AccessScope carried in the context.
 type CandidateRepository interface {
 	Create(ctx context.Context, candidate *models.Candidate) error
 	GetByID(ctx context.Context, id string) (*models.Candidate, error)
 	List(ctx context.Context, limit, offset int, filters map[string]interface{}) ([]*models.Candidate, error)
+	ListByEmail(ctx context.Context, email string) ([]*models.Candidate, error)
 	Update(ctx context.Context, candidate *models.Candidate) error
+	UpdateIfVersion(ctx context.Context, candidate *models.Candidate, version int) error
 	UpdateStatus(ctx context.Context, id, status string) error
 	Delete(ctx context.Context, id string) error
+	DeleteIfVersion(ctx context.Context, id string, version int) error
+	CountByStatus(ctx context.Context) (map[string]int, error)
+	Reencrypt(ctx context.Context, limit int) (int, error)
 }
 
 // PostgresCandidateRepository implements CandidateRepository for PostgreSQL
 type PostgresCandidateRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	cipher FieldCipher
 }
 
-// NewPostgresCandidateRepository creates a new PostgresCandidateRepository
-func NewPostgresCandidateRepository(db *sql.DB) *PostgresCandidateRepository {
-	return &PostgresCandidateRepository{db: db}
+// NewPostgresCandidateRepository creates a new PostgresCandidateRepository. The
+// email, phone, salary expectation and parsed resume data are encrypted with
+// cipher as it is configured; a nil cipher stores them in plaintext.
+func NewPostgresCandidateRepository(db *sql.DB, cipher FieldCipher) *PostgresCandidateRepository {
+	return &PostgresCandidateRepository{db: db, cipher: cipher}
 }
 
+const candidateColumns = `c.id, c.name, c.email, c.phone, c.resume_url, c.parsed_data, c.status, c.salary_expectation, c.job_posting_id,
+	c.version, c.created_at, c.updated_at, c.created_by, c.erased_at`
+
+// Create inserts the candidate with a new ID if the caller can see its job,
+// returning ErrAccessDenied otherwise
 func (r *PostgresCandidateRepository) Create(ctx context.Context, candidate *models.Candidate) error {
-	parsedDataJSON, err := json.Marshal(candidate.ParsedData)
+	// The ID is chosen here because encrypted values are bound to it
+	id := uuid.NewString()
+	sealed, err := r.seal(ctx, id, candidate)
 	if err != nil {
 		return err
 	}
 
+	jobVisible, args := jobRefVisibleClause(accessScopeFrom(ctx), "$8::uuid", 14)
 	query := `
-		INSERT INTO candidates (name, email, phone, resume_url, parsed_data, status, salary_expectation, job_posting_id, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, created_at, updated_at
+		INSERT INTO candidates (name, email, phone, resume_url, parsed_data, status, salary_expectation, job_posting_id, created_by,
+			email_index, data_key_id, encrypted_columns, id)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+		WHERE ` + jobVisible + `
+		RETURNING id, version, created_at, updated_at
 	`
-	return r.db.QueryRowContext(ctx, query,
-		candidate.Name, candidate.Email, candidate.Phone, candidate.ResumeURL,
-		parsedDataJSON, candidate.Status, candidate.SalaryExpectation,
+	err = r.db.QueryRowContext(ctx, query, append([]interface{}{
+		candidate.Name, sealed.email, sealed.phone, candidate.ResumeURL,
+		sealed.parsedData, candidate.Status, sealed.salaryExpectation,
 		nullStringOrNil(candidate.JobPostingID), candidate.CreatedBy,
-	).Scan(&candidate.ID, &candidate.CreatedAt, &candidate.UpdatedAt)
+		sealed.emailIndex, sealed.dataKeyID, sealed.encryptedColumns, id,
+	}, args...)...).Scan(&candidate.ID, &candidate.Version, &candidate.CreatedAt, &candidate.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAccessDenied
+	}
+	return err
 }
 
 func (r *PostgresCandidateRepository) GetByID(ctx context.Context, id string) (*models.Candidate, error) {
+	visible, args := candidateVisibleClause(accessScopeFrom(ctx), "c", 2)
 	query := `
-		SELECT id, name, email, phone, resume_url, parsed_data, status, salary_expectation, job_posting_id, created_at, updated_at, created_by
-		FROM candidates
-		WHERE id = $1
-	`
-	candidate := &models.Candidate{}
-	var parsedDataJSON []byte
-	var jobPostingID sql.NullString
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&candidate.ID, &candidate.Name, &candidate.Email, &candidate.Phone,
-		&candidate.ResumeURL, &parsedDataJSON, &candidate.Status,
-		&candidate.SalaryExpectation, &jobPostingID,
-		&candidate.CreatedAt, &candidate.UpdatedAt, &candidate.CreatedBy,
-	)
+		SELECT ` + candidateColumns + `
+		FROM candidates c
+		WHERE c.id = $1 AND ` + visible
+	candidate, err := r.scanCandidate(ctx, r.db.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
+	return candidate, err
+}
 
-	if jobPostingID.Valid {
-		candidate.JobPostingID = jobPostingID.String
-	}
+func (r *PostgresCandidateRepository) List(ctx context.Context, limit, offset int, filters map[string]interface{}) ([]*models.Candidate, error) {
+	visible, args := candidateVisibleClause(accessScopeFrom(ctx), "c", 3)
+	query := `
+		SELECT ` + candidateColumns + `
+		FROM candidates c
+		WHERE ` + visible + `
+		ORDER BY c.created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+	return r.queryCandidates(ctx, query, append([]interface{}{limit, offset}, args...)...)
+}
 
-	if len(parsedDataJSON) > 0 {
-		if err := json.Unmarshal(parsedDataJSON, &candidate.ParsedData); err != nil {
+// ListByEmail returns the visible candidates with an email address, ignoring
+// case, newest first. Encrypted emails are matched by their blind index.
+func (r *PostgresCandidateRepository) ListByEmail(ctx context.Context, email string) ([]*models.Candidate, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return []*models.Candidate{}, nil
+	}
+	var index interface{}
+	if r.cipher != nil {
+		blindIndex, err := r.cipher.BlindIndex(ctx, email)
+		if err != nil {
 			return nil, err
 		}
+		index = blindIndex
 	}
 
-	return candidate, nil
+	// Candidates written before encryption was enabled have no blind index yet
+	visible, args := candidateVisibleClause(accessScopeFrom(ctx), "c", 3)
+	query := `
+		SELECT ` + candidateColumns + `
+		FROM candidates c
+		WHERE (c.email_index = $1 OR (c.email_index IS NULL AND lower(c.email) = lower($2))) AND ` + visible + `
+		ORDER BY c.created_at DESC
+	`
+	return r.queryCandidates(ctx, query, append([]interface{}{index, email}, args...)...)
 }
 
-func (r *PostgresCandidateRepository) List(ctx context.Context, limit, offset int, filters map[string]interface{}) ([]*models.Candidate, error) {
+func (r *PostgresCandidateRepository) Update(ctx context.Context, candidate *models.Candidate) error {
+	return r.update(ctx, candidate, 0)
+}
+
+// UpdateIfVersion saves the candidate like Update, but only if it is still at
+// version, returning ErrVersionConflict if it has changed since
+func (r *PostgresCandidateRepository) UpdateIfVersion(ctx context.Context, candidate *models.Candidate, version int) error {
+	err := r.update(ctx, candidate, version)
+	if err == ErrAccessDenied {
+		return versionConflict(ctx, r.db, "candidates", candidate.ID, version, err)
+	}
+	return err
+}
+
+// update saves the candidate if it is at version, or at any version if version is 0
+func (r *PostgresCandidateRepository) update(ctx context.Context, candidate *models.Candidate, version int) error {
+	sealed, err := r.seal(ctx, candidate.ID, candidate)
+	if err != nil {
+		return err
+	}
+
+	// The candidate must be editable where it is, and its new job visible
+	scope := accessScopeFrom(ctx)
+	editable, args := candidateEditableClause(scope, "c", 14)
+	jobVisible, jobArgs := jobRefVisibleClause(scope, "$8::uuid", 14+len(args))
+	args = append(args, jobArgs...)
 	query := `
-		SELECT id, name, email, phone, resume_url, parsed_data, status, salary_expectation, job_posting_id, created_at, updated_at, created_by
-		FROM candidates
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		UPDATE candidates c
+		SET name = $1, email = $2, phone = $3, resume_url = $4, parsed_data = $5, status = $6, salary_expectation = $7, job_posting_id = $8,
+			email_index = $10, data_key_id = $11, encrypted_columns = $12
+		WHERE c.id = $9 AND ($13 = 0 OR c.version = $13) AND ` + editable + ` AND ` + jobVisible + `
+		RETURNING c.version, c.updated_at
 	`
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	err = r.db.QueryRowContext(ctx, query, append([]interface{}{
+		candidate.Name, sealed.email, sealed.phone, candidate.ResumeURL,
+		sealed.parsedData, candidate.Status, sealed.salaryExpectation,
+		nullStringOrNil(candidate.JobPostingID), candidate.ID,
+		sealed.emailIndex, sealed.dataKeyID, sealed.encryptedColumns, version,
+	}, args...)...).Scan(&candidate.Version, &candidate.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAccessDenied
+	}
+	return err
+}
+
+func (r *PostgresCandidateRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	editable, args := candidateEditableClause(accessScopeFrom(ctx), "c", 3)
+	query := `UPDATE candidates c SET status = $1 WHERE c.id = $2 AND ` + editable
+	result, err := r.db.ExecContext(ctx, query, append([]interface{}{status, id}, args...)...)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
+
+func (r *PostgresCandidateRepository) Delete(ctx context.Context, id string) error {
+	return r.delete(ctx, id, 0)
+}
+
+// DeleteIfVersion removes the candidate like Delete, but only if it is still at
+// version, returning ErrVersionConflict if it has changed since
+func (r *PostgresCandidateRepository) DeleteIfVersion(ctx context.Context, id string, version int) error {
+	err := r.delete(ctx, id, version)
+	if err == ErrAccessDenied {
+		return versionConflict(ctx, r.db, "candidates", id, version, err)
+	}
+	return err
+}
+
+// delete removes the candidate if it is at version, or at any version if version is 0
+func (r *PostgresCandidateRepository) delete(ctx context.Context, id string, version int) error {
+	editable, args := candidateEditableClause(accessScopeFrom(ctx), "c", 3)
+	query := `DELETE FROM candidates c WHERE c.id = $1 AND ($2 = 0 OR c.version = $2) AND ` + editable
+	result, err := r.db.ExecContext(ctx, query, append([]interface{}{id, version}, args...)...)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
+
+// CountByStatus returns the number of visible candidates in each status
+func (r *PostgresCandidateRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	visible, args := candidateVisibleClause(accessScopeFrom(ctx), "c", 1)
+	query := `SELECT c.status, COUNT(*) FROM candidates c WHERE ` + visible + ` GROUP BY c.status`
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	return scanStatusCounts(rows)
+}
+
+// Reencrypt rewrites up to limit candidates that are not encrypted with the
+// current data key and columns, and returns how many it rewrote. Rows that other
+// transactions hold are skipped, and updated_at is left alone.
+func (r *PostgresCandidateRepository) Reencrypt(ctx context.Context, limit int) (int, error) {
+	if r.cipher == nil {
+		return 0, nil
+	}
+	sealer, err := r.cipher.Sealer(ctx)
+	if err != nil {
+		return 0, err
+	}
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer rollback(ctx, tx)
+
+	if _, err := tx.ExecContext(ctx, `SET LOCAL app.housekeeping = 'on'`); err != nil {
+		return 0, err
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+candidateColumns+`
+		FROM candidates c
+		WHERE c.data_key_id IS DISTINCT FROM $1 OR c.encrypted_columns IS DISTINCT FROM $2
+		ORDER BY c.id
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`, sealer.KeyID(), pq.Array(sealer.Columns()), limit)
+	if err != nil {
+		return 0, err
+	}
 	var candidates []*models.Candidate
 	for rows.Next() {
-		candidate := &models.Candidate{}
-		var parsedDataJSON []byte
-		var jobPostingID sql.NullString
-
-		if err := rows.Scan(
-			&candidate.ID, &candidate.Name, &candidate.Email, &candidate.Phone,
-			&candidate.ResumeURL, &parsedDataJSON, &candidate.Status,
-			&candidate.SalaryExpectation, &jobPostingID,
-			&candidate.CreatedAt, &candidate.UpdatedAt, &candidate.CreatedBy,
-		); err != nil {
-			return nil, err
+		candidate, err := r.scanCandidate(ctx, rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
 		}
+		candidates = append(candidates, candidate)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
 
-		if jobPostingID.Valid {
-			candidate.JobPostingID = jobPostingID.String
+	for _, candidate := range candidates {
+		sealed, err := r.sealWith(ctx, sealer, candidate.ID, candidate)
+		if err != nil {
+			return 0, err
 		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE candidates
+			SET email = $1, phone = $2, parsed_data = $3, salary_expectation = $4,
+				email_index = $5, data_key_id = $6, encrypted_columns = $7
+			WHERE id = $8
+		`, sealed.email, sealed.phone, sealed.parsedData, sealed.salaryExpectation,
+			sealed.emailIndex, sealed.dataKeyID, sealed.encryptedColumns, candidate.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(candidates), tx.Commit()
+}
+
+// sealedCandidate holds a candidate's sensitive columns as they are stored
+type sealedCandidate struct {
+	email             string
+	phone             string
+	salaryExpectation string
+	parsedData        []byte
+	emailIndex        interface{}
+	dataKeyID         interface{}
+	encryptedColumns  interface{}
+}
+
+// seal encrypts the sensitive columns of a candidate stored with the given ID
+// with the current data key
+func (r *PostgresCandidateRepository) seal(ctx context.Context, id string, candidate *models.Candidate) (*sealedCandidate, error) {
+	if r.cipher == nil {
+		return r.sealWith(ctx, nil, id, candidate)
+	}
+	sealer, err := r.cipher.Sealer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.sealWith(ctx, sealer, id, candidate)
+}
 
-		if len(parsedDataJSON) > 0 {
-			if err := json.Unmarshal(parsedDataJSON, &candidate.ParsedData); err != nil {
-				return nil, err
-			}
+// sealWith encrypts the sensitive columns of a candidate stored with the given
+// ID with sealer, or leaves them in plaintext if it is nil
+func (r *PostgresCandidateRepository) sealWith(ctx context.Context, sealer FieldSealer, id string, candidate *models.Candidate) (*sealedCandidate, error) {
+	parsedDataJSON, err := json.Marshal(candidate.ParsedData)
+	if err != nil {
+		return nil, err
+	}
+	sealed := &sealedCandidate{
+		email:             candidate.Email,
+		phone:             candidate.Phone,
+		salaryExpectation: candidate.SalaryExpectation,
+		parsedData:        parsedDataJSON,
+	}
+	if sealer == nil {
+		return sealed, nil
+	}
+
+	for column, value := range map[string]*string{
+		"email":              &sealed.email,
+		"phone":              &sealed.phone,
+		"salary_expectation": &sealed.salaryExpectation,
+	} {
+		if *value, err = sealer.Seal(id, column, *value); err != nil {
+			return nil, err
 		}
+	}
+	if candidate.ParsedData != nil && slices.Contains(sealer.Columns(), "parsed_data") {
+		value, err := sealer.Seal(id, "parsed_data", string(parsedDataJSON))
+		if err != nil {
+			return nil, err
+		}
+		// The column is JSONB, so ciphertext is stored as a JSON string
+		if sealed.parsedData, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	if candidate.Email != "" {
+		if sealed.emailIndex, err = r.cipher.BlindIndex(ctx, candidate.Email); err != nil {
+			return nil, err
+		}
+	}
+	sealed.dataKeyID = sealer.KeyID()
+	sealed.encryptedColumns = pq.Array(sealer.Columns())
+	return sealed, nil
+}
+
+// queryCandidates runs a query selecting candidateColumns
+func (r *PostgresCandidateRepository) queryCandidates(ctx context.Context, query string, args ...interface{}) ([]*models.Candidate, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	var candidates []*models.Candidate
+	for rows.Next() {
+		candidate, err := r.scanCandidate(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
 		candidates = append(candidates, candidate)
 	}
 	return candidates, rows.Err()
 }
 
-func (r *PostgresCandidateRepository) Update(ctx context.Context, candidate *models.Candidate) error {
-	parsedDataJSON, err := json.Marshal(candidate.ParsedData)
-	if err != nil {
-		return err
+// scanCandidate reads a row of candidateColumns, decrypting its sensitive columns
+func (r *PostgresCandidateRepository) scanCandidate(ctx context.Context, row rowScanner) (*models.Candidate, error) {
+	candidate := &models.Candidate{}
+	var parsedDataJSON []byte
+	var jobPostingID sql.NullString
+	var erasedAt sql.NullTime
+
+	if err := row.Scan(
+		&candidate.ID, &candidate.Name, &candidate.Email, &candidate.Phone,
+		&candidate.ResumeURL, &parsedDataJSON, &candidate.Status,
+		&candidate.SalaryExpectation, &jobPostingID,
+		&candidate.Version, &candidate.CreatedAt, &candidate.UpdatedAt, &candidate.CreatedBy, &erasedAt,
+	); err != nil {
+		return nil, err
 	}
 
-	query := `
-		UPDATE candidates
-		SET name = $1, email = $2, phone = $3, resume_url = $4, parsed_data = $5, status = $6, salary_expectation = $7, job_posting_id = $8
-		WHERE id = $9
-		RETURNING updated_at
-	`
-	return r.db.QueryRowContext(ctx, query,
-		candidate.Name, candidate.Email, candidate.Phone, candidate.ResumeURL,
-		parsedDataJSON, candidate.Status, candidate.SalaryExpectation,
-		nullStringOrNil(candidate.JobPostingID), candidate.ID,
-	).Scan(&candidate.UpdatedAt)
+	if jobPostingID.Valid {
+		candidate.JobPostingID = jobPostingID.String
+	}
+	candidate.ErasedAt = timePtr(erasedAt)
+
+	var err error
+	for column, value := range map[string]*string{
+		"email":              &candidate.Email,
+		"phone":              &candidate.Phone,
+		"salary_expectation": &candidate.SalaryExpectation,
+	} {
+		if *value, err = r.open(ctx, candidate.ID, column, *value); err != nil {
+			return nil, err
+		}
+	}
+	if len(parsedDataJSON) > 0 && parsedDataJSON[0] == '"' {
+		var value string
+		if err := json.Unmarshal(parsedDataJSON, &value); err != nil {
+			return nil, err
+		}
+		if value, err = r.open(ctx, candidate.ID, "parsed_data", value); err != nil {
+			return nil, err
+		}
+		parsedDataJSON = []byte(value)
+	}
+	if len(parsedDataJSON) > 0 {
+		if err := json.Unmarshal(parsedDataJSON, &candidate.ParsedData); err != nil {
+			return nil, err
+		}
+	}
+
+	return candidate, nil
 }
 
-func (r *PostgresCandidateRepository) UpdateStatus(ctx context.Context, id, status string) error {
-	query := `UPDATE candidates SET status = $1 WHERE id = $2`
-	_, err := r.db.ExecContext(ctx, query, status, id)
-	return err
+// open decrypts a sealed value of the candidate with ID id; plaintext values are
+// returned unchanged
+func (r *PostgresCandidateRepository) open(ctx context.Context, id, column, value string) (string, error) {
+	if !strings.HasPrefix(value, SealedPrefix) {
+		return value, nil
+	}
+	if r.cipher == nil {
+		return "", fmt.Errorf("candidate %s is encrypted but no encryption keys are configured", column)
+	}
+	return r.cipher.Open(ctx, id, column, value)
 }
 
-func (r *PostgresCandidateRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM candidates WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
-	return err
+// scanStatusCounts reads rows of (status, count)
+func scanStatusCounts(rows *sql.Rows) (map[string]int, error) {
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
 }
 
 // Helper function to handle nullable strings
@@ -0,0 +1,29 @@
+package repository
+
+import "context"
+
+// SealedPrefix starts every value a FieldCipher encrypts, telling ciphertext
+// apart from values written before their column was encrypted
+const SealedPrefix = "enc:"
+
+// FieldCipher encrypts sensitive columns before they are written and decrypts
+// them after they are read
+type FieldCipher interface {
+	// Sealer returns the data key and columns new values are written with
+	Sealer(ctx context.Context) (FieldSealer, error)
+	// Open decrypts a value that was sealed for column of the row with ID rowID
+	Open(ctx context.Context, rowID, column, value string) (string, error)
+	// BlindIndex returns a keyed hash of a normalized value, so that encrypted
+	// values can still be looked up by equality
+	BlindIndex(ctx context.Context, value string) (string, error)
+}
+
+// FieldSealer encrypts the configured columns with one data key
+type FieldSealer interface {
+	KeyID() string
+	Columns() []string // Sorted
+	// Seal encrypts value if column is encrypted, and returns it unchanged otherwise.
+	// Empty values stay empty. The ciphertext is bound to the row's ID and the
+	// column, so that it cannot be copied to another row or column.
+	Seal(rowID, column, value string) (string, error)
+}
@@ -7,13 +7,17 @@ import (
 	"github.com/candidate-organizer/backend/internal/models"
 )
 
-// CommentRepository defines the interface for comment operations
+// CommentRepository defines the interface for comment operations.
+// Reads and writes are filtered by the AccessScope carried in the context: the
+// caller must be able to see the candidate.
 type CommentRepository interface {
 	Create(ctx context.Context, comment *models.Comment) error
 	GetByID(ctx context.Context, id string) (*models.Comment, error)
 	ListByCandidate(ctx context.Context, candidateID string) ([]*models.Comment, error)
 	Update(ctx context.Context, comment *models.Comment) error
+	UpdateIfVersion(ctx context.Context, comment *models.Comment, version int) error
 	Delete(ctx context.Context, id string) error
+	DeleteIfVersion(ctx context.Context, id string, version int) error
 }
 
 // PostgresCommentRepository implements CommentRepository for PostgreSQL
@@ -26,28 +30,36 @@ func NewPostgresCommentRepository(db *sql.DB) *PostgresCommentRepository {
 	return &PostgresCommentRepository{db: db}
 }
 
+// Create inserts the comment if the caller can see the candidate, returning
+// ErrAccessDenied otherwise
 func (r *PostgresCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	visible, args := candidateRefVisibleClause(accessScopeFrom(ctx), "$1::uuid", 4)
 	query := `
 		INSERT INTO comments (candidate_id, user_id, content)
-		VALUES ($1, $2, $3)
-		RETURNING id, created_at, updated_at
+		SELECT $1, $2, $3
+		WHERE ` + visible + `
+		RETURNING id, version, created_at, updated_at
 	`
-	return r.db.QueryRowContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{
 		comment.CandidateID, comment.UserID, comment.Content,
-	).Scan(&comment.ID, &comment.CreatedAt, &comment.UpdatedAt)
+	}, args...)...).Scan(&comment.ID, &comment.Version, &comment.CreatedAt, &comment.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAccessDenied
+	}
+	return err
 }
 
 func (r *PostgresCommentRepository) GetByID(ctx context.Context, id string) (*models.Comment, error) {
+	visible, args := candidateRefVisibleClause(accessScopeFrom(ctx), "c.candidate_id", 2)
 	query := `
-		SELECT c.id, c.candidate_id, c.user_id, u.name as user_name, c.content, c.created_at, c.updated_at
+		SELECT c.id, c.candidate_id, c.user_id, u.name as user_name, c.content, c.version, c.created_at, c.updated_at
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.id = $1
-	`
+		WHERE c.id = $1 AND ` + visible
 	comment := &models.Comment{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...).Scan(
 		&comment.ID, &comment.CandidateID, &comment.UserID,
-		&comment.UserName, &comment.Content,
+		&comment.UserName, &comment.Content, &comment.Version,
 		&comment.CreatedAt, &comment.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
@@ -57,14 +69,15 @@ func (r *PostgresCommentRepository) GetByID(ctx context.Context, id string) (*mo
 }
 
 func (r *PostgresCommentRepository) ListByCandidate(ctx context.Context, candidateID string) ([]*models.Comment, error) {
+	visible, args := candidateRefVisibleClause(accessScopeFrom(ctx), "c.candidate_id", 2)
 	query := `
-		SELECT c.id, c.candidate_id, c.user_id, u.name as user_name, c.content, c.created_at, c.updated_at
+		SELECT c.id, c.candidate_id, c.user_id, u.name as user_name, c.content, c.version, c.created_at, c.updated_at
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.candidate_id = $1
+		WHERE c.candidate_id = $1 AND ` + visible + `
 		ORDER BY c.created_at ASC
 	`
-	rows, err := r.db.QueryContext(ctx, query, candidateID)
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{candidateID}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +88,7 @@ func (r *PostgresCommentRepository) ListByCandidate(ctx context.Context, candida
 		comment := &models.Comment{}
 		if err := rows.Scan(
 			&comment.ID, &comment.CandidateID, &comment.UserID,
-			&comment.UserName, &comment.Content,
+			&comment.UserName, &comment.Content, &comment.Version,
 			&comment.CreatedAt, &comment.UpdatedAt,
 		); err != nil {
 			return nil, err
@@ -85,19 +98,62 @@ func (r *PostgresCommentRepository) ListByCandidate(ctx context.Context, candida
 	return comments, rows.Err()
 }
 
+// Update saves the comment if the caller can see the candidate, returning
+// ErrAccessDenied otherwise
 func (r *PostgresCommentRepository) Update(ctx context.Context, comment *models.Comment) error {
+	return r.update(ctx, comment, 0)
+}
+
+// UpdateIfVersion saves the comment like Update, but only if it is still at
+// version, returning ErrVersionConflict if it has changed since
+func (r *PostgresCommentRepository) UpdateIfVersion(ctx context.Context, comment *models.Comment, version int) error {
+	err := r.update(ctx, comment, version)
+	if err == ErrAccessDenied {
+		return versionConflict(ctx, r.db, "comments", comment.ID, version, err)
+	}
+	return err
+}
+
+// update saves the comment if it is at version, or at any version if version is 0
+func (r *PostgresCommentRepository) update(ctx context.Context, comment *models.Comment, version int) error {
+	visible, args := candidateRefVisibleClause(accessScopeFrom(ctx), "comments.candidate_id", 4)
 	query := `
 		UPDATE comments
 		SET content = $1
-		WHERE id = $2
-		RETURNING updated_at
+		WHERE id = $2 AND ($3 = 0 OR version = $3) AND ` + visible + `
+		RETURNING version, updated_at
 	`
-	return r.db.QueryRowContext(ctx, query, comment.Content, comment.ID).
-		Scan(&comment.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{comment.Content, comment.ID, version}, args...)...).
+		Scan(&comment.Version, &comment.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAccessDenied
+	}
+	return err
 }
 
+// Delete removes the comment if the caller can see the candidate, returning
+// ErrAccessDenied otherwise
 func (r *PostgresCommentRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM comments WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	return r.delete(ctx, id, 0)
+}
+
+// DeleteIfVersion removes the comment like Delete, but only if it is still at
+// version, returning ErrVersionConflict if it has changed since
+func (r *PostgresCommentRepository) DeleteIfVersion(ctx context.Context, id string, version int) error {
+	err := r.delete(ctx, id, version)
+	if err == ErrAccessDenied {
+		return versionConflict(ctx, r.db, "comments", id, version, err)
+	}
 	return err
 }
+
+// delete removes the comment if it is at version, or at any version if version is 0
+func (r *PostgresCommentRepository) delete(ctx context.Context, id string, version int) error {
+	visible, args := candidateRefVisibleClause(accessScopeFrom(ctx), "comments.candidate_id", 3)
+	query := `DELETE FROM comments WHERE id = $1 AND ($2 = 0 OR version = $2) AND ` + visible
+	result, err := r.db.ExecContext(ctx, query, append([]interface{}{id, version}, args...)...)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
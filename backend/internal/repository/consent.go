@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// ConsentRepository defines the interface for candidates' consents to be kept in
+// the talent pool
+type ConsentRepository interface {
+	Create(ctx context.Context, consent *models.CandidateConsent) error
+	ListByCandidate(ctx context.Context, candidateID string) ([]*models.CandidateConsent, error)
+	Revoke(ctx context.Context, candidateID, id string) (*models.CandidateConsent, error)
+}
+
+// PostgresConsentRepository implements ConsentRepository for PostgreSQL
+type PostgresConsentRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresConsentRepository creates a new PostgresConsentRepository
+func NewPostgresConsentRepository(db *sql.DB) *PostgresConsentRepository {
+	return &PostgresConsentRepository{db: db}
+}
+
+const consentColumns = `cc.id, cc.candidate_id, cc.expires_at, cc.note, cc.recorded_by, COALESCE(u.name, ''), cc.revoked_at, cc.created_at`
+
+func (r *PostgresConsentRepository) Create(ctx context.Context, consent *models.CandidateConsent) error {
+	query := `
+		INSERT INTO candidate_consents (candidate_id, expires_at, note, recorded_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		consent.CandidateID, consent.ExpiresAt, nullStringOrNil(consent.Note), nullStringOrNil(consent.RecordedBy),
+	).Scan(&consent.ID, &consent.CreatedAt)
+}
+
+// ListByCandidate returns a candidate's consents, newest first
+func (r *PostgresConsentRepository) ListByCandidate(ctx context.Context, candidateID string) ([]*models.CandidateConsent, error) {
+	query := `
+		SELECT ` + consentColumns + `
+		FROM candidate_consents cc
+		LEFT JOIN users u ON cc.recorded_by = u.id
+		WHERE cc.candidate_id = $1
+		ORDER BY cc.created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consents := []*models.CandidateConsent{}
+	for rows.Next() {
+		consent, err := scanConsent(rows)
+		if err != nil {
+			return nil, err
+		}
+		consents = append(consents, consent)
+	}
+	return consents, rows.Err()
+}
+
+// Revoke withdraws one of a candidate's consents, returning it, or nil if the
+// candidate has no such consent that is not already revoked
+func (r *PostgresConsentRepository) Revoke(ctx context.Context, candidateID, id string) (*models.CandidateConsent, error) {
+	query := `
+		WITH revoked AS (
+			UPDATE candidate_consents SET revoked_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND candidate_id = $2 AND revoked_at IS NULL
+			RETURNING *
+		)
+		SELECT ` + consentColumns + `
+		FROM revoked cc
+		LEFT JOIN users u ON cc.recorded_by = u.id
+	`
+	consent, err := scanConsent(r.db.QueryRowContext(ctx, query, id, candidateID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return consent, err
+}
+
+func scanConsent(row rowScanner) (*models.CandidateConsent, error) {
+	consent := &models.CandidateConsent{}
+	var note, recordedBy sql.NullString
+	var revokedAt sql.NullTime
+	if err := row.Scan(
+		&consent.ID, &consent.CandidateID, &consent.ExpiresAt, &note, &recordedBy, &consent.RecordedByName,
+		&revokedAt, &consent.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	consent.Note = note.String
+	consent.RecordedBy = recordedBy.String
+	consent.RevokedAt = timePtr(revokedAt)
+	return consent, nil
+}
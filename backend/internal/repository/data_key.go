@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// dataKeyCreationLock is the advisory lock key that stops instances starting
+// together from each creating a first data key
+const dataKeyCreationLock = 7_304_003
+
+// DataKeyRepository defines the interface for the wrapped keys that encrypt
+// sensitive columns
+type DataKeyRepository interface {
+	List(ctx context.Context) ([]*models.DataKey, error)
+	Create(ctx context.Context, key *models.DataKey) error
+	CreateIfMissing(ctx context.Context, key *models.DataKey) (bool, error)
+	Rewrap(ctx context.Context, id string, wrappedKey []byte, masterKeyID string) error
+	RetireUnused(ctx context.Context, currentID string) (int64, error)
+}
+
+// PostgresDataKeyRepository implements DataKeyRepository for PostgreSQL
+type PostgresDataKeyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresDataKeyRepository creates a new PostgresDataKeyRepository
+func NewPostgresDataKeyRepository(db *sql.DB) *PostgresDataKeyRepository {
+	return &PostgresDataKeyRepository{db: db}
+}
+
+// List returns every key, newest first, with the number of candidates encrypted with it
+func (r *PostgresDataKeyRepository) List(ctx context.Context) ([]*models.DataKey, error) {
+	query := `
+		SELECT k.id, k.purpose, k.wrapped_key, k.master_key_id,
+			(SELECT COUNT(*) FROM candidates c WHERE c.data_key_id = k.id),
+			k.created_at, k.retired_at
+		FROM data_keys k
+		ORDER BY k.created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.DataKey
+	for rows.Next() {
+		key := &models.DataKey{}
+		var retiredAt sql.NullTime
+		if err := rows.Scan(
+			&key.ID, &key.Purpose, &key.WrappedKey, &key.MasterKeyID, &key.Candidates, &key.CreatedAt, &retiredAt,
+		); err != nil {
+			return nil, err
+		}
+		key.RetiredAt = timePtr(retiredAt)
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Create stores a new key. A new data key encrypts everything written after it.
+func (r *PostgresDataKeyRepository) Create(ctx context.Context, key *models.DataKey) error {
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO data_keys (purpose, wrapped_key, master_key_id)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, key.Purpose, key.WrappedKey, key.MasterKeyID).Scan(&key.ID, &key.CreatedAt)
+}
+
+// CreateIfMissing stores key if there is no key with its purpose yet, and reports
+// whether it did. Instances racing to create the first keys are serialised by an
+// advisory lock, so only one of them stores a key.
+func (r *PostgresDataKeyRepository) CreateIfMissing(ctx context.Context, key *models.DataKey) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer rollback(ctx, tx)
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, dataKeyCreationLock); err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM data_keys WHERE purpose = $1 AND retired_at IS NULL)
+	`, key.Purpose).Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO data_keys (purpose, wrapped_key, master_key_id)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, key.Purpose, key.WrappedKey, key.MasterKeyID).Scan(&key.ID, &key.CreatedAt); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Rewrap replaces a key's wrapped form, after it was wrapped again with another master key
+func (r *PostgresDataKeyRepository) Rewrap(ctx context.Context, id string, wrappedKey []byte, masterKeyID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE data_keys SET wrapped_key = $1, master_key_id = $2 WHERE id = $3
+	`, wrappedKey, masterKeyID, id)
+	return err
+}
+
+// RetireUnused marks the data keys other than currentID that no candidate is
+// encrypted with any more. Retired keys are kept to read backups made before.
+func (r *PostgresDataKeyRepository) RetireUnused(ctx context.Context, currentID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE data_keys k SET retired_at = CURRENT_TIMESTAMP
+		WHERE k.purpose = 'data' AND k.id <> $1 AND k.retired_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM candidates c WHERE c.data_key_id = k.id)
+	`, currentID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
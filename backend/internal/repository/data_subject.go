@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// DataSubjectRepository reads and erases what is held about a candidate, to answer
+// data subject requests. Access scopes do not apply: requests cover every job.
+type DataSubjectRepository interface {
+	ListSummaries(ctx context.Context, candidateID string) ([]*models.AISummary, error)
+	Erase(ctx context.Context, candidateID string) (*models.CandidateErasure, error)
+}
+
+// PostgresDataSubjectRepository implements DataSubjectRepository for PostgreSQL
+type PostgresDataSubjectRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresDataSubjectRepository creates a new PostgresDataSubjectRepository
+func NewPostgresDataSubjectRepository(db *sql.DB) *PostgresDataSubjectRepository {
+	return &PostgresDataSubjectRepository{db: db}
+}
+
+// ErasedCandidateName replaces the name of a candidate whose data was erased
+const ErasedCandidateName = "Erased candidate"
+
+// ListSummaries returns the AI summaries generated for a candidate
+func (r *PostgresDataSubjectRepository) ListSummaries(ctx context.Context, candidateID string) ([]*models.AISummary, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, candidate_id, job_posting_id, summary, created_at, updated_at
+		FROM ai_summaries
+		WHERE candidate_id = $1
+		ORDER BY created_at
+	`, candidateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []*models.AISummary{}
+	for rows.Next() {
+		s := &models.AISummary{}
+		var jobPostingID sql.NullString
+		if err := rows.Scan(&s.ID, &s.CandidateID, &jobPostingID, &s.Summary, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		s.JobPostingID = jobPostingID.String
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// Erase irreversibly removes a candidate's personal data in one transaction. Free
+// text about them (comments, attributes, summaries and consents) is deleted; the candidate
+// row is kept with its status, job and dates but nothing identifying, and offers
+// keep their status but lose their compensation. Audit events about the candidate
+// and their offers keep the action and time but lose their details. It returns
+// nil if the candidate does not exist.
+func (r *PostgresDataSubjectRepository) Erase(ctx context.Context, candidateID string) (*models.CandidateErasure, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(ctx, tx)
+
+	var id string
+	err = tx.QueryRowContext(ctx, `SELECT id FROM candidates WHERE id = $1 FOR UPDATE`, candidateID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	erasure := &models.CandidateErasure{CandidateID: candidateID}
+	steps := []struct {
+		count *int64
+		query string
+	}{
+		{&erasure.Comments, `DELETE FROM comments WHERE candidate_id = $1`},
+		{&erasure.Attributes, `DELETE FROM candidate_attributes WHERE candidate_id = $1`},
+		{&erasure.Summaries, `DELETE FROM ai_summaries WHERE candidate_id = $1`},
+		{nil, `DELETE FROM candidate_consents WHERE candidate_id = $1`},
+		{nil, `
+			UPDATE offer_approvals SET comment = ''
+			WHERE offer_id IN (SELECT id FROM offers WHERE candidate_id = $1)
+		`},
+		{&erasure.Offers, `
+			UPDATE offers
+			SET base_salary = '', bonus = '', equity = '', compensation_notes = ''
+			WHERE candidate_id = $1
+		`},
+		{&erasure.AuditEvents, `
+			UPDATE audit_events SET details = NULL
+			WHERE (entity_type = 'candidate' AND entity_id = $1)
+				OR (entity_type = 'offer' AND entity_id IN (SELECT id::text FROM offers WHERE candidate_id = $1))
+		`},
+	}
+	for _, step := range steps {
+		result, err := tx.ExecContext(ctx, step.query, candidateID)
+		if err != nil {
+			return nil, err
+		}
+		if step.count != nil {
+			if *step.count, err = result.RowsAffected(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE candidates
+		SET name = $1, email = '', phone = '', resume_url = '', parsed_data = NULL, email_index = NULL,
+			salary_expectation = '', erased_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING erased_at
+	`, ErasedCandidateName, candidateID).Scan(&erasure.ErasedAt)
+	if err != nil {
+		return nil, err
+	}
+	return erasure, tx.Commit()
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// DigestRepository reads the activity summarised in digest and reminder emails.
+// Only open jobs and active, interactive users are included. Access scopes do not
+// apply: recipients are members of the job's hiring team, who may see it anyway.
+type DigestRepository interface {
+	ListJobActivity(ctx context.Context, since, staleBefore time.Time) ([]*models.JobActivity, error)
+	ListStaleCandidates(ctx context.Context, staleBefore time.Time) ([]*models.StaleCandidate, error)
+}
+
+// PostgresDigestRepository implements DigestRepository for PostgreSQL
+type PostgresDigestRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresDigestRepository creates a new PostgresDigestRepository
+func NewPostgresDigestRepository(db *sql.DB) *PostgresDigestRepository {
+	return &PostgresDigestRepository{db: db}
+}
+
+// staleCandidateClause matches candidates aliased c that are still in the pipeline
+// but have not been updated or commented on since the time bound as the argument
+const staleCandidateClause = `c.status IN ('applied', 'screened', 'interviewing')
+		AND c.updated_at < $%[1]d
+		AND NOT EXISTS (SELECT 1 FROM comments sc WHERE sc.candidate_id = c.id AND sc.created_at >= $%[1]d)`
+
+// digestRecipientClause matches users aliased u who should receive email
+const digestRecipientClause = `u.status = 'active' AND u.deleted_at IS NULL AND NOT u.service_account`
+
+// ListJobActivity returns, for each hiring team member of each open job, the
+// candidates added and comments left by others since a time, and how many
+// candidates have gone stale. Rows are ordered by user.
+func (r *PostgresDigestRepository) ListJobActivity(ctx context.Context, since, staleBefore time.Time) ([]*models.JobActivity, error) {
+	query := `
+		SELECT u.id, u.email, u.name, j.id, j.title,
+			(SELECT COUNT(*) FROM candidates c WHERE c.job_posting_id = j.id AND c.created_at >= $1),
+			(SELECT COUNT(*) FROM comments cm JOIN candidates c ON cm.candidate_id = c.id
+				WHERE c.job_posting_id = j.id AND cm.created_at >= $1 AND cm.user_id <> u.id),
+			(SELECT COUNT(*) FROM candidates c WHERE c.job_posting_id = j.id AND ` + fmt.Sprintf(staleCandidateClause, 2) + `)
+		FROM job_team_members m
+		JOIN users u ON m.user_id = u.id
+		JOIN job_postings j ON m.job_posting_id = j.id
+		WHERE j.status = 'open' AND ` + digestRecipientClause + `
+		ORDER BY u.id, j.title
+	`
+	rows, err := r.db.QueryContext(ctx, query, since, staleBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []*models.JobActivity
+	for rows.Next() {
+		a := &models.JobActivity{}
+		if err := rows.Scan(
+			&a.UserID, &a.UserEmail, &a.UserName, &a.JobID, &a.JobTitle,
+			&a.NewCandidates, &a.NewComments, &a.StaleCandidates,
+		); err != nil {
+			return nil, err
+		}
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+// ListStaleCandidates returns candidates on open jobs that have not been updated
+// or commented on since staleBefore, once for each owner of their job. Rows are
+// ordered by owner, then job, then the longest waiting candidate.
+func (r *PostgresDigestRepository) ListStaleCandidates(ctx context.Context, staleBefore time.Time) ([]*models.StaleCandidate, error) {
+	query := `
+		SELECT c.id, c.name, c.status, j.id, j.title, u.id, u.email, u.name, c.updated_at
+		FROM candidates c
+		JOIN job_postings j ON c.job_posting_id = j.id
+		JOIN job_team_members m ON m.job_posting_id = j.id AND m.team_role = 'owner'
+		JOIN users u ON m.user_id = u.id
+		WHERE j.status = 'open' AND ` + fmt.Sprintf(staleCandidateClause, 1) + ` AND ` + digestRecipientClause + `
+		ORDER BY u.id, j.title, c.updated_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, staleBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []*models.StaleCandidate
+	for rows.Next() {
+		s := &models.StaleCandidate{}
+		if err := rows.Scan(
+			&s.CandidateID, &s.CandidateName, &s.Status, &s.JobID, &s.JobTitle,
+			&s.OwnerID, &s.OwnerEmail, &s.OwnerName, &s.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, s)
+	}
+	return candidates, rows.Err()
+}
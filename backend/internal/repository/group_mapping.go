@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// AnyProvider is the provider of group mappings that apply to every identity provider
+const AnyProvider = "*"
+
+// GroupMappingRepository defines the interface for group to role mapping operations
+type GroupMappingRepository interface {
+	Create(ctx context.Context, mapping *models.GroupRoleMapping) error
+	GetByID(ctx context.Context, id string) (*models.GroupRoleMapping, error)
+	List(ctx context.Context) ([]*models.GroupRoleMapping, error)
+	ListForProvider(ctx context.Context, provider string) ([]*models.GroupRoleMapping, error)
+	Update(ctx context.Context, mapping *models.GroupRoleMapping) error
+	Delete(ctx context.Context, id string) error
+}
+
+// PostgresGroupMappingRepository implements GroupMappingRepository for PostgreSQL
+type PostgresGroupMappingRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresGroupMappingRepository creates a new PostgresGroupMappingRepository
+func NewPostgresGroupMappingRepository(db *sql.DB) *PostgresGroupMappingRepository {
+	return &PostgresGroupMappingRepository{db: db}
+}
+
+const groupMappingColumns = `id, provider, group_name, role, priority, created_at, updated_at, created_by`
+
+func (r *PostgresGroupMappingRepository) Create(ctx context.Context, mapping *models.GroupRoleMapping) error {
+	query := `
+		INSERT INTO group_role_mappings (provider, group_name, role, priority, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		mapping.Provider, mapping.GroupName, mapping.Role, mapping.Priority, nullStringOrNil(mapping.CreatedBy),
+	).Scan(&mapping.ID, &mapping.CreatedAt, &mapping.UpdatedAt)
+}
+
+func (r *PostgresGroupMappingRepository) GetByID(ctx context.Context, id string) (*models.GroupRoleMapping, error) {
+	query := `SELECT ` + groupMappingColumns + ` FROM group_role_mappings WHERE id = $1`
+	mapping, err := scanGroupMapping(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return mapping, err
+}
+
+func (r *PostgresGroupMappingRepository) List(ctx context.Context) ([]*models.GroupRoleMapping, error) {
+	query := `SELECT ` + groupMappingColumns + ` FROM group_role_mappings ORDER BY priority DESC, group_name ASC`
+	return r.queryMappings(ctx, query)
+}
+
+// ListForProvider returns the mappings that apply to the provider, highest priority first
+func (r *PostgresGroupMappingRepository) ListForProvider(ctx context.Context, provider string) ([]*models.GroupRoleMapping, error) {
+	query := `
+		SELECT ` + groupMappingColumns + `
+		FROM group_role_mappings
+		WHERE provider = $1 OR provider = $2
+		ORDER BY priority DESC, created_at ASC
+	`
+	return r.queryMappings(ctx, query, provider, AnyProvider)
+}
+
+func (r *PostgresGroupMappingRepository) Update(ctx context.Context, mapping *models.GroupRoleMapping) error {
+	query := `
+		UPDATE group_role_mappings
+		SET provider = $1, group_name = $2, role = $3, priority = $4
+		WHERE id = $5
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		mapping.Provider, mapping.GroupName, mapping.Role, mapping.Priority, mapping.ID,
+	).Scan(&mapping.UpdatedAt)
+}
+
+func (r *PostgresGroupMappingRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM group_role_mappings WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *PostgresGroupMappingRepository) queryMappings(ctx context.Context, query string, args ...interface{}) ([]*models.GroupRoleMapping, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mappings []*models.GroupRoleMapping
+	for rows.Next() {
+		mapping, err := scanGroupMapping(rows)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, rows.Err()
+}
+
+func scanGroupMapping(row rowScanner) (*models.GroupRoleMapping, error) {
+	mapping := &models.GroupRoleMapping{}
+	var createdBy sql.NullString
+	if err := row.Scan(
+		&mapping.ID, &mapping.Provider, &mapping.GroupName, &mapping.Role, &mapping.Priority,
+		&mapping.CreatedAt, &mapping.UpdatedAt, &createdBy,
+	); err != nil {
+		return nil, err
+	}
+	if createdBy.Valid {
+		mapping.CreatedBy = createdBy.String
+	}
+	return mapping, nil
+}
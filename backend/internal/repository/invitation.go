@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// InvitationRepository defines the interface for invitation operations
+type InvitationRepository interface {
+	Invite(ctx context.Context, user *models.User, invitation *models.Invitation) error
+	GetByID(ctx context.Context, id string) (*models.Invitation, error)
+	List(ctx context.Context) ([]*models.Invitation, error)
+	GetPendingForUser(ctx context.Context, userID string) (*models.Invitation, error)
+	Accept(ctx context.Context, id string) error
+	Revoke(ctx context.Context, id string) error
+	Extend(ctx context.Context, id string, expiresAt time.Time) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PostgresInvitationRepository implements InvitationRepository for PostgreSQL
+type PostgresInvitationRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresInvitationRepository creates a new PostgresInvitationRepository
+func NewPostgresInvitationRepository(db *sql.DB) *PostgresInvitationRepository {
+	return &PostgresInvitationRepository{db: db}
+}
+
+const invitationSelect = `
+	SELECT i.id, i.user_id, i.email, u.name, u.role, i.invited_by, COALESCE(ib.name, ''),
+		i.expires_at, i.accepted_at, i.revoked_at, i.created_at, i.updated_at
+	FROM invitations i
+	JOIN users u ON i.user_id = u.id
+	LEFT JOIN users ib ON i.invited_by = ib.id
+`
+
+// Invite records an invitation. If user.ID is empty the user is pre-created with
+// status "invited" in the same transaction; otherwise an existing invited user is re-invited.
+func (r *PostgresInvitationRepository) Invite(ctx context.Context, user *models.User, invitation *models.Invitation) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	if user.ID == "" {
+		userQuery := `
+			INSERT INTO users (email, name, role, workspace_domain, status)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, status, created_at, updated_at
+		`
+		if err := tx.QueryRowContext(ctx, userQuery,
+			user.Email, user.Name, user.Role, user.WorkspaceDomain, UserStatusInvited,
+		).Scan(&user.ID, &user.Status, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return err
+		}
+	} else {
+		// Supersede any invitation still outstanding for this user
+		revokeQuery := `
+			UPDATE invitations SET revoked_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+		`
+		if _, err := tx.ExecContext(ctx, revokeQuery, user.ID); err != nil {
+			return err
+		}
+	}
+
+	invitation.UserID = user.ID
+	invitation.Email = user.Email
+	invitation.Name = user.Name
+	invitation.Role = user.Role
+	query := `
+		INSERT INTO invitations (user_id, email, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	if err := tx.QueryRowContext(ctx, query,
+		invitation.UserID, invitation.Email, invitation.InvitedBy, invitation.ExpiresAt,
+	).Scan(&invitation.ID, &invitation.CreatedAt, &invitation.UpdatedAt); err != nil {
+		return err
+	}
+	invitation.Status = invitationStatus(invitation)
+
+	return tx.Commit()
+}
+
+func (r *PostgresInvitationRepository) GetByID(ctx context.Context, id string) (*models.Invitation, error) {
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, invitationSelect+` WHERE i.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return invitation, err
+}
+
+func (r *PostgresInvitationRepository) List(ctx context.Context) ([]*models.Invitation, error) {
+	rows, err := r.db.QueryContext(ctx, invitationSelect+` ORDER BY i.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*models.Invitation
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+// GetPendingForUser returns the user's unexpired, unrevoked and unaccepted invitation, or nil
+func (r *PostgresInvitationRepository) GetPendingForUser(ctx context.Context, userID string) (*models.Invitation, error) {
+	query := invitationSelect + `
+		WHERE i.user_id = $1 AND i.accepted_at IS NULL AND i.revoked_at IS NULL AND i.expires_at > CURRENT_TIMESTAMP
+		ORDER BY i.created_at DESC
+		LIMIT 1
+	`
+	invitation, err := scanInvitation(r.db.QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return invitation, err
+}
+
+// Accept marks the invitation accepted and activates the invited user
+func (r *PostgresInvitationRepository) Accept(ctx context.Context, id string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	var userID string
+	query := `
+		UPDATE invitations SET accepted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING user_id
+	`
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&userID); err != nil {
+		return err
+	}
+
+	userQuery := `UPDATE users SET status = $1 WHERE id = $2 AND status = $3`
+	if _, err := tx.ExecContext(ctx, userQuery, UserStatusActive, userID, UserStatusInvited); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresInvitationRepository) Revoke(ctx context.Context, id string) error {
+	query := `UPDATE invitations SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *PostgresInvitationRepository) Extend(ctx context.Context, id string, expiresAt time.Time) error {
+	query := `UPDATE invitations SET expires_at = $1 WHERE id = $2 AND accepted_at IS NULL AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, expiresAt, id)
+	return err
+}
+
+// DeleteExpired deletes invitations that were never accepted and expired or were
+// revoked before the given time. Accepted invitations are kept as history.
+func (r *PostgresInvitationRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM invitations WHERE accepted_at IS NULL AND (expires_at < $1 OR revoked_at < $1)`
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanInvitation(row rowScanner) (*models.Invitation, error) {
+	invitation := &models.Invitation{}
+	var acceptedAt, revokedAt sql.NullTime
+	if err := row.Scan(
+		&invitation.ID, &invitation.UserID, &invitation.Email, &invitation.Name, &invitation.Role,
+		&invitation.InvitedBy, &invitation.InvitedByName, &invitation.ExpiresAt,
+		&acceptedAt, &revokedAt, &invitation.CreatedAt, &invitation.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	invitation.AcceptedAt = timePtr(acceptedAt)
+	invitation.RevokedAt = timePtr(revokedAt)
+	invitation.Status = invitationStatus(invitation)
+	return invitation, nil
+}
+
+// invitationStatus derives the invitation's status from its timestamps
+func invitationStatus(invitation *models.Invitation) string {
+	switch {
+	case invitation.AcceptedAt != nil:
+		return "accepted"
+	case invitation.RevokedAt != nil:
+		return "revoked"
+	case time.Now().After(invitation.ExpiresAt):
+		return "expired"
+	default:
+		return "pending"
+	}
+}
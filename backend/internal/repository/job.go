@@ -7,13 +7,21 @@ import (
 	"github.com/candidate-organizer/backend/internal/models"
 )
 
-// JobRepository defines the interface for job posting operations
+// JobRepository defines the interface for job posting operations.
+// Reads and writes are filtered by the AccessScope carried in the context.
 type JobRepository interface {
 	Create(ctx context.Context, job *models.JobPosting) error
 	GetByID(ctx context.Context, id string) (*models.JobPosting, error)
 	List(ctx context.Context, limit, offset int) ([]*models.JobPosting, error)
 	Update(ctx context.Context, job *models.JobPosting) error
+	UpdateIfVersion(ctx context.Context, job *models.JobPosting, version int) error
 	Delete(ctx context.Context, id string) error
+	DeleteIfVersion(ctx context.Context, id string, version int) error
+	CountByStatus(ctx context.Context) (map[string]int, error)
+
+	ListTeam(ctx context.Context, jobID string) ([]*models.JobTeamMember, error)
+	SetTeamMember(ctx context.Context, member *models.JobTeamMember) error
+	RemoveTeamMember(ctx context.Context, jobID, userID string) error
 }
 
 // PostgresJobRepository implements JobRepository for PostgreSQL
@@ -26,28 +34,45 @@ func NewPostgresJobRepository(db *sql.DB) *PostgresJobRepository {
 	return &PostgresJobRepository{db: db}
 }
 
+// Create inserts the job and makes its creator the owner of the hiring team
 func (r *PostgresJobRepository) Create(ctx context.Context, job *models.JobPosting) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
 	query := `
-		INSERT INTO job_postings (title, description, requirements, location, salary_range, status, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, created_at, updated_at
+		INSERT INTO job_postings (title, description, requirements, location, salary_range, status, confidential, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, version, created_at, updated_at
 	`
-	return r.db.QueryRowContext(ctx, query,
+	if err := tx.QueryRowContext(ctx, query,
 		job.Title, job.Description, job.Requirements, job.Location,
-		job.SalaryRange, job.Status, job.CreatedBy,
-	).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+		job.SalaryRange, job.Status, job.Confidential, job.CreatedBy,
+	).Scan(&job.ID, &job.Version, &job.CreatedAt, &job.UpdatedAt); err != nil {
+		return err
+	}
+
+	teamQuery := `INSERT INTO job_team_members (job_posting_id, user_id, team_role) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, teamQuery, job.ID, job.CreatedBy, TeamRoleOwner); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *PostgresJobRepository) GetByID(ctx context.Context, id string) (*models.JobPosting, error) {
+	visible, args := jobVisibleClause(accessScopeFrom(ctx), "j", 2)
 	query := `
-		SELECT id, title, description, requirements, location, salary_range, status, created_at, updated_at, created_by
-		FROM job_postings
-		WHERE id = $1
-	`
+		SELECT j.id, j.title, j.description, j.requirements, j.location, j.salary_range, j.status, j.confidential,
+			j.version, j.created_at, j.updated_at, j.created_by
+		FROM job_postings j
+		WHERE j.id = $1 AND ` + visible
 	job := &models.JobPosting{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...).Scan(
 		&job.ID, &job.Title, &job.Description, &job.Requirements, &job.Location,
-		&job.SalaryRange, &job.Status, &job.CreatedAt, &job.UpdatedAt, &job.CreatedBy,
+		&job.SalaryRange, &job.Status, &job.Confidential, &job.Version, &job.CreatedAt, &job.UpdatedAt, &job.CreatedBy,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -56,13 +81,16 @@ func (r *PostgresJobRepository) GetByID(ctx context.Context, id string) (*models
 }
 
 func (r *PostgresJobRepository) List(ctx context.Context, limit, offset int) ([]*models.JobPosting, error) {
+	visible, args := jobVisibleClause(accessScopeFrom(ctx), "j", 3)
 	query := `
-		SELECT id, title, description, requirements, location, salary_range, status, created_at, updated_at, created_by
-		FROM job_postings
-		ORDER BY created_at DESC
+		SELECT j.id, j.title, j.description, j.requirements, j.location, j.salary_range, j.status, j.confidential,
+			j.version, j.created_at, j.updated_at, j.created_by
+		FROM job_postings j
+		WHERE ` + visible + `
+		ORDER BY j.created_at DESC
 		LIMIT $1 OFFSET $2
 	`
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{limit, offset}, args...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +101,7 @@ func (r *PostgresJobRepository) List(ctx context.Context, limit, offset int) ([]
 		job := &models.JobPosting{}
 		if err := rows.Scan(
 			&job.ID, &job.Title, &job.Description, &job.Requirements, &job.Location,
-			&job.SalaryRange, &job.Status, &job.CreatedAt, &job.UpdatedAt, &job.CreatedBy,
+			&job.SalaryRange, &job.Status, &job.Confidential, &job.Version, &job.CreatedAt, &job.UpdatedAt, &job.CreatedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -82,21 +110,209 @@ func (r *PostgresJobRepository) List(ctx context.Context, limit, offset int) ([]
 	return jobs, rows.Err()
 }
 
+// Update saves the job if the caller is an owner or recruiter on its hiring team,
+// returning ErrAccessDenied otherwise
 func (r *PostgresJobRepository) Update(ctx context.Context, job *models.JobPosting) error {
+	return r.update(ctx, job, 0)
+}
+
+// UpdateIfVersion saves the job like Update, but only if it is still at version,
+// returning ErrVersionConflict if it has changed since
+func (r *PostgresJobRepository) UpdateIfVersion(ctx context.Context, job *models.JobPosting, version int) error {
+	err := r.update(ctx, job, version)
+	if err == ErrAccessDenied {
+		return versionConflict(ctx, r.db, "job_postings", job.ID, version, err)
+	}
+	return err
+}
+
+// update saves the job if it is at version, or at any version if version is 0
+func (r *PostgresJobRepository) update(ctx context.Context, job *models.JobPosting, version int) error {
+	editable, args := jobTeamRoleClause(accessScopeFrom(ctx), "job_postings.id", 10, TeamRoleOwner, TeamRoleRecruiter)
 	query := `
 		UPDATE job_postings
-		SET title = $1, description = $2, requirements = $3, location = $4, salary_range = $5, status = $6
-		WHERE id = $7
-		RETURNING updated_at
+		SET title = $1, description = $2, requirements = $3, location = $4, salary_range = $5, status = $6, confidential = $7
+		WHERE id = $8 AND ($9 = 0 OR version = $9) AND ` + editable + `
+		RETURNING version, updated_at
 	`
-	return r.db.QueryRowContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{
 		job.Title, job.Description, job.Requirements, job.Location,
-		job.SalaryRange, job.Status, job.ID,
-	).Scan(&job.UpdatedAt)
+		job.SalaryRange, job.Status, job.Confidential, job.ID, version,
+	}, args...)...).Scan(&job.Version, &job.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAccessDenied
+	}
+	return err
 }
 
+// Delete removes the job if the caller owns it, returning ErrAccessDenied otherwise
 func (r *PostgresJobRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM job_postings WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	return r.delete(ctx, id, 0)
+}
+
+// DeleteIfVersion removes the job like Delete, but only if it is still at
+// version, returning ErrVersionConflict if it has changed since
+func (r *PostgresJobRepository) DeleteIfVersion(ctx context.Context, id string, version int) error {
+	err := r.delete(ctx, id, version)
+	if err == ErrAccessDenied {
+		return versionConflict(ctx, r.db, "job_postings", id, version, err)
+	}
 	return err
 }
+
+// delete removes the job if it is at version, or at any version if version is 0
+func (r *PostgresJobRepository) delete(ctx context.Context, id string, version int) error {
+	owner, args := jobTeamRoleClause(accessScopeFrom(ctx), "job_postings.id", 3, TeamRoleOwner)
+	query := `DELETE FROM job_postings WHERE id = $1 AND ($2 = 0 OR version = $2) AND ` + owner
+	result, err := r.db.ExecContext(ctx, query, append([]interface{}{id, version}, args...)...)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
+
+// CountByStatus returns the number of visible jobs in each status
+func (r *PostgresJobRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	visible, args := jobVisibleClause(accessScopeFrom(ctx), "j", 1)
+	query := `SELECT j.status, COUNT(*) FROM job_postings j WHERE ` + visible + ` GROUP BY j.status`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanStatusCounts(rows)
+}
+
+func (r *PostgresJobRepository) ListTeam(ctx context.Context, jobID string) ([]*models.JobTeamMember, error) {
+	visible, args := jobVisibleClause(accessScopeFrom(ctx), "j", 2)
+	query := `
+		SELECT m.job_posting_id, m.user_id, u.name, u.email, m.team_role, m.created_at, m.updated_at
+		FROM job_team_members m
+		JOIN users u ON m.user_id = u.id
+		JOIN job_postings j ON m.job_posting_id = j.id
+		WHERE m.job_posting_id = $1 AND ` + visible + `
+		ORDER BY m.created_at ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{jobID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*models.JobTeamMember
+	for rows.Next() {
+		member := &models.JobTeamMember{}
+		if err := rows.Scan(
+			&member.JobPostingID, &member.UserID, &member.UserName, &member.UserEmail,
+			&member.TeamRole, &member.CreatedAt, &member.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// SetTeamMember adds a user to the hiring team or changes their team role.
+// Only owners of the job may change its team, and its last owner cannot be
+// demoted, which returns ErrLastOwner.
+func (r *PostgresJobRepository) SetTeamMember(ctx context.Context, member *models.JobTeamMember) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	if member.TeamRole != TeamRoleOwner {
+		if err := requireOtherOwner(ctx, tx, member.JobPostingID, member.UserID); err != nil {
+			return err
+		}
+	}
+
+	owner, args := jobTeamRoleClause(accessScopeFrom(ctx), "$1::uuid", 4, TeamRoleOwner)
+	query := `
+		INSERT INTO job_team_members (job_posting_id, user_id, team_role)
+		SELECT $1, $2, $3
+		WHERE ` + owner + `
+		ON CONFLICT (job_posting_id, user_id) DO UPDATE SET team_role = EXCLUDED.team_role
+		RETURNING created_at, updated_at
+	`
+	err = tx.QueryRowContext(ctx, query, append([]interface{}{
+		member.JobPostingID, member.UserID, member.TeamRole,
+	}, args...)...).Scan(&member.CreatedAt, &member.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrAccessDenied
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveTeamMember removes a user from the hiring team. Only owners of the job may
+// change its team, and its last owner cannot be removed, which returns ErrLastOwner.
+func (r *PostgresJobRepository) RemoveTeamMember(ctx context.Context, jobID, userID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	if err := requireOtherOwner(ctx, tx, jobID, userID); err != nil {
+		return err
+	}
+
+	owner, args := jobTeamRoleClause(accessScopeFrom(ctx), "$1::uuid", 3, TeamRoleOwner)
+	query := `DELETE FROM job_team_members WHERE job_posting_id = $1 AND user_id = $2 AND ` + owner
+	result, err := tx.ExecContext(ctx, query, append([]interface{}{jobID, userID}, args...)...)
+	if err != nil {
+		return err
+	}
+	if err := requireRowsAffected(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// requireOtherOwner returns ErrLastOwner if userID is the job's only owner. The
+// owners' memberships stay locked until tx ends, so that concurrent changes
+// cannot each remove a different one of the last two owners.
+func requireOtherOwner(ctx context.Context, tx *sql.Tx, jobID, userID string) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT user_id FROM job_team_members
+		WHERE job_posting_id = $1 AND team_role = $2
+		FOR UPDATE
+	`, jobID, TeamRoleOwner)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var owners []string
+	for rows.Next() {
+		var owner string
+		if err := rows.Scan(&owner); err != nil {
+			return err
+		}
+		owners = append(owners, owner)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(owners) == 1 && owners[0] == userID {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// requireRowsAffected returns ErrAccessDenied if a scoped write matched no rows
+func requireRowsAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAccessDenied
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// MetricSnapshotRepository stores business metrics computed by a scheduled task,
+// so that every instance can report them without recounting
+type MetricSnapshotRepository interface {
+	Replace(ctx context.Context, metric string, values map[string]int) error
+	List(ctx context.Context) ([]*models.MetricSnapshot, error)
+}
+
+// PostgresMetricSnapshotRepository implements MetricSnapshotRepository for PostgreSQL
+type PostgresMetricSnapshotRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresMetricSnapshotRepository creates a new PostgresMetricSnapshotRepository
+func NewPostgresMetricSnapshotRepository(db *sql.DB) *PostgresMetricSnapshotRepository {
+	return &PostgresMetricSnapshotRepository{db: db}
+}
+
+// Replace stores the values of a metric by label, removing labels that no longer
+// have a value
+func (r *PostgresMetricSnapshotRepository) Replace(ctx context.Context, metric string, values map[string]int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM business_metrics WHERE metric = $1`, metric); err != nil {
+		return err
+	}
+	for label, value := range values {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO business_metrics (metric, label, value) VALUES ($1, $2, $3)
+		`, metric, label, value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// List returns every stored metric value
+func (r *PostgresMetricSnapshotRepository) List(ctx context.Context) ([]*models.MetricSnapshot, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT metric, label, value, refreshed_at FROM business_metrics ORDER BY metric, label`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []*models.MetricSnapshot
+	for rows.Next() {
+		s := &models.MetricSnapshot{}
+		if err := rows.Scan(&s.Metric, &s.Label, &s.Value, &s.RefreshedAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
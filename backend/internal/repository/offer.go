@@ -0,0 +1,374 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// ErrApprovalDecided is returned when an approval step was decided, or its offer
+// left approval, by someone else
+var ErrApprovalDecided = errors.New("approval already decided")
+
+// OfferRepository defines the interface for offer, approval chain and offer template operations.
+// Offer reads are filtered by the AccessScope carried in the context.
+type OfferRepository interface {
+	Create(ctx context.Context, offer *models.Offer) error
+	GetByID(ctx context.Context, id string) (*models.Offer, error)
+	ListByCandidate(ctx context.Context, candidateID string) ([]*models.Offer, error)
+	Update(ctx context.Context, offer *models.Offer) error
+	Delete(ctx context.Context, id string) error
+	ExpireOverdue(ctx context.Context, now time.Time) ([]*models.Offer, error)
+
+	SetApprovalChain(ctx context.Context, offerID string, approverIDs []string) ([]*models.OfferApproval, error)
+	ListApprovals(ctx context.Context, offerID string) ([]*models.OfferApproval, error)
+	DecideApproval(ctx context.Context, approval *models.OfferApproval, offer *models.Offer) error
+
+	CreateTemplate(ctx context.Context, template *models.OfferTemplate) error
+	GetTemplate(ctx context.Context, id string) (*models.OfferTemplate, error)
+	ListTemplates(ctx context.Context) ([]*models.OfferTemplate, error)
+	UpdateTemplate(ctx context.Context, template *models.OfferTemplate) error
+	DeleteTemplate(ctx context.Context, id string) error
+}
+
+// PostgresOfferRepository implements OfferRepository for PostgreSQL
+type PostgresOfferRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresOfferRepository creates a new PostgresOfferRepository
+func NewPostgresOfferRepository(db *sql.DB) *PostgresOfferRepository {
+	return &PostgresOfferRepository{db: db}
+}
+
+const offerColumns = `id, candidate_id, job_posting_id, template_id, status, job_title, base_salary, currency, bonus, equity,
+		compensation_notes, start_date, expires_at, sent_at, responded_at, created_at, updated_at, created_by`
+
+func (r *PostgresOfferRepository) Create(ctx context.Context, offer *models.Offer) error {
+	query := `
+		INSERT INTO offers (candidate_id, job_posting_id, template_id, status, job_title, base_salary, currency, bonus, equity,
+			compensation_notes, start_date, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		offer.CandidateID, nullStringOrNil(offer.JobPostingID), nullStringOrNil(offer.TemplateID),
+		offer.Status, offer.JobTitle, offer.BaseSalary, offer.Currency, offer.Bonus, offer.Equity,
+		offer.CompensationNotes, offer.StartDate, offer.ExpiresAt, offer.CreatedBy,
+	).Scan(&offer.ID, &offer.CreatedAt, &offer.UpdatedAt)
+}
+
+func (r *PostgresOfferRepository) GetByID(ctx context.Context, id string) (*models.Offer, error) {
+	visible, args := candidateRefVisibleClause(accessScopeFrom(ctx), "offers.candidate_id", 2)
+	query := `SELECT ` + offerColumns + ` FROM offers WHERE id = $1 AND ` + visible
+	offer, err := scanOffer(r.db.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return offer, err
+}
+
+func (r *PostgresOfferRepository) ListByCandidate(ctx context.Context, candidateID string) ([]*models.Offer, error) {
+	visible, args := candidateRefVisibleClause(accessScopeFrom(ctx), "offers.candidate_id", 2)
+	query := `SELECT ` + offerColumns + ` FROM offers WHERE candidate_id = $1 AND ` + visible + ` ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{candidateID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var offers []*models.Offer
+	for rows.Next() {
+		offer, err := scanOffer(rows)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+	return offers, rows.Err()
+}
+
+func (r *PostgresOfferRepository) Update(ctx context.Context, offer *models.Offer) error {
+	query := `
+		UPDATE offers
+		SET job_posting_id = $1, template_id = $2, status = $3, job_title = $4, base_salary = $5, currency = $6,
+			bonus = $7, equity = $8, compensation_notes = $9, start_date = $10, expires_at = $11,
+			sent_at = $12, responded_at = $13
+		WHERE id = $14
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		nullStringOrNil(offer.JobPostingID), nullStringOrNil(offer.TemplateID), offer.Status, offer.JobTitle,
+		offer.BaseSalary, offer.Currency, offer.Bonus, offer.Equity, offer.CompensationNotes,
+		offer.StartDate, offer.ExpiresAt, offer.SentAt, offer.RespondedAt, offer.ID,
+	).Scan(&offer.UpdatedAt)
+}
+
+func (r *PostgresOfferRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM offers WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// ExpireOverdue moves sent offers whose expiry has passed to expired and returns them
+func (r *PostgresOfferRepository) ExpireOverdue(ctx context.Context, now time.Time) ([]*models.Offer, error) {
+	query := `
+		UPDATE offers SET status = 'expired'
+		WHERE status = 'sent' AND expires_at < $1
+		RETURNING ` + offerColumns
+	rows, err := r.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var offers []*models.Offer
+	for rows.Next() {
+		offer, err := scanOffer(rows)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+	return offers, rows.Err()
+}
+
+// SetApprovalChain replaces the offer's approval chain with the given approvers, in order
+func (r *PostgresOfferRepository) SetApprovalChain(ctx context.Context, offerID string, approverIDs []string) ([]*models.OfferApproval, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(ctx, tx)
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM offer_approvals WHERE offer_id = $1`, offerID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO offer_approvals (offer_id, approver_id, step)
+		VALUES ($1, $2, $3)
+		RETURNING id, decision, created_at, updated_at
+	`
+	var approvals []*models.OfferApproval
+	for i, approverID := range approverIDs {
+		approval := &models.OfferApproval{
+			OfferID:    offerID,
+			ApproverID: approverID,
+			Step:       i + 1,
+		}
+		if err := tx.QueryRowContext(ctx, query, offerID, approverID, approval.Step).
+			Scan(&approval.ID, &approval.Decision, &approval.CreatedAt, &approval.UpdatedAt); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+func (r *PostgresOfferRepository) ListApprovals(ctx context.Context, offerID string) ([]*models.OfferApproval, error) {
+	query := `
+		SELECT a.id, a.offer_id, a.approver_id, u.name as approver_name, a.step, a.decision,
+			COALESCE(a.comment, ''), a.decided_at, a.created_at, a.updated_at
+		FROM offer_approvals a
+		JOIN users u ON a.approver_id = u.id
+		WHERE a.offer_id = $1
+		ORDER BY a.step ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, offerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var approvals []*models.OfferApproval
+	for rows.Next() {
+		approval := &models.OfferApproval{}
+		var decidedAt sql.NullTime
+		if err := rows.Scan(
+			&approval.ID, &approval.OfferID, &approval.ApproverID, &approval.ApproverName,
+			&approval.Step, &approval.Decision, &approval.Comment, &decidedAt,
+			&approval.CreatedAt, &approval.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		approval.DecidedAt = timePtr(decidedAt)
+		approvals = append(approvals, approval)
+	}
+	return approvals, rows.Err()
+}
+
+// DecideApproval records an approver's decision and saves the offer's resulting
+// status in one transaction, so that a decided step never leaves the offer
+// awaiting approval. It returns ErrApprovalDecided if the step was decided, or
+// the offer left approval, since they were read.
+func (r *PostgresOfferRepository) DecideApproval(ctx context.Context, approval *models.OfferApproval, offer *models.Offer) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	// Locking the offer first serialises decisions on the same offer
+	err = tx.QueryRowContext(ctx, `
+		UPDATE offers SET status = $1
+		WHERE id = $2 AND status = 'pending_approval'
+		RETURNING updated_at
+	`, offer.Status, offer.ID).Scan(&offer.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrApprovalDecided
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE offer_approvals
+		SET decision = $1, comment = $2, decided_at = $3
+		WHERE id = $4 AND decision = 'pending'
+		RETURNING updated_at
+	`, approval.Decision, approval.Comment, approval.DecidedAt, approval.ID).Scan(&approval.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrApprovalDecided
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresOfferRepository) CreateTemplate(ctx context.Context, template *models.OfferTemplate) error {
+	query := `
+		INSERT INTO offer_templates (name, body, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query, template.Name, template.Body, template.CreatedBy).
+		Scan(&template.ID, &template.CreatedAt, &template.UpdatedAt)
+}
+
+func (r *PostgresOfferRepository) GetTemplate(ctx context.Context, id string) (*models.OfferTemplate, error) {
+	query := `
+		SELECT id, name, body, created_at, updated_at, created_by
+		FROM offer_templates
+		WHERE id = $1
+	`
+	template := &models.OfferTemplate{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&template.ID, &template.Name, &template.Body,
+		&template.CreatedAt, &template.UpdatedAt, &template.CreatedBy,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return template, err
+}
+
+func (r *PostgresOfferRepository) ListTemplates(ctx context.Context) ([]*models.OfferTemplate, error) {
+	query := `
+		SELECT id, name, body, created_at, updated_at, created_by
+		FROM offer_templates
+		ORDER BY name ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*models.OfferTemplate
+	for rows.Next() {
+		template := &models.OfferTemplate{}
+		if err := rows.Scan(
+			&template.ID, &template.Name, &template.Body,
+			&template.CreatedAt, &template.UpdatedAt, &template.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, rows.Err()
+}
+
+func (r *PostgresOfferRepository) UpdateTemplate(ctx context.Context, template *models.OfferTemplate) error {
+	query := `
+		UPDATE offer_templates
+		SET name = $1, body = $2
+		WHERE id = $3
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(ctx, query, template.Name, template.Body, template.ID).
+		Scan(&template.UpdatedAt)
+}
+
+func (r *PostgresOfferRepository) DeleteTemplate(ctx context.Context, id string) error {
+	query := `DELETE FROM offer_templates WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// execer and queryer are satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func scanOffer(row rowScanner) (*models.Offer, error) {
+	offer := &models.Offer{}
+	var jobPostingID, templateID sql.NullString
+	var startDate, expiresAt, sentAt, respondedAt sql.NullTime
+
+	if err := row.Scan(
+		&offer.ID, &offer.CandidateID, &jobPostingID, &templateID, &offer.Status, &offer.JobTitle,
+		&offer.BaseSalary, &offer.Currency, &offer.Bonus, &offer.Equity, &offer.CompensationNotes,
+		&startDate, &expiresAt, &sentAt, &respondedAt,
+		&offer.CreatedAt, &offer.UpdatedAt, &offer.CreatedBy,
+	); err != nil {
+		return nil, err
+	}
+
+	if jobPostingID.Valid {
+		offer.JobPostingID = jobPostingID.String
+	}
+	if templateID.Valid {
+		offer.TemplateID = templateID.String
+	}
+	offer.StartDate = timePtr(startDate)
+	offer.ExpiresAt = timePtr(expiresAt)
+	offer.SentAt = timePtr(sentAt)
+	offer.RespondedAt = timePtr(respondedAt)
+
+	return offer, nil
+}
+
+// Helper function to convert a nullable time to a pointer
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// rollback ends a transaction that was not committed. It is deferred right after
+// BeginTx; after a commit there is nothing to roll back.
+func rollback(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		logging.FromContext(ctx).Error("Failed to roll back transaction", "error", err)
+	}
+}
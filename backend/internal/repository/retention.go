@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// RetentionRepository defines the interface for retention policies and the
+// candidates they apply to. Access scopes do not apply: policies cover every job.
+type RetentionRepository interface {
+	Create(ctx context.Context, policy *models.RetentionPolicy) error
+	GetByID(ctx context.Context, id string) (*models.RetentionPolicy, error)
+	List(ctx context.Context) ([]*models.RetentionPolicy, error)
+	Update(ctx context.Context, policy *models.RetentionPolicy) error
+	Delete(ctx context.Context, id string) error
+	ListDue(ctx context.Context, policy *models.RetentionPolicy, now time.Time, limit int) ([]*models.RetentionCandidate, error)
+	DeleteResume(ctx context.Context, candidateID string) error
+}
+
+// PostgresRetentionRepository implements RetentionRepository for PostgreSQL
+type PostgresRetentionRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRetentionRepository creates a new PostgresRetentionRepository
+func NewPostgresRetentionRepository(db *sql.DB) *PostgresRetentionRepository {
+	return &PostgresRetentionRepository{db: db}
+}
+
+const retentionPolicyColumns = `id, name, candidate_status, action, after_days, mode, created_by, created_at, updated_at`
+
+func (r *PostgresRetentionRepository) Create(ctx context.Context, policy *models.RetentionPolicy) error {
+	query := `
+		INSERT INTO retention_policies (name, candidate_status, action, after_days, mode, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		policy.Name, policy.CandidateStatus, policy.Action, policy.AfterDays, policy.Mode, nullStringOrNil(policy.CreatedBy),
+	).Scan(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt)
+}
+
+func (r *PostgresRetentionRepository) GetByID(ctx context.Context, id string) (*models.RetentionPolicy, error) {
+	query := `SELECT ` + retentionPolicyColumns + ` FROM retention_policies WHERE id = $1`
+	policy, err := scanRetentionPolicy(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return policy, err
+}
+
+func (r *PostgresRetentionRepository) List(ctx context.Context) ([]*models.RetentionPolicy, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+retentionPolicyColumns+` FROM retention_policies ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []*models.RetentionPolicy{}
+	for rows.Next() {
+		policy, err := scanRetentionPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+func (r *PostgresRetentionRepository) Update(ctx context.Context, policy *models.RetentionPolicy) error {
+	query := `
+		UPDATE retention_policies
+		SET name = $1, candidate_status = $2, action = $3, after_days = $4, mode = $5
+		WHERE id = $6
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		policy.Name, policy.CandidateStatus, policy.Action, policy.AfterDays, policy.Mode, policy.ID,
+	).Scan(&policy.UpdatedAt)
+}
+
+func (r *PostgresRetentionRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM retention_policies WHERE id = $1`, id)
+	return err
+}
+
+// ListDue returns up to limit candidates the policy applies to now, longest
+// inactive first: candidates in the policy's status that have not been updated
+// or commented on for its number of days, still have data for its action to
+// remove, and have no unexpired, unrevoked consent to be kept.
+func (r *PostgresRetentionRepository) ListDue(ctx context.Context, policy *models.RetentionPolicy, now time.Time, limit int) ([]*models.RetentionCandidate, error) {
+	remaining := `c.erased_at IS NULL`
+	if policy.Action == "delete_resume" {
+		remaining += ` AND (c.resume_url <> '' OR c.parsed_data IS NOT NULL)`
+	}
+	query := `
+		SELECT c.id, c.name, c.status, COALESCE(c.resume_url, ''),
+			GREATEST(c.updated_at, (SELECT MAX(cm.created_at) FROM comments cm WHERE cm.candidate_id = c.id)) AS last_activity
+		FROM candidates c
+		WHERE c.status = $1 AND ` + remaining + `
+			AND c.updated_at < $2
+			AND NOT EXISTS (SELECT 1 FROM comments cm WHERE cm.candidate_id = c.id AND cm.created_at >= $2)
+			AND NOT EXISTS (
+				SELECT 1 FROM candidate_consents cc
+				WHERE cc.candidate_id = c.id AND cc.revoked_at IS NULL AND cc.expires_at > $3
+			)
+		ORDER BY last_activity
+		LIMIT $4
+	`
+	cutoff := now.AddDate(0, 0, -policy.AfterDays)
+	rows, err := r.db.QueryContext(ctx, query, policy.CandidateStatus, cutoff, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []*models.RetentionCandidate{}
+	for rows.Next() {
+		c := &models.RetentionCandidate{}
+		if err := rows.Scan(&c.ID, &c.Name, &c.Status, &c.ResumeURL, &c.LastActivity); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// DeleteResume clears a candidate's resume link and the data parsed from it,
+// leaving updated_at alone so that the deletion does not count as activity.
+// The version is bumped so that clients holding the old resume see a conflict.
+func (r *PostgresRetentionRepository) DeleteResume(ctx context.Context, candidateID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	if _, err := tx.ExecContext(ctx, `SET LOCAL app.housekeeping = 'on'`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE candidates SET resume_url = '', parsed_data = NULL, version = version + 1 WHERE id = $1
+	`, candidateID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanRetentionPolicy(row rowScanner) (*models.RetentionPolicy, error) {
+	policy := &models.RetentionPolicy{}
+	var createdBy sql.NullString
+	if err := row.Scan(
+		&policy.ID, &policy.Name, &policy.CandidateStatus, &policy.Action, &policy.AfterDays, &policy.Mode,
+		&createdBy, &policy.CreatedAt, &policy.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	policy.CreatedBy = createdBy.String
+	return policy, nil
+}
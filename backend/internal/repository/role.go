@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+// RoleRepository defines the interface for role operations
+type RoleRepository interface {
+	Create(ctx context.Context, role *models.Role) error
+	GetByName(ctx context.Context, name string) (*models.Role, error)
+	List(ctx context.Context) ([]*models.Role, error)
+	Update(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, name string) error
+	CountUsers(ctx context.Context, name string) (int, error)
+}
+
+// PostgresRoleRepository implements RoleRepository for PostgreSQL
+type PostgresRoleRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRoleRepository creates a new PostgresRoleRepository
+func NewPostgresRoleRepository(db *sql.DB) *PostgresRoleRepository {
+	return &PostgresRoleRepository{db: db}
+}
+
+func (r *PostgresRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	query := `
+		INSERT INTO roles (name, description, permissions)
+		VALUES ($1, $2, $3)
+		RETURNING is_system, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query, role.Name, role.Description, pq.Array(role.Permissions)).
+		Scan(&role.IsSystem, &role.CreatedAt, &role.UpdatedAt)
+}
+
+func (r *PostgresRoleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	query := `
+		SELECT name, description, permissions, is_system, created_at, updated_at
+		FROM roles
+		WHERE name = $1
+	`
+	role := &models.Role{}
+	err := r.db.QueryRowContext(ctx, query, name).Scan(
+		&role.Name, &role.Description, pq.Array(&role.Permissions),
+		&role.IsSystem, &role.CreatedAt, &role.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return role, err
+}
+
+func (r *PostgresRoleRepository) List(ctx context.Context) ([]*models.Role, error) {
+	query := `
+		SELECT name, description, permissions, is_system, created_at, updated_at
+		FROM roles
+		ORDER BY is_system DESC, name ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*models.Role
+	for rows.Next() {
+		role := &models.Role{}
+		if err := rows.Scan(
+			&role.Name, &role.Description, pq.Array(&role.Permissions),
+			&role.IsSystem, &role.CreatedAt, &role.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *PostgresRoleRepository) Update(ctx context.Context, role *models.Role) error {
+	query := `
+		UPDATE roles
+		SET description = $1, permissions = $2
+		WHERE name = $3
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(ctx, query, role.Description, pq.Array(role.Permissions), role.Name).
+		Scan(&role.UpdatedAt)
+}
+
+func (r *PostgresRoleRepository) Delete(ctx context.Context, name string) error {
+	query := `DELETE FROM roles WHERE name = $1 AND NOT is_system`
+	_, err := r.db.ExecContext(ctx, query, name)
+	return err
+}
+
+func (r *PostgresRoleRepository) CountUsers(ctx context.Context, name string) (int, error) {
+	query := `SELECT COUNT(*) FROM users WHERE role = $1`
+	var count int
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&count)
+	return count, err
+}
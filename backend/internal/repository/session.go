@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// Session revocation reasons
+const (
+	SessionRevokedLogout      = "logout"
+	SessionRevokedLogoutAll   = "logout_all"
+	SessionRevokedAdmin       = "admin"
+	SessionRevokedReuse       = "refresh_token_reuse"
+	SessionRevokedDeactivated = "user_deactivated"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned when a refresh token is unknown or its session has ended
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again.
+	// The session is revoked, since the token has probably been stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// SessionRepository defines the interface for session and refresh token operations.
+// Refresh tokens are stored as hashes only.
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session, refreshTokenHash string) error
+	GetByID(ctx context.Context, id string) (*models.Session, error)
+	ListActiveByUser(ctx context.Context, userID string) ([]*models.Session, error)
+	Rotate(ctx context.Context, refreshTokenHash, newRefreshTokenHash string) (*models.Session, error)
+	Revoke(ctx context.Context, id, reason string) error
+	RevokeAllForUser(ctx context.Context, userID, reason string) (int64, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PostgresSessionRepository implements SessionRepository for PostgreSQL
+type PostgresSessionRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresSessionRepository creates a new PostgresSessionRepository
+func NewPostgresSessionRepository(db *sql.DB) *PostgresSessionRepository {
+	return &PostgresSessionRepository{db: db}
+}
+
+const sessionColumns = `s.id, s.user_id, s.user_agent, s.ip_address, s.expires_at, s.last_used_at,
+		s.revoked_at, COALESCE(s.revoked_reason, ''), s.created_at`
+
+// Create inserts the session together with its first refresh token
+func (r *PostgresSessionRepository) Create(ctx context.Context, session *models.Session, refreshTokenHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	query := `
+		INSERT INTO sessions (user_id, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, last_used_at, created_at
+	`
+	if err := tx.QueryRowContext(ctx, query,
+		session.UserID, session.UserAgent, session.IPAddress, session.ExpiresAt,
+	).Scan(&session.ID, &session.LastUsedAt, &session.CreatedAt); err != nil {
+		return err
+	}
+
+	tokenQuery := `INSERT INTO refresh_tokens (session_id, token_hash) VALUES ($1, $2)`
+	if _, err := tx.ExecContext(ctx, tokenQuery, session.ID, refreshTokenHash); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresSessionRepository) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions s WHERE s.id = $1`
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+// ListActiveByUser returns the user's unrevoked, unexpired sessions, most recently used first
+func (r *PostgresSessionRepository) ListActiveByUser(ctx context.Context, userID string) ([]*models.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM sessions s
+		WHERE s.user_id = $1 AND s.revoked_at IS NULL AND s.expires_at > CURRENT_TIMESTAMP
+		ORDER BY s.last_used_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// Rotate exchanges a refresh token for a new one in the same session. Presenting a
+// token that was already rotated revokes the session and returns ErrRefreshTokenReused.
+func (r *PostgresSessionRepository) Rotate(ctx context.Context, refreshTokenHash, newRefreshTokenHash string) (*models.Session, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(ctx, tx)
+
+	var tokenID string
+	var usedAt sql.NullTime
+	query := `
+		SELECT t.id, t.used_at, ` + sessionColumns + `
+		FROM refresh_tokens t
+		JOIN sessions s ON t.session_id = s.id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t, s
+	`
+	session := &models.Session{}
+	var revokedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, query, refreshTokenHash).Scan(
+		&tokenID, &usedAt,
+		&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress, &session.ExpiresAt,
+		&session.LastUsedAt, &revokedAt, &session.RevokedReason, &session.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	session.RevokedAt = timePtr(revokedAt)
+
+	if !session.IsActive() {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if usedAt.Valid {
+		revokeQuery := `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $1 WHERE id = $2`
+		if _, err := tx.ExecContext(ctx, revokeQuery, SessionRevokedReuse, session.ID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return session, ErrRefreshTokenReused
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = $1`, tokenID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO refresh_tokens (session_id, token_hash) VALUES ($1, $2)`, session.ID, newRefreshTokenHash); err != nil {
+		return nil, err
+	}
+	if err := tx.QueryRowContext(ctx,
+		`UPDATE sessions SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING last_used_at`, session.ID,
+	).Scan(&session.LastUsedAt); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r *PostgresSessionRepository) Revoke(ctx context.Context, id, reason string) error {
+	query := `UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $1 WHERE id = $2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, reason, id)
+	return err
+}
+
+// RevokeAllForUser revokes every active session of the user, returning how many were revoked
+func (r *PostgresSessionRepository) RevokeAllForUser(ctx context.Context, userID, reason string) (int64, error) {
+	query := `
+		UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $1
+		WHERE user_id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`
+	result, err := r.db.ExecContext(ctx, query, reason, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteExpired deletes sessions, and their refresh tokens, that expired or were
+// revoked before the given time, returning how many were deleted
+func (r *PostgresSessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM sessions WHERE expires_at < $1 OR revoked_at < $1`
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	session := &models.Session{}
+	var revokedAt sql.NullTime
+	if err := row.Scan(
+		&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress, &session.ExpiresAt,
+		&session.LastUsedAt, &revokedAt, &session.RevokedReason, &session.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	session.RevokedAt = timePtr(revokedAt)
+	return session, nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// signingKeyRotationLock is the advisory lock key that serialises key rotation across instances
+const signingKeyRotationLock = 7_304_001
+
+// SigningKeyRepository defines the interface for access token signing key operations
+type SigningKeyRepository interface {
+	ListValid(ctx context.Context) ([]*models.SigningKey, error)
+	RotateIfDue(ctx context.Context, key *models.SigningKey, rotateAfter, publishLead, grace time.Duration) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PostgresSigningKeyRepository implements SigningKeyRepository for PostgreSQL
+type PostgresSigningKeyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresSigningKeyRepository creates a new PostgresSigningKeyRepository
+func NewPostgresSigningKeyRepository(db *sql.DB) *PostgresSigningKeyRepository {
+	return &PostgresSigningKeyRepository{db: db}
+}
+
+// ListValid returns the keys that have not expired, newest activation first
+func (r *PostgresSigningKeyRepository) ListValid(ctx context.Context) ([]*models.SigningKey, error) {
+	query := `
+		SELECT id, algorithm, private_key, public_key, activates_at, expires_at, created_at
+		FROM signing_keys
+		WHERE expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP
+		ORDER BY activates_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.SigningKey
+	for rows.Next() {
+		key := &models.SigningKey{}
+		var expiresAt sql.NullTime
+		if err := rows.Scan(
+			&key.ID, &key.Algorithm, &key.PrivateKey, &key.PublicKey, &key.ActivatesAt, &expiresAt, &key.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		key.ExpiresAt = timePtr(expiresAt)
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RotateIfDue stores key as the next signing key if there is no key yet, or if the
+// current key is older than rotateAfter or uses another algorithm. It reports whether
+// key was stored.
+//
+// The new key is published straight away but only signs after publishLead, so that
+// verifiers caching the JWKS learn it first. The keys it replaces keep verifying for
+// grace after that. The very first key signs immediately. Instances racing to rotate
+// are serialised by an advisory lock, so only one of them stores a key.
+func (r *PostgresSigningKeyRepository) RotateIfDue(ctx context.Context, key *models.SigningKey, rotateAfter, publishLead, grace time.Duration) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer rollback(ctx, tx)
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, signingKeyRotationLock); err != nil {
+		return false, err
+	}
+
+	var latest sql.NullTime
+	var latestAlgorithm sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT created_at, algorithm FROM signing_keys
+		WHERE expires_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`).Scan(&latest, &latestAlgorithm)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	// A changed algorithm rotates straight away so the new setting takes effect
+	if latest.Valid && latestAlgorithm.String == key.Algorithm && time.Since(latest.Time) < rotateAfter {
+		return false, nil
+	}
+
+	lead := publishLead
+	if !latest.Valid {
+		lead = 0
+	}
+
+	query := `
+		INSERT INTO signing_keys (id, algorithm, private_key, public_key, activates_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + make_interval(secs => $5))
+		RETURNING activates_at, created_at
+	`
+	if err := tx.QueryRowContext(ctx, query,
+		key.ID, key.Algorithm, key.PrivateKey, key.PublicKey, lead.Seconds(),
+	).Scan(&key.ActivatesAt, &key.CreatedAt); err != nil {
+		return false, err
+	}
+
+	retireQuery := `
+		UPDATE signing_keys SET expires_at = $1::timestamptz + make_interval(secs => $2)
+		WHERE expires_at IS NULL AND id <> $3
+	`
+	if _, err := tx.ExecContext(ctx, retireQuery, key.ActivatesAt, grace.Seconds(), key.ID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteExpired deletes keys that stopped verifying before the given time
+func (r *PostgresSigningKeyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM signing_keys WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
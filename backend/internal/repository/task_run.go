@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/models"
+)
+
+// TaskRunRepository defines the interface for recording scheduled task runs
+type TaskRunRepository interface {
+	Start(ctx context.Context, run *models.TaskRun) (bool, error)
+	Finish(ctx context.Context, run *models.TaskRun) error
+	AbandonRunning(ctx context.Context, taskName string) (int64, error)
+	ListByTask(ctx context.Context, taskName string, limit int) ([]*models.TaskRun, error)
+	LatestByTask(ctx context.Context) (map[string]*models.TaskRun, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PostgresTaskRunRepository implements TaskRunRepository for PostgreSQL
+type PostgresTaskRunRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresTaskRunRepository creates a new PostgresTaskRunRepository
+func NewPostgresTaskRunRepository(db *sql.DB) *PostgresTaskRunRepository {
+	return &PostgresTaskRunRepository{db: db}
+}
+
+const taskRunColumns = `id, task_name, scheduled_for, triggered_by, instance, status, summary, error, started_at, finished_at`
+
+// Start records a run as running. It returns false, without recording anything,
+// when the scheduled occurrence has already been run.
+func (r *PostgresTaskRunRepository) Start(ctx context.Context, run *models.TaskRun) (bool, error) {
+	query := `
+		INSERT INTO scheduled_task_runs (task_name, scheduled_for, triggered_by, instance)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (task_name, scheduled_for) WHERE scheduled_for IS NOT NULL DO NOTHING
+		RETURNING id, status, started_at
+	`
+	err := r.db.QueryRowContext(ctx, query, run.TaskName, run.ScheduledFor, nullStringOrNil(run.TriggeredBy), run.Instance).Scan(
+		&run.ID, &run.Status, &run.StartedAt,
+	)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Finish stores the outcome of a run
+func (r *PostgresTaskRunRepository) Finish(ctx context.Context, run *models.TaskRun) error {
+	query := `
+		UPDATE scheduled_task_runs
+		SET status = $1, summary = $2, error = $3, finished_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING finished_at
+	`
+	var finishedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, run.Status, nullStringOrNil(run.Summary), nullStringOrNil(run.Error), run.ID).Scan(&finishedAt)
+	if err != nil {
+		return err
+	}
+	run.FinishedAt = &finishedAt
+	return nil
+}
+
+// AbandonRunning marks a task's unfinished runs as failed. The scheduler calls it
+// while holding the task's lock, when such runs can only be left over from an
+// instance that stopped mid-run.
+func (r *PostgresTaskRunRepository) AbandonRunning(ctx context.Context, taskName string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE scheduled_task_runs
+		SET status = 'failed', error = 'the instance stopped during the run', finished_at = CURRENT_TIMESTAMP
+		WHERE task_name = $1 AND status = 'running'
+	`, taskName)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListByTask returns a task's runs, newest first
+func (r *PostgresTaskRunRepository) ListByTask(ctx context.Context, taskName string, limit int) ([]*models.TaskRun, error) {
+	query := `
+		SELECT ` + taskRunColumns + ` FROM scheduled_task_runs
+		WHERE task_name = $1
+		ORDER BY started_at DESC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, taskName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []*models.TaskRun{}
+	for rows.Next() {
+		run, err := scanTaskRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// LatestByTask returns the most recent run of each task that has run
+func (r *PostgresTaskRunRepository) LatestByTask(ctx context.Context) (map[string]*models.TaskRun, error) {
+	query := `
+		SELECT DISTINCT ON (task_name) ` + taskRunColumns + ` FROM scheduled_task_runs
+		ORDER BY task_name, started_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := map[string]*models.TaskRun{}
+	for rows.Next() {
+		run, err := scanTaskRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs[run.TaskName] = run
+	}
+	return runs, rows.Err()
+}
+
+// DeleteExpired deletes runs that started before the given time, returning how
+// many were deleted
+func (r *PostgresTaskRunRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM scheduled_task_runs WHERE started_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanTaskRun(row rowScanner) (*models.TaskRun, error) {
+	run := &models.TaskRun{}
+	var scheduledFor, finishedAt sql.NullTime
+	var triggeredBy, summary, runErr sql.NullString
+	if err := row.Scan(
+		&run.ID, &run.TaskName, &scheduledFor, &triggeredBy, &run.Instance, &run.Status,
+		&summary, &runErr, &run.StartedAt, &finishedAt,
+	); err != nil {
+		return nil, err
+	}
+	run.ScheduledFor = timePtr(scheduledFor)
+	run.TriggeredBy = triggeredBy.String
+	run.Summary = summary.String
+	run.Error = runErr.String
+	run.FinishedAt = timePtr(finishedAt)
+	return run, nil
+}
@@ -3,23 +3,59 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 
 	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/lib/pq"
 )
 
+// User statuses
+const (
+	UserStatusActive      = "active"
+	UserStatusDeactivated = "deactivated"
+	UserStatusInvited     = "invited" // Pre-created, has not signed in yet
+)
+
+// ErrUserNotFound is returned by GetByID when no user has the given ID
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrIdentityConflict is returned by LinkIdentity when the user already signs
+// in with another identity
+var ErrIdentityConflict = errors.New("user is linked to another identity")
+
+// ErrLastHolder is returned by the Keeping methods when the change would leave
+// no active person whose role grants the kept permission
+var ErrLastHolder = errors.New("last active holder of the permission")
+
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id string) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByIdentity(ctx context.Context, provider, subject string) (*models.User, error)
+	LinkIdentity(ctx context.Context, userID, provider, subject string) error
 	List(ctx context.Context) ([]*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id string) error
-	PromoteToAdmin(ctx context.Context, id string) error
+	SetRole(ctx context.Context, id, role string) error
+	SetRoleKeeping(ctx context.Context, id, role, permission string) error
+	SetGroupRole(ctx context.Context, id, role string) error
+	SetStatus(ctx context.Context, id, status string) error
+	SetStatusKeeping(ctx context.Context, id, status, permission string) error
+	DeleteKeeping(ctx context.Context, id, permission string) error
+	CountActiveWithPermission(ctx context.Context, permission string) (int, error)
+	TransferOwnership(ctx context.Context, fromUserID, toUserID string) (*OwnershipTransfer, error)
 	IsFirstUser(ctx context.Context) (bool, error)
 }
 
+// OwnershipTransfer reports how many records were reassigned by TransferOwnership
+type OwnershipTransfer struct {
+	Jobs       int64 `json:"jobs"`
+	Candidates int64 `json:"candidates"`
+	Offers     int64 `json:"offers"`
+	TeamSeats  int64 `json:"team_seats"`
+}
+
 // PostgresUserRepository implements UserRepository for PostgreSQL
 type PostgresUserRepository struct {
 	db *sql.DB
@@ -30,55 +66,98 @@ func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
 	return &PostgresUserRepository{db: db}
 }
 
+const userColumns = `u.id, u.email, u.name, u.role, u.role_source, COALESCE(r.permissions, '{}'), u.workspace_domain,
+		u.status, u.deactivated_at, u.deleted_at, u.service_account, u.created_at, u.updated_at`
+
 func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (email, name, role, workspace_domain)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, created_at, updated_at
+		INSERT INTO users (email, name, role, workspace_domain, service_account)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, role_source, status, created_at, updated_at
 	`
-	return r.db.QueryRowContext(ctx, query, user.Email, user.Name, user.Role, user.WorkspaceDomain).
-		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	return r.db.QueryRowContext(ctx, query, user.Email, user.Name, user.Role, user.WorkspaceDomain, user.ServiceAccount).
+		Scan(&user.ID, &user.RoleSource, &user.Status, &user.CreatedAt, &user.UpdatedAt)
 }
 
+// GetByID returns the user, including soft-deleted users so their history can be displayed
 func (r *PostgresUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	query := `
-		SELECT id, email, name, role, workspace_domain, created_at, updated_at
-		FROM users
-		WHERE id = $1
+		SELECT ` + userColumns + `
+		FROM users u
+		LEFT JOIN roles r ON u.role = r.name
+		WHERE u.id = $1
 	`
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Email, &user.Name, &user.Role,
-		&user.WorkspaceDomain, &user.CreatedAt, &user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 	return user, err
 }
 
 func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-		SELECT id, email, name, role, workspace_domain, created_at, updated_at
-		FROM users
-		WHERE email = $1
+		SELECT ` + userColumns + `
+		FROM users u
+		LEFT JOIN roles r ON u.role = r.name
+		WHERE u.email = $1
 	`
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Email, &user.Name, &user.Role,
-		&user.WorkspaceDomain, &user.CreatedAt, &user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, email))
 	if err == sql.ErrNoRows {
 		return nil, nil // Return nil for no user found
 	}
 	return user, err
 }
 
+// GetByIdentity returns the user linked to the provider's subject, or nil if
+// the identity has not been linked yet. Deleted users are returned so that
+// callers can refuse them.
+func (r *PostgresUserRepository) GetByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		LEFT JOIN roles r ON u.role = r.name
+		WHERE i.provider = $1 AND i.subject = $2
+	`
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, provider, subject))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return user, err
+}
+
+// LinkIdentity links a sign-in identity to a user that has none yet. Users are
+// only ever matched by email for this first link; it returns ErrIdentityConflict
+// if the user already has an identity or the identity belongs to someone else.
+func (r *PostgresUserRepository) LinkIdentity(ctx context.Context, userID, provider, subject string) error {
+	query := `
+		INSERT INTO user_identities (provider, subject, user_id)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (SELECT 1 FROM user_identities WHERE user_id = $3)
+		ON CONFLICT (provider, subject) DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query, provider, subject, userID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrIdentityConflict
+	}
+	return nil
+}
+
+// List returns all users that have not been deleted
 func (r *PostgresUserRepository) List(ctx context.Context) ([]*models.User, error) {
 	query := `
-		SELECT id, email, name, role, workspace_domain, created_at, updated_at
-		FROM users
-		ORDER BY created_at DESC
+		SELECT ` + userColumns + `
+		FROM users u
+		LEFT JOIN roles r ON u.role = r.name
+		WHERE u.deleted_at IS NULL
+		ORDER BY u.created_at DESC
 	`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
@@ -88,11 +167,8 @@ func (r *PostgresUserRepository) List(ctx context.Context) ([]*models.User, erro
 
 	var users []*models.User
 	for rows.Next() {
-		user := &models.User{}
-		if err := rows.Scan(
-			&user.ID, &user.Email, &user.Name, &user.Role,
-			&user.WorkspaceDomain, &user.CreatedAt, &user.UpdatedAt,
-		); err != nil {
+		user, err := scanUser(rows)
+		if err != nil {
 			return nil, err
 		}
 		users = append(users, user)
@@ -112,21 +188,221 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *models.User)
 	).Scan(&user.UpdatedAt)
 }
 
+// Delete soft-deletes the user. The row is kept so that records they created keep their history.
 func (r *PostgresUserRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM users WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	return deleteUser(ctx, r.db, id)
+}
+
+// DeleteKeeping soft-deletes the user unless that leaves no active holder of the permission
+func (r *PostgresUserRepository) DeleteKeeping(ctx context.Context, id, permission string) error {
+	return r.keeping(ctx, permission, func(tx *sql.Tx) error {
+		return deleteUser(ctx, tx, id)
+	})
+}
+
+func deleteUser(ctx context.Context, db execer, id string) error {
+	query := `
+		UPDATE users
+		SET status = 'deactivated', deactivated_at = COALESCE(deactivated_at, CURRENT_TIMESTAMP), deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	_, err := db.ExecContext(ctx, query, id)
 	return err
 }
 
-func (r *PostgresUserRepository) PromoteToAdmin(ctx context.Context, id string) error {
-	query := `UPDATE users SET role = 'admin' WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+// SetRole assigns a role manually. Manual roles are kept when the user matches no group mapping.
+func (r *PostgresUserRepository) SetRole(ctx context.Context, id, role string) error {
+	return setRole(ctx, r.db, id, role)
+}
+
+// SetRoleKeeping assigns a role manually unless that leaves no active holder of the permission
+func (r *PostgresUserRepository) SetRoleKeeping(ctx context.Context, id, role, permission string) error {
+	return r.keeping(ctx, permission, func(tx *sql.Tx) error {
+		return setRole(ctx, tx, id, role)
+	})
+}
+
+// setRole returns ErrUserNotFound for a missing or deleted user
+func setRole(ctx context.Context, db execer, id, role string) error {
+	query := `UPDATE users SET role = $1, role_source = 'manual' WHERE id = $2 AND deleted_at IS NULL`
+	return expectOne(db.ExecContext(ctx, query, role, id))
+}
+
+// SetGroupRole assigns a role derived from the user's identity provider groups
+func (r *PostgresUserRepository) SetGroupRole(ctx context.Context, id, role string) error {
+	query := `UPDATE users SET role = $1, role_source = 'group' WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, role, id)
 	return err
 }
 
+// SetStatus activates or deactivates a user that has not been deleted
+func (r *PostgresUserRepository) SetStatus(ctx context.Context, id, status string) error {
+	return setStatus(ctx, r.db, id, status)
+}
+
+// SetStatusKeeping changes a user's status unless that leaves no active holder of the permission
+func (r *PostgresUserRepository) SetStatusKeeping(ctx context.Context, id, status, permission string) error {
+	return r.keeping(ctx, permission, func(tx *sql.Tx) error {
+		return setStatus(ctx, tx, id, status)
+	})
+}
+
+func setStatus(ctx context.Context, db execer, id, status string) error {
+	query := `
+		UPDATE users
+		SET status = $1::varchar, deactivated_at = CASE WHEN $1::varchar = 'active' THEN NULL ELSE CURRENT_TIMESTAMP END
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+	return expectOne(db.ExecContext(ctx, query, status, id))
+}
+
+// expectOne returns ErrUserNotFound if an update matched no user
+func expectOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// keeping makes a change to a user in a transaction that first locks every active
+// person whose role grants the permission, and rolls it back with ErrLastHolder if
+// afterwards none are left. Concurrent changes queue on the lock, so two of them
+// cannot each remove one of the last two holders.
+func (r *PostgresUserRepository) keeping(ctx context.Context, permission string, change func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	lockQuery := `
+		SELECT u.id
+		FROM users u
+		JOIN roles r ON u.role = r.name
+		WHERE u.status = 'active' AND u.deleted_at IS NULL AND NOT u.service_account AND $1 = ANY(r.permissions)
+		ORDER BY u.id
+		FOR UPDATE OF u
+	`
+	rows, err := tx.QueryContext(ctx, lockQuery, permission)
+	if err != nil {
+		return err
+	}
+	holders := 0
+	for rows.Next() {
+		holders++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := change(tx); err != nil {
+		return err
+	}
+
+	remaining, err := countActiveWithPermission(ctx, tx, permission)
+	if err != nil {
+		return err
+	}
+	if holders > 0 && remaining == 0 {
+		return ErrLastHolder
+	}
+	return tx.Commit()
+}
+
+// CountActiveWithPermission counts active people, not service accounts, whose role grants the permission
+func (r *PostgresUserRepository) CountActiveWithPermission(ctx context.Context, permission string) (int, error) {
+	return countActiveWithPermission(ctx, r.db, permission)
+}
+
+func countActiveWithPermission(ctx context.Context, db queryer, permission string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM users u
+		JOIN roles r ON u.role = r.name
+		WHERE u.status = 'active' AND u.deleted_at IS NULL AND NOT u.service_account AND $1 = ANY(r.permissions)
+	`
+	var count int
+	err := db.QueryRowContext(ctx, query, permission).Scan(&count)
+	return count, err
+}
+
+// TransferOwnership reassigns the jobs, candidates, offers and hiring team seats of one user to another
+func (r *PostgresUserRepository) TransferOwnership(ctx context.Context, fromUserID, toUserID string) (*OwnershipTransfer, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(ctx, tx)
+
+	transfer := &OwnershipTransfer{}
+	exec := func(dest *int64, query string) error {
+		result, err := tx.ExecContext(ctx, query, fromUserID, toUserID)
+		if err != nil {
+			return err
+		}
+		*dest, err = result.RowsAffected()
+		return err
+	}
+
+	if err := exec(&transfer.Jobs, `UPDATE job_postings SET created_by = $2 WHERE created_by = $1`); err != nil {
+		return nil, err
+	}
+	if err := exec(&transfer.Candidates, `UPDATE candidates SET created_by = $2 WHERE created_by = $1`); err != nil {
+		return nil, err
+	}
+	if err := exec(&transfer.Offers, `UPDATE offers SET created_by = $2 WHERE created_by = $1`); err != nil {
+		return nil, err
+	}
+
+	// Hand over team seats, keeping the stronger role where the recipient is already on the team
+	teamQuery := `
+		INSERT INTO job_team_members (job_posting_id, user_id, team_role)
+		SELECT job_posting_id, $2, team_role FROM job_team_members WHERE user_id = $1
+		ON CONFLICT (job_posting_id, user_id) DO UPDATE SET team_role = CASE
+			WHEN job_team_members.team_role = 'owner' OR EXCLUDED.team_role = 'owner' THEN 'owner'
+			WHEN job_team_members.team_role = 'recruiter' OR EXCLUDED.team_role = 'recruiter' THEN 'recruiter'
+			ELSE 'interviewer'
+		END
+	`
+	if err := exec(&transfer.TeamSeats, teamQuery); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM job_team_members WHERE user_id = $1`, fromUserID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
 func (r *PostgresUserRepository) IsFirstUser(ctx context.Context) (bool, error) {
 	query := `SELECT COUNT(*) FROM users`
 	var count int
 	err := r.db.QueryRowContext(ctx, query).Scan(&count)
 	return count == 0, err
 }
+
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+	var deactivatedAt, deletedAt sql.NullTime
+	if err := row.Scan(
+		&user.ID, &user.Email, &user.Name, &user.Role, &user.RoleSource, pq.Array(&user.Permissions),
+		&user.WorkspaceDomain, &user.Status, &deactivatedAt, &deletedAt, &user.ServiceAccount,
+		&user.CreatedAt, &user.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	user.DeactivatedAt = timePtr(deactivatedAt)
+	user.DeletedAt = timePtr(deletedAt)
+	return user, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrVersionConflict is returned when a write conditioned on a record's version
+// finds that the record has changed since the caller read it
+var ErrVersionConflict = errors.New("version conflict")
+
+// versionConflict explains why a write conditioned on version matched no rows:
+// ErrVersionConflict if the record still exists at another version, and err
+// otherwise. table is always a constant.
+func versionConflict(ctx context.Context, db *sql.DB, table, id string, version int, err error) error {
+	var current int
+	switch scanErr := db.QueryRowContext(ctx, `SELECT version FROM `+table+` WHERE id = $1`, id).Scan(&current); {
+	case scanErr == sql.ErrNoRows:
+		return err
+	case scanErr != nil:
+		return scanErr
+	case current != version:
+		return ErrVersionConflict
+	}
+	return err
+}
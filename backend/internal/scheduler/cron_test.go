@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // The DST tests need zones that may not be installed
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"* * * * *", false},
+		{"30 8 * * 1-5", false},
+		{"*/15 0-6,22,23 1,15 */2 7", false},
+		{"5/15 * * * *", false},
+		{"@daily", false},
+		{"@hourly", false},
+		{"* * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"*/0 * * * *", true},
+		{"10-5 * * * *", true},
+		{"@yearly", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	santiago := mustLoadLocation(t, "America/Santiago")
+
+	tests := []struct {
+		name string
+		expr string
+		loc  *time.Location
+		from time.Time
+		want time.Time
+	}{
+		{"next minute", "* * * * *", time.UTC,
+			time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC), time.Date(2024, 1, 1, 10, 1, 0, 0, time.UTC)},
+		{"weekdays", "30 8 * * 1-5", time.UTC,
+			time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC), time.Date(2024, 1, 8, 8, 30, 0, 0, time.UTC)},
+		{"day of month or week", "0 0 13 * 5", time.UTC,
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.UTC,
+			time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"impossible date", "0 0 30 2 *", time.UTC,
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}},
+
+		// On 2024-03-10 New York skips from 02:00 EST to 03:00 EDT
+		{"hour skipped by spring forward", "0 2 * * *", newYork,
+			time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), time.Date(2024, 3, 11, 2, 0, 0, 0, newYork)},
+		{"minute in skipped hour", "30 2 * * *", newYork,
+			time.Date(2024, 3, 9, 23, 0, 0, 0, newYork), time.Date(2024, 3, 11, 2, 30, 0, 0, newYork)},
+		{"every 15 minutes across spring forward", "*/15 * * * *", newYork,
+			time.Date(2024, 3, 10, 1, 50, 0, 0, newYork), time.Date(2024, 3, 10, 3, 0, 0, 0, newYork)},
+		{"hour after spring forward", "0 3 * * *", newYork,
+			time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), time.Date(2024, 3, 10, 3, 0, 0, 0, newYork)},
+
+		// On 2024-11-03 New York repeats 01:00-02:00, first in EDT and then in EST
+		{"repeated hour runs once", "30 1 * * *", newYork,
+			time.Date(2024, 11, 3, 1, 30, 0, 0, time.FixedZone("EDT", -4*3600)), time.Date(2024, 11, 4, 1, 30, 0, 0, newYork)},
+		{"hour after fall back", "0 2 * * *", newYork,
+			time.Date(2024, 11, 3, 0, 0, 0, 0, newYork), time.Date(2024, 11, 3, 2, 0, 0, 0, newYork)},
+
+		// On 2024-09-08 Santiago skips from 00:00 to 01:00, so the day has no midnight
+		{"midnight skipped by spring forward", "0 0 * * *", santiago,
+			time.Date(2024, 9, 7, 12, 0, 0, 0, santiago), time.Date(2024, 9, 9, 0, 0, 0, 0, santiago)},
+		{"first hour after skipped midnight", "0 1 * * *", santiago,
+			time.Date(2024, 9, 7, 12, 0, 0, 0, santiago), time.Date(2024, 9, 8, 1, 0, 0, 0, santiago)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr, tt.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan time.Time, 1)
+			go func() { done <- schedule.Next(tt.from) }()
+			select {
+			case got := <-done:
+				if !got.Equal(tt.want) {
+					t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Next(%v) did not return", tt.from)
+			}
+		})
+	}
+}
+
+// TestNextAdvancesThroughDST steps hourly and every-minute schedules through a
+// whole year of daylight saving changes, checking that each run is later than the last
+func TestNextAdvancesThroughDST(t *testing.T) {
+	for _, name := range []string{"America/New_York", "Europe/London", "America/Santiago", "Australia/Lord_Howe"} {
+		loc := mustLoadLocation(t, name)
+		for _, expr := range []string{"0 * * * *", "*/30 * * * *", "0 0 * * *", "30 2 * * *"} {
+			schedule, err := Parse(expr, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+			end := start.AddDate(1, 0, 0)
+			for prev := start; prev.Before(end); {
+				next := schedule.Next(prev)
+				if !next.After(prev) {
+					t.Fatalf("%s %q: Next(%v) = %v", name, expr, prev, next)
+				}
+				prev = next
+			}
+		}
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
@@ -0,0 +1,318 @@
+// Package scheduler runs recurring tasks, such as digests and purges, on cron
+// schedules. Every instance runs the scheduler; a Postgres advisory lock per task
+// elects the instance that runs each occurrence, and every run is recorded.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/logging"
+	"github.com/candidate-organizer/backend/internal/models"
+	"github.com/candidate-organizer/backend/internal/repository"
+	"github.com/candidate-organizer/backend/internal/tracing"
+)
+
+// taskLock is the first key of the two-key advisory lock held while a task runs;
+// the second is a hash of the task name
+const taskLock = 7_304_003
+
+// defaultTimeout bounds a run of a task that does not set its own timeout
+const defaultTimeout = 10 * time.Minute
+
+var (
+	// ErrUnknownTask is returned for a task name that has not been added
+	ErrUnknownTask = errors.New("unknown task")
+	// ErrTaskRunning is returned when a task cannot be run now because it is
+	// already running, on this instance or another
+	ErrTaskRunning = errors.New("task is already running")
+)
+
+// Run statuses
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Task is a piece of recurring work
+type Task struct {
+	Name        string
+	Description string
+	Schedule    string        // Cron expression, see Parse
+	Timeout     time.Duration // Zero uses 10 minutes
+	// Run does the work and returns a short summary of it, e.g. "Sent 12 digests"
+	Run func(ctx context.Context) (string, error)
+}
+
+type task struct {
+	Task
+	schedule *Schedule
+}
+
+// TaskInfo describes a task and when it runs, for admins
+type TaskInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Schedule    string          `json:"schedule"`
+	Timezone    string          `json:"timezone"`
+	NextRun     *time.Time      `json:"next_run,omitempty"`
+	LastRun     *models.TaskRun `json:"last_run,omitempty"`
+}
+
+// Scheduler runs tasks on their schedules
+type Scheduler struct {
+	db       *sql.DB
+	runRepo  repository.TaskRunRepository
+	instance string
+	location *time.Location
+	tasks    map[string]*task
+}
+
+// New creates a scheduler whose schedules are evaluated in loc
+func New(db *sql.DB, runRepo repository.TaskRunRepository, loc *time.Location) *Scheduler {
+	hostname, _ := os.Hostname()
+	return &Scheduler{
+		db:       db,
+		runRepo:  runRepo,
+		instance: fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		location: loc,
+		tasks:    map[string]*task{},
+	}
+}
+
+// Add adds a task. It fails if the schedule is invalid or the name is taken.
+func (s *Scheduler) Add(t Task) error {
+	if _, ok := s.tasks[t.Name]; ok {
+		return fmt.Errorf("task %s added twice", t.Name)
+	}
+	schedule, err := Parse(t.Schedule, s.location)
+	if err != nil {
+		return fmt.Errorf("task %s: %w", t.Name, err)
+	}
+	if t.Timeout <= 0 {
+		t.Timeout = defaultTimeout
+	}
+	s.tasks[t.Name] = &task{Task: t, schedule: schedule}
+	return nil
+}
+
+// Tasks describes every task, sorted by name, with its next and most recent runs
+func (s *Scheduler) Tasks(ctx context.Context) ([]*TaskInfo, error) {
+	latest, err := s.runRepo.LatestByTask(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	infos := make([]*TaskInfo, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		info := &TaskInfo{
+			Name:        t.Name,
+			Description: t.Description,
+			Schedule:    t.schedule.String(),
+			Timezone:    s.location.String(),
+			LastRun:     latest[t.Name],
+		}
+		if next := t.schedule.Next(now); !next.IsZero() {
+			info.NextRun = &next
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Runs returns a task's most recent runs, newest first
+func (s *Scheduler) Runs(ctx context.Context, name string, limit int) ([]*models.TaskRun, error) {
+	if _, ok := s.tasks[name]; !ok {
+		return nil, ErrUnknownTask
+	}
+	return s.runRepo.ListByTask(ctx, name, limit)
+}
+
+// Has reports whether a task with the name has been added
+func (s *Scheduler) Has(name string) bool {
+	_, ok := s.tasks[name]
+	return ok
+}
+
+// Run runs tasks as they fall due until ctx is cancelled, then waits for running
+// tasks, whose context is cancelled too. Occurrences missed while no instance was
+// running are skipped rather than caught up.
+func (s *Scheduler) Run(ctx context.Context) {
+	slog.Info("Scheduler started", "tasks", len(s.tasks), "timezone", s.location.String())
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	next := map[*task]time.Time{}
+	now := time.Now()
+	for _, t := range s.tasks {
+		next[t] = t.schedule.Next(now)
+	}
+
+	for {
+		var wake time.Time
+		for _, at := range next {
+			if !at.IsZero() && (wake.IsZero() || at.Before(wake)) {
+				wake = at
+			}
+		}
+		if wake.IsZero() {
+			<-ctx.Done()
+			return
+		}
+
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for t, at := range next {
+			if at.IsZero() || at.After(now) {
+				continue
+			}
+			running.Add(1)
+			go func() {
+				defer running.Done()
+				s.runScheduled(ctx, t, at)
+			}()
+			next[t] = t.schedule.Next(now)
+		}
+	}
+}
+
+// runScheduled runs one occurrence of a task unless another instance holds the
+// task's lock or has already run it
+func (s *Scheduler) runScheduled(ctx context.Context, t *task, scheduledFor time.Time) {
+	logger := slog.Default().With("task", t.Name)
+	unlock, err := s.lock(ctx, t.Name)
+	if errors.Is(err, ErrTaskRunning) {
+		logger.Debug("Scheduled task is running on another instance")
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to lock scheduled task", "error", err)
+		return
+	}
+	defer unlock()
+
+	run := &models.TaskRun{TaskName: t.Name, ScheduledFor: &scheduledFor, Instance: s.instance}
+	started, err := s.start(ctx, run)
+	if err != nil {
+		logger.Error("Failed to record scheduled task run", "error", err)
+		return
+	}
+	if !started {
+		logger.Debug("Scheduled task already ran on another instance", "scheduled_for", scheduledFor)
+		return
+	}
+	s.execute(ctx, t, run)
+}
+
+// RunNow runs a task straight away, on behalf of the user with the given ID. It
+// returns the recorded run, along with the task's error if it failed.
+func (s *Scheduler) RunNow(ctx context.Context, name, triggeredBy string) (*models.TaskRun, error) {
+	t, ok := s.tasks[name]
+	if !ok {
+		return nil, ErrUnknownTask
+	}
+	unlock, err := s.lock(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	run := &models.TaskRun{TaskName: name, TriggeredBy: triggeredBy, Instance: s.instance}
+	if _, err := s.start(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, s.execute(ctx, t, run)
+}
+
+// start records a run. Runs left running by an instance that stopped are marked
+// failed first; holding the task's lock means nothing else is running it.
+func (s *Scheduler) start(ctx context.Context, run *models.TaskRun) (bool, error) {
+	if _, err := s.runRepo.AbandonRunning(ctx, run.TaskName); err != nil {
+		return false, err
+	}
+	return s.runRepo.Start(ctx, run)
+}
+
+// execute runs the task and records its outcome
+func (s *Scheduler) execute(ctx context.Context, t *task, run *models.TaskRun) error {
+	logger := slog.Default().With("task", t.Name, "run_id", run.ID)
+	ctx = logging.WithLogger(ctx, logger)
+	ctx, span := tracing.StartJob(ctx, "task "+t.Name)
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	summary, err := call(ctx, t)
+	tracing.End(span, err)
+
+	run.Summary = summary
+	run.Status = StatusSucceeded
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = err.Error()
+		logger.Error("Scheduled task failed", "duration", time.Since(started).String(), "error", err)
+	} else {
+		logger.Info("Scheduled task finished", "duration", time.Since(started).String(), "summary", summary)
+	}
+
+	// Record the outcome even if the task was cut short by shutdown
+	if finishErr := s.runRepo.Finish(context.WithoutCancel(ctx), run); finishErr != nil {
+		logger.Error("Failed to record scheduled task outcome", "error", finishErr)
+	}
+	return err
+}
+
+// call runs the task, turning panics into errors
+func call(ctx context.Context, t *task) (summary string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return t.Run(ctx)
+}
+
+// lock takes the task's advisory lock on a dedicated connection, returning
+// ErrTaskRunning if it is held. The returned function releases it.
+func (s *Scheduler) lock(ctx context.Context, name string) (func(), error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, taskLock, name).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrTaskRunning
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1, hashtext($2))`, taskLock, name); err != nil {
+			slog.Error("Failed to unlock scheduled task", "task", name, "error", err)
+			// Close the connection rather than return it to the pool holding the lock
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
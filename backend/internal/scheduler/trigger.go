@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/candidate-organizer/backend/internal/jobs"
+)
+
+// KindRunTask runs a scheduled task on demand
+const KindRunTask = "scheduler.run_task"
+
+// RunTaskPayload is the payload of a KindRunTask job
+type RunTaskPayload struct {
+	Task        string `json:"task"`
+	TriggeredBy string `json:"triggered_by"` // ID of the admin who asked for the run
+}
+
+// triggerTimeoutMargin lets a triggered run reach the task's own timeout, and be
+// recorded as timed out, before the job running it is cancelled
+const triggerTimeoutMargin = time.Minute
+
+// Trigger queues a run of a task now. While a triggered run is queued or running,
+// triggering it again returns the same job. The job may run for as long as the
+// task's timeout allows.
+func (s *Scheduler) Trigger(ctx context.Context, queue *jobs.Queue, name, triggeredBy string) (*jobs.Job, error) {
+	t, ok := s.tasks[name]
+	if !ok {
+		return nil, ErrUnknownTask
+	}
+	return queue.Enqueue(ctx, KindRunTask, RunTaskPayload{Task: name, TriggeredBy: triggeredBy}, jobs.EnqueueOptions{
+		UniqueKey: KindRunTask + ":" + name,
+		Timeout:   t.Timeout + triggerTimeoutMargin,
+	})
+}
+
+// Register makes the worker run triggered tasks
+func (s *Scheduler) Register(w *jobs.Worker) {
+	jobs.Handle(w, KindRunTask, s.handleRunTask)
+}
+
+// handleRunTask runs a triggered task. A task that is already running is retried
+// later; a task that fails is not, since its failure is recorded with the run.
+func (s *Scheduler) handleRunTask(ctx context.Context, p RunTaskPayload) error {
+	run, err := s.RunNow(ctx, p.Task, p.TriggeredBy)
+	if errors.Is(err, ErrUnknownTask) || (err != nil && run != nil) {
+		return jobs.Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+// Package storage keeps uploaded files, such as resumes, outside the database.
+// Records refer to a file by its key, a relative slash-separated path.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotFound is returned when no file is stored under a key
+var ErrNotFound = errors.New("file not found")
+
+// CandidatePrefix returns the prefix of the keys of a candidate's files, such
+// as their resume. A candidate's records may only refer to keys under it.
+func CandidatePrefix(candidateID string) string {
+	return "candidates/" + candidateID + "/"
+}
+
+// Store holds files by key
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a file. Deleting a missing file is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Dir stores files in a directory on the local disk
+type Dir struct {
+	root string
+}
+
+// NewDir creates a store rooted at a directory, which is created when the first
+// file is written
+func NewDir(root string) *Dir {
+	return &Dir{root: root}
+}
+
+// path returns where a key is stored, refusing keys that would escape the root
+func (d *Dir) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes a file, replacing any stored under the same key
+func (d *Dir) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Open reads a file
+func (d *Dir) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete removes a file
+func (d *Dir) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+-- Offers, approval chains and audit trail
+
+-- Audit events table (append-only record of actions taken against entities)
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(100) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id UUID NOT NULL,
+    details JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_events_entity ON audit_events(entity_type, entity_id);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at DESC);
+
+-- Offer letter templates table
+CREATE TABLE offer_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Offers table
+CREATE TABLE offers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    candidate_id UUID NOT NULL REFERENCES candidates(id) ON DELETE CASCADE,
+    job_posting_id UUID REFERENCES job_postings(id) ON DELETE SET NULL,
+    template_id UUID REFERENCES offer_templates(id) ON DELETE SET NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'draft', -- 'draft', 'pending_approval', 'approved', 'sent', 'accepted', 'declined'
+    job_title VARCHAR(255) NOT NULL,
+    base_salary VARCHAR(100),
+    currency VARCHAR(10),
+    bonus VARCHAR(100),
+    equity VARCHAR(100),
+    compensation_notes TEXT,
+    start_date DATE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    responded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_offers_candidate_id ON offers(candidate_id);
+CREATE INDEX idx_offers_status ON offers(status);
+CREATE INDEX idx_offers_expires_at ON offers(expires_at);
+
+-- Offer approvals table (one row per step in the approval chain)
+CREATE TABLE offer_approvals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    offer_id UUID NOT NULL REFERENCES offers(id) ON DELETE CASCADE,
+    approver_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    step INTEGER NOT NULL,
+    decision VARCHAR(50) NOT NULL DEFAULT 'pending', -- 'pending', 'approved', 'rejected'
+    comment TEXT,
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(offer_id, step)
+);
+
+CREATE INDEX idx_offer_approvals_offer_id ON offer_approvals(offer_id);
+CREATE INDEX idx_offer_approvals_approver_id ON offer_approvals(approver_id);
+
+CREATE TRIGGER update_offer_templates_updated_at BEFORE UPDATE ON offer_templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_offers_updated_at BEFORE UPDATE ON offers
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_offer_approvals_updated_at BEFORE UPDATE ON offer_approvals
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,36 @@
+-- Roles and named permissions
+
+-- Roles table
+CREATE TABLE roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    is_system BOOLEAN NOT NULL DEFAULT FALSE, -- built-in roles cannot be deleted
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO roles (name, description, permissions, is_system) VALUES
+    ('admin', 'Full access, including user management and salary data',
+        ARRAY['candidates.read', 'candidates.write', 'comments.write', 'salary.read', 'jobs.read', 'jobs.manage',
+              'offers.read', 'offers.manage', 'ai.use', 'users.manage'], TRUE),
+    ('recruiter', 'Manages jobs, candidates and offers',
+        ARRAY['candidates.read', 'candidates.write', 'comments.write', 'jobs.read', 'jobs.manage',
+              'offers.read', 'ai.use'], TRUE),
+    ('hiring_manager', 'Reviews candidates and approves offers for their roles',
+        ARRAY['candidates.read', 'candidates.write', 'comments.write', 'salary.read', 'jobs.read',
+              'offers.read', 'ai.use'], TRUE),
+    ('interviewer', 'Reads candidates and leaves feedback',
+        ARRAY['candidates.read', 'comments.write', 'jobs.read'], TRUE),
+    ('viewer', 'Read-only access to jobs and candidates',
+        ARRAY['candidates.read', 'jobs.read'], TRUE);
+
+-- Migrate the original 'user' role, which could do everything except manage users and read salaries
+UPDATE users SET role = 'recruiter' WHERE role = 'user';
+
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'recruiter';
+ALTER TABLE users
+    ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
+
+CREATE TRIGGER update_roles_updated_at BEFORE UPDATE ON roles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,28 @@
+-- Hiring teams and confidential job postings
+
+ALTER TABLE job_postings ADD COLUMN confidential BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_job_postings_confidential ON job_postings(confidential);
+
+-- Job team members table
+CREATE TABLE job_team_members (
+    job_posting_id UUID NOT NULL REFERENCES job_postings(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    team_role VARCHAR(50) NOT NULL, -- 'owner', 'recruiter', 'interviewer'
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (job_posting_id, user_id)
+);
+
+CREATE INDEX idx_job_team_members_user_id ON job_team_members(user_id);
+
+-- Existing job creators become the owners of their jobs
+INSERT INTO job_team_members (job_posting_id, user_id, team_role)
+SELECT id, created_by, 'owner' FROM job_postings;
+
+-- Admins can see and manage every job regardless of hiring team
+UPDATE roles SET permissions = array_append(permissions, 'jobs.access_all')
+WHERE name = 'admin' AND NOT ('jobs.access_all' = ANY(permissions));
+
+CREATE TRIGGER update_job_team_members_updated_at BEFORE UPDATE ON job_team_members
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,32 @@
+-- User lifecycle: deactivation and soft delete
+
+ALTER TABLE users ADD COLUMN status VARCHAR(50) NOT NULL DEFAULT 'active'; -- 'active', 'deactivated'
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_users_status ON users(status);
+
+-- Users are soft-deleted, so deleting one must never cascade into the records they created
+ALTER TABLE job_postings DROP CONSTRAINT job_postings_created_by_fkey;
+ALTER TABLE job_postings
+    ADD CONSTRAINT job_postings_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE RESTRICT;
+
+ALTER TABLE candidates DROP CONSTRAINT candidates_created_by_fkey;
+ALTER TABLE candidates
+    ADD CONSTRAINT candidates_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE RESTRICT;
+
+ALTER TABLE comments DROP CONSTRAINT comments_user_id_fkey;
+ALTER TABLE comments
+    ADD CONSTRAINT comments_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+ALTER TABLE offers DROP CONSTRAINT offers_created_by_fkey;
+ALTER TABLE offers
+    ADD CONSTRAINT offers_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE RESTRICT;
+
+ALTER TABLE offer_templates DROP CONSTRAINT offer_templates_created_by_fkey;
+ALTER TABLE offer_templates
+    ADD CONSTRAINT offer_templates_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE RESTRICT;
+
+ALTER TABLE offer_approvals DROP CONSTRAINT offer_approvals_approver_id_fkey;
+ALTER TABLE offer_approvals
+    ADD CONSTRAINT offer_approvals_approver_id_fkey FOREIGN KEY (approver_id) REFERENCES users(id) ON DELETE RESTRICT;
@@ -0,0 +1,19 @@
+-- Map identity provider groups to application roles
+
+CREATE TABLE group_role_mappings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    provider VARCHAR(50) NOT NULL DEFAULT '*', -- 'google', an OIDC provider name, or '*' for any provider
+    group_name VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    priority INTEGER NOT NULL DEFAULT 0, -- the highest priority matching mapping wins
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE (provider, group_name)
+);
+
+-- Whether the user's role was assigned by an admin or derived from their groups at login
+ALTER TABLE users ADD COLUMN role_source VARCHAR(50) NOT NULL DEFAULT 'manual'; -- 'manual', 'group'
+
+CREATE TRIGGER update_group_role_mappings_updated_at BEFORE UPDATE ON group_role_mappings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
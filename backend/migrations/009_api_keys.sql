@@ -0,0 +1,23 @@
+-- Personal API keys and service accounts for automation
+
+-- Service accounts are users that cannot sign in interactively and authenticate with API keys only
+ALTER TABLE users ADD COLUMN service_account BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL, -- first characters of the key, shown to identify it
+    key_hash VARCHAR(64) NOT NULL UNIQUE, -- SHA-256 of the key, hex encoded
+    scopes TEXT[] NOT NULL DEFAULT '{}', -- permissions the key may use, limited by its user's role
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
+
+-- Audit events record the API key that made the change, if any
+ALTER TABLE audit_events ADD COLUMN api_key_id UUID REFERENCES api_keys(id) ON DELETE SET NULL;
@@ -0,0 +1,13 @@
+-- Asymmetric JWT signing keys, shared by all server instances and rotated on a schedule
+
+CREATE TABLE signing_keys (
+    id VARCHAR(64) PRIMARY KEY, -- the kid header of tokens signed with the key
+    algorithm VARCHAR(10) NOT NULL, -- 'RS256' or 'ES256'
+    private_key TEXT NOT NULL, -- PKCS #8, PEM encoded
+    public_key TEXT NOT NULL, -- PKIX, PEM encoded
+    activates_at TIMESTAMP WITH TIME ZONE NOT NULL, -- signing starts here, after the key has been published
+    expires_at TIMESTAMP WITH TIME ZONE, -- set when the key is rotated out; verification stops here
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_signing_keys_expires_at ON signing_keys(expires_at);
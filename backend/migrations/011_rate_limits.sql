@@ -0,0 +1,11 @@
+-- Token buckets for the PostgreSQL rate limit store (RATE_LIMIT_STORE=postgres).
+-- Unlogged: buckets are cheap to lose on a crash and written on every request.
+
+CREATE UNLOGGED TABLE rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY, -- route group and caller, e.g. 'auth:ip:203.0.113.7'
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL, -- whether the last request took a token
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
@@ -0,0 +1,24 @@
+-- Records which migrations have been applied, so the server can tell whether the
+-- schema is up to date. Every migration from here on inserts its own version.
+
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY, -- the number the migration file starts with
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Migrations before this one were applied without being recorded
+INSERT INTO schema_migrations (version, name) VALUES
+    (1, '001_init.sql'),
+    (2, '002_offers.sql'),
+    (3, '003_roles.sql'),
+    (4, '004_hiring_teams.sql'),
+    (5, '005_user_lifecycle.sql'),
+    (6, '006_invitations.sql'),
+    (7, '007_group_role_mappings.sql'),
+    (8, '008_sessions.sql'),
+    (9, '009_api_keys.sql'),
+    (10, '010_signing_keys.sql'),
+    (11, '011_rate_limits.sql'),
+    (12, '012_schema_migrations.sql')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,36 @@
+-- Background job queue. Workers claim due jobs with SELECT ... FOR UPDATE SKIP LOCKED,
+-- so any number of worker processes can share it.
+
+CREATE TABLE background_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(100) NOT NULL, -- selects the handler, e.g. 'email.send'
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'queued', -- 'queued', 'running', 'succeeded' or 'dead'
+    unique_key VARCHAR(255), -- at most one queued or running job per key
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP, -- not claimed before this
+    locked_at TIMESTAMP WITH TIME ZONE, -- when the current attempt started
+    locked_by VARCHAR(255), -- worker running the current attempt
+    last_error TEXT,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Only the jobs workers look for are indexed for claiming
+CREATE INDEX idx_background_jobs_due ON background_jobs(run_at) WHERE status = 'queued';
+CREATE INDEX idx_background_jobs_running ON background_jobs(locked_at) WHERE status = 'running';
+CREATE INDEX idx_background_jobs_status ON background_jobs(status, created_at);
+CREATE UNIQUE INDEX idx_background_jobs_unique_key ON background_jobs(unique_key)
+    WHERE status IN ('queued', 'running');
+
+CREATE TRIGGER update_background_jobs_updated_at BEFORE UPDATE ON background_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Admins can inspect and retry background jobs
+UPDATE roles SET permissions = array_append(permissions, 'system.manage')
+WHERE name = 'admin' AND NOT ('system.manage' = ANY(permissions));
+
+INSERT INTO schema_migrations (version, name) VALUES (13, '013_background_jobs.sql')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,35 @@
+-- Runs of recurring tasks such as digests and purges. Each occurrence of a schedule
+-- is recorded once, so only one instance runs it even if several wake up for it.
+
+CREATE TABLE scheduled_task_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_name VARCHAR(100) NOT NULL,
+    scheduled_for TIMESTAMP WITH TIME ZONE, -- the occurrence this run is for; NULL when triggered by an admin
+    triggered_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    instance VARCHAR(255) NOT NULL, -- host and process that ran it
+    status VARCHAR(20) NOT NULL DEFAULT 'running', -- 'running', 'succeeded' or 'failed'
+    summary TEXT, -- what the task did, e.g. 'Sent 12 digests'
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_scheduled_task_runs_occurrence ON scheduled_task_runs(task_name, scheduled_for)
+    WHERE scheduled_for IS NOT NULL;
+CREATE INDEX idx_scheduled_task_runs_task ON scheduled_task_runs(task_name, started_at DESC);
+
+-- Candidate and job counts for /metrics, refreshed by a scheduled task so that
+-- scrapes of every instance do not each count the whole database
+CREATE TABLE business_metrics (
+    metric VARCHAR(100) NOT NULL, -- e.g. 'candidates'
+    label VARCHAR(100) NOT NULL, -- the status counted
+    value DOUBLE PRECISION NOT NULL,
+    refreshed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (metric, label)
+);
+
+-- Sent offers that pass expires_at unanswered are moved to the new 'expired' status
+-- by the offers.expire task; offers.status needs no change for it.
+
+INSERT INTO schema_migrations (version, name) VALUES (14, '014_scheduled_tasks.sql')
+ON CONFLICT (version) DO NOTHING;
@@ -0,0 +1,11 @@
+-- Data subject requests. Erasing a candidate anonymizes their row rather than
+-- deleting it, so that counts by status and job stay correct.
+
+ALTER TABLE candidates ADD COLUMN erased_at TIMESTAMP WITH TIME ZONE;
+
+-- Admins can export and erase candidates' personal data
+UPDATE roles SET permissions = array_append(permissions, 'privacy.manage')
+WHERE name = 'admin' AND NOT ('privacy.manage' = ANY(permissions));
+
+INSERT INTO schema_migrations (version, name) VALUES (15, '015_candidate_erasure.sql')
+ON CONFLICT (version) DO NOTHING;
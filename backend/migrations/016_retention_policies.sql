@@ -0,0 +1,52 @@
+-- Retention policies remove candidates' personal data once it has not been used
+-- for a while, unless the candidate agreed to be kept in the talent pool.
+
+CREATE TABLE retention_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    candidate_status VARCHAR(50) NOT NULL, -- candidates the policy applies to, e.g. 'rejected'
+    action VARCHAR(50) NOT NULL, -- 'anonymize' or 'delete_resume'
+    after_days INTEGER NOT NULL CHECK (after_days > 0), -- days since the candidate was last updated or commented on
+    mode VARCHAR(20) NOT NULL DEFAULT 'dry_run', -- 'disabled', 'dry_run' (report only) or 'enforce'
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_retention_policies_updated_at BEFORE UPDATE ON retention_policies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- A candidate's agreement to be kept until a date; no policy applies to them meanwhile
+CREATE TABLE candidate_consents (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    candidate_id UUID NOT NULL REFERENCES candidates(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    note TEXT, -- how the consent was given, e.g. 'Replied to talent pool email'
+    recorded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_candidate_consents_candidate_id ON candidate_consents(candidate_id);
+
+-- Removing old data under a retention policy is not activity: it must not restart
+-- the period after which other policies apply. Purges set app.housekeeping for
+-- their transaction to keep updated_at unchanged.
+CREATE OR REPLACE FUNCTION update_candidates_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF current_setting('app.housekeeping', true) = 'on' THEN
+        NEW.updated_at = OLD.updated_at;
+    ELSE
+        NEW.updated_at = CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER update_candidates_updated_at ON candidates;
+CREATE TRIGGER update_candidates_updated_at BEFORE UPDATE ON candidates
+    FOR EACH ROW EXECUTE FUNCTION update_candidates_updated_at_column();
+
+INSERT INTO schema_migrations (version, name) VALUES (16, '016_retention_policies.sql')
+ON CONFLICT (version) DO NOTHING;
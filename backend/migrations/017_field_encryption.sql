@@ -0,0 +1,29 @@
+-- Data keys encrypt sensitive candidate columns. They are stored wrapped by a
+-- master key from configuration (or a KMS) and unwrapped in memory only.
+CREATE TABLE data_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    purpose VARCHAR(20) NOT NULL DEFAULT 'data' CHECK (purpose IN ('data', 'blind_index')),
+    wrapped_key BYTEA NOT NULL,
+    master_key_id VARCHAR(100) NOT NULL, -- the master key that wraps it
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    retired_at TIMESTAMP WITH TIME ZONE -- set once no rows are encrypted with it
+);
+
+-- Blind indexes must stay comparable, so there is only ever one key for them
+CREATE UNIQUE INDEX idx_data_keys_blind_index ON data_keys(purpose) WHERE purpose = 'blind_index';
+
+-- Encrypted values are longer than the plaintext they replace. An encrypted
+-- parsed_data is stored as a JSON string.
+ALTER TABLE candidates
+    ALTER COLUMN email TYPE TEXT,
+    ALTER COLUMN phone TYPE TEXT,
+    ALTER COLUMN salary_expectation TYPE TEXT,
+    ADD COLUMN email_index VARCHAR(64), -- keyed hash of the normalized email, for lookups
+    ADD COLUMN data_key_id UUID REFERENCES data_keys(id),
+    ADD COLUMN encrypted_columns TEXT[]; -- the columns encrypted with data_key_id
+
+CREATE INDEX idx_candidates_email_index ON candidates(email_index);
+CREATE INDEX idx_candidates_data_key_id ON candidates(data_key_id);
+
+INSERT INTO schema_migrations (version, name) VALUES (17, '017_field_encryption.sql')
+ON CONFLICT (version) DO NOTHING;
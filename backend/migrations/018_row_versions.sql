@@ -0,0 +1,38 @@
+-- Row versions for optimistic concurrency control. Clients read a row's version
+-- as its ETag and send it back in If-Match; an update or delete only applies
+-- while the row is still at that version.
+
+ALTER TABLE job_postings ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE candidates ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE comments ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE candidate_attributes ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- Every update bumps the version, except housekeeping such as re-encryption that
+-- leaves what clients see unchanged. Housekeeping that does change it, such as
+-- deleting a resume, bumps the version itself.
+CREATE OR REPLACE FUNCTION increment_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF current_setting('app.housekeeping', true) = 'on' THEN
+        NEW.version = OLD.version;
+    ELSE
+        NEW.version = OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER increment_job_postings_version BEFORE UPDATE ON job_postings
+    FOR EACH ROW EXECUTE FUNCTION increment_version();
+
+CREATE TRIGGER increment_candidates_version BEFORE UPDATE ON candidates
+    FOR EACH ROW EXECUTE FUNCTION increment_version();
+
+CREATE TRIGGER increment_comments_version BEFORE UPDATE ON comments
+    FOR EACH ROW EXECUTE FUNCTION increment_version();
+
+CREATE TRIGGER increment_candidate_attributes_version BEFORE UPDATE ON candidate_attributes
+    FOR EACH ROW EXECUTE FUNCTION increment_version();
+
+INSERT INTO schema_migrations (version, name) VALUES (18, '018_row_versions.sql')
+ON CONFLICT (version) DO NOTHING;
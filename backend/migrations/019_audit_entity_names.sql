@@ -0,0 +1,7 @@
+-- Some audited entities, such as roles, are identified by name rather than by
+-- UUID, so audit events keep the entity's identifier as text.
+
+ALTER TABLE audit_events ALTER COLUMN entity_id TYPE VARCHAR(255) USING entity_id::text;
+
+INSERT INTO schema_migrations (version, name) VALUES (19, '019_audit_entity_names.sql')
+ON CONFLICT (version) DO NOTHING;
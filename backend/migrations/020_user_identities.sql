@@ -0,0 +1,16 @@
+-- Sign-in identities linked to users. Logins are matched on the provider's
+-- stable subject, so another identity provider asserting the same email
+-- address cannot take over an account.
+
+CREATE TABLE user_identities (
+    provider VARCHAR(100) NOT NULL, -- 'google' or the name of an OIDC provider
+    subject VARCHAR(255) NOT NULL, -- the provider's sub claim
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+
+INSERT INTO schema_migrations (version, name) VALUES (20, '020_user_identities.sql')
+ON CONFLICT (version) DO NOTHING;
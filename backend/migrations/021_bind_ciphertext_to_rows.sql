@@ -0,0 +1,11 @@
+-- Encrypted candidate values are now authenticated with the candidate's ID as
+-- well as the column, so that they cannot be copied from one candidate to
+-- another. Values sealed before are bound to their column only; clearing
+-- encrypted_columns makes the background re-encryption rewrite them.
+
+SET LOCAL app.housekeeping = 'on';
+
+UPDATE candidates SET encrypted_columns = NULL WHERE data_key_id IS NOT NULL;
+
+INSERT INTO schema_migrations (version, name) VALUES (21, '021_bind_ciphertext_to_rows.sql')
+ON CONFLICT (version) DO NOTHING;
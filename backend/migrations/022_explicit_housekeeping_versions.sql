@@ -0,0 +1,16 @@
+-- Housekeeping that changes what clients see, such as deleting a resume, bumps
+-- the version itself. The trigger used to reset NEW.version to OLD.version under
+-- housekeeping, discarding that bump; it now keeps whatever the statement set,
+-- which is the old version unless the statement changed it.
+CREATE OR REPLACE FUNCTION increment_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF current_setting('app.housekeeping', true) IS DISTINCT FROM 'on' THEN
+        NEW.version = OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+INSERT INTO schema_migrations (version, name) VALUES (22, '022_explicit_housekeeping_versions.sql')
+ON CONFLICT (version) DO NOTHING;
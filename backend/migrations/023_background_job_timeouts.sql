@@ -0,0 +1,8 @@
+-- Jobs can run for longer than the worker's default timeout, such as triggered
+-- runs of scheduled tasks that have their own timeout. A running job is only
+-- assumed abandoned once it has been running for longer than its timeout.
+
+ALTER TABLE background_jobs ADD COLUMN timeout_seconds INTEGER; -- NULL uses the worker's default
+
+INSERT INTO schema_migrations (version, name) VALUES (23, '023_background_job_timeouts.sql')
+ON CONFLICT (version) DO NOTHING;
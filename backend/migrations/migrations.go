@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations, so the server knows which schema
+// version it expects.
+package migrations
+
+import "embed"
+
+// FS holds the migration files, named NNN_description.sql
+//
+//go:embed *.sql
+var FS embed.FS
@@ -16,7 +16,7 @@ function AuthCallbackContent() {
       // Check for error in URL params
       const errorParam = searchParams.get('error');
       if (errorParam) {
-        setError(decodeURIComponent(errorParam));
+        setError(errorParam);
         return;
       }
 
@@ -33,8 +33,11 @@ function AuthCallbackContent() {
             // We'll use a placeholder token in localStorage, but actual auth is via cookies
             login('cookie-auth', authData.user);
 
-            // Redirect to dashboard
-            router.push('/dashboard');
+            // Continue where the user started, or at the dashboard. The backend has
+            // already validated return_to; only same-site paths are followed.
+            const returnTo = searchParams.get('return_to');
+            const isLocalPath = returnTo && returnTo.startsWith('/') && !returnTo.startsWith('//');
+            router.push(isLocalPath ? returnTo : '/dashboard');
           } else {
             setError('Failed to authenticate. Please try again.');
           }
@@ -4,6 +4,7 @@ import { useAuth } from '@/contexts/AuthContext';
 import { useRouter, useParams } from 'next/navigation';
 import { useEffect, useState } from 'react';
 import { jobsApi, UpdateJobPostingData } from '@/lib/api/services/jobs';
+import { ApiRequestError } from '@/lib/api/client';
 import { Button } from '@/components/ui/button';
 
 export default function EditJobPage() {
@@ -19,6 +20,7 @@ export default function EditJobPage() {
     salary_range: '',
     status: 'draft',
   });
+  const [version, setVersion] = useState(0);
   const [loading, setLoading] = useState(true);
   const [submitting, setSubmitting] = useState(false);
   const [error, setError] = useState<string | null>(null);
@@ -49,6 +51,7 @@ export default function EditJobPage() {
         salary_range: job.salary_range,
         status: job.status,
       });
+      setVersion(job.version);
     } catch (err: any) {
       setError(err.message || 'Failed to load job posting');
       console.error('Error fetching job:', err);
@@ -68,9 +71,13 @@ export default function EditJobPage() {
     try {
       setSubmitting(true);
       setError(null);
-      await jobsApi.update(jobId, formData);
+      await jobsApi.update(jobId, formData, version);
       router.push(`/jobs/${jobId}`);
     } catch (err: any) {
+      if (err instanceof ApiRequestError && err.status === 412) {
+        setError('Someone else changed this job posting while you were editing. Reload the page to see their changes.');
+        return;
+      }
       setError(err.message || 'Failed to update job posting');
       console.error('Error updating job:', err);
     } finally {
@@ -47,8 +47,12 @@ export default function JobDetailPage() {
       return;
     }
 
+    if (!job) {
+      return;
+    }
+
     try {
-      await jobsApi.delete(jobId);
+      await jobsApi.delete(jobId, job.version);
       router.push('/jobs');
     } catch (err: any) {
       alert(err.message || 'Failed to delete job posting');
@@ -42,13 +42,13 @@ export default function JobsPage() {
     }
   };
 
-  const handleDelete = async (id: string) => {
+  const handleDelete = async (id: string, version: number) => {
     if (!confirm('Are you sure you want to delete this job posting?')) {
       return;
     }
 
     try {
-      await jobsApi.delete(id);
+      await jobsApi.delete(id, version);
       // Refresh the list
       fetchJobs();
     } catch (err: any) {
@@ -229,7 +229,7 @@ export default function JobsPage() {
                         Edit
                       </Button>
                       <Button
-                        onClick={() => handleDelete(job.id)}
+                        onClick={() => handleDelete(job.id, job.version)}
                         variant="outline"
                         size="sm"
                       >
@@ -18,8 +18,9 @@ export default function LoginPage() {
   }, [isAuthenticated, isLoading, router]);
 
   const handleGoogleLogin = () => {
-    // Redirect to the backend Google OAuth endpoint
-    window.location.href = authService.getGoogleAuthUrl();
+    // Redirect to the backend Google OAuth endpoint, keeping the page the user came from
+    const returnTo = new URLSearchParams(window.location.search).get('return_to');
+    window.location.href = authService.getGoogleAuthUrl(returnTo);
   };
 
   if (isLoading) {
@@ -16,7 +16,9 @@ export function ProtectedRoute({ children, requireAdmin = false }: ProtectedRout
   useEffect(() => {
     if (!isLoading) {
       if (!isAuthenticated) {
-        router.push('/login');
+        // Come back to this page after signing in
+        const returnTo = window.location.pathname + window.location.search;
+        router.push(`/login?return_to=${encodeURIComponent(returnTo)}`);
       } else if (requireAdmin && user?.role !== 'admin') {
         router.push('/unauthorized');
       }
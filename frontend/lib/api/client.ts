@@ -24,6 +24,26 @@ interface RequestOptions extends RequestInit {
   token?: string;
 }
 
+const REFRESH_ENDPOINT = '/api/v1/auth/refresh';
+
+// Concurrent requests that hit an expired access token share one refresh
+let refreshInFlight: Promise<boolean> | null = null;
+
+function refreshSession(): Promise<boolean> {
+  if (!refreshInFlight) {
+    refreshInFlight = fetch(`${API_BASE_URL}${REFRESH_ENDPOINT}`, {
+      method: 'POST',
+      credentials: 'include',
+    })
+      .then((response) => response.ok)
+      .catch(() => false)
+      .finally(() => {
+        refreshInFlight = null;
+      });
+  }
+  return refreshInFlight;
+}
+
 async function handleResponse<T>(response: Response): Promise<T> {
   const contentType = response.headers.get('content-type');
   const isJson = contentType?.includes('application/json');
@@ -62,15 +82,28 @@ export async function apiRequest<T = any>(
 
   const url = `${API_BASE_URL}${endpoint}`;
 
-  const response = await fetch(url, {
-    ...fetchOptions,
-    headers,
-    credentials: 'include', // Important: include cookies for authentication
-  });
+  const send = () =>
+    fetch(url, {
+      ...fetchOptions,
+      headers,
+      credentials: 'include', // Important: include cookies for authentication
+    });
+
+  let response = await send();
+
+  // Access tokens are short-lived: refresh the session once and retry
+  if (response.status === 401 && !token && endpoint !== REFRESH_ENDPOINT && (await refreshSession())) {
+    response = await send();
+  }
 
   return handleResponse<T>(response);
 }
 
+// ifMatch makes an update or delete fail with 412 if the record changed since it was read
+export function ifMatch(version: number): Record<string, string> {
+  return { 'If-Match': `"${version}"` };
+}
+
 export const api = {
   get: <T = any>(endpoint: string, token?: string) =>
     apiRequest<T>(endpoint, { method: 'GET', token }),
@@ -82,20 +115,22 @@ export const api = {
       token,
     }),
 
-  put: <T = any>(endpoint: string, data?: any, token?: string) =>
+  put: <T = any>(endpoint: string, data?: any, token?: string, headers?: Record<string, string>) =>
     apiRequest<T>(endpoint, {
       method: 'PUT',
       body: data ? JSON.stringify(data) : undefined,
       token,
+      headers,
     }),
 
-  patch: <T = any>(endpoint: string, data?: any, token?: string) =>
+  patch: <T = any>(endpoint: string, data?: any, token?: string, headers?: Record<string, string>) =>
     apiRequest<T>(endpoint, {
       method: 'PATCH',
       body: data ? JSON.stringify(data) : undefined,
       token,
+      headers,
     }),
 
-  delete: <T = any>(endpoint: string, token?: string) =>
-    apiRequest<T>(endpoint, { method: 'DELETE', token }),
+  delete: <T = any>(endpoint: string, token?: string, headers?: Record<string, string>) =>
+    apiRequest<T>(endpoint, { method: 'DELETE', token, headers }),
 };
@@ -8,15 +8,15 @@ export interface LoginResponse {
 
 export const authService = {
   /**
-   * Get the OAuth URL to redirect to Google login
+   * Get the OAuth URL to redirect to Google login. returnTo is the page to
+   * continue at after signing in; the backend only accepts paths on this site.
    */
-  getGoogleAuthUrl: (): string => {
+  getGoogleAuthUrl: (returnTo?: string | null): string => {
     // Use relative URL since frontend and backend are on the same domain
     // Falls back to localhost for local development
-    if (typeof window !== 'undefined') {
-      return `${window.location.origin}/api/v1/auth/google`;
-    }
-    return 'http://localhost:8080/api/v1/auth/google';
+    const base = typeof window !== 'undefined' ? window.location.origin : 'http://localhost:8080';
+    const query = returnTo ? `?return_to=${encodeURIComponent(returnTo)}` : '';
+    return `${base}/api/v1/auth/google${query}`;
   },
 
   /**
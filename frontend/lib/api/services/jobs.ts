@@ -1,4 +1,4 @@
-import { api } from '../client';
+import { api, ifMatch } from '../client';
 import { JobPosting } from '../types';
 
 export interface CreateJobPostingData {
@@ -12,6 +12,11 @@ export interface CreateJobPostingData {
 
 export interface UpdateJobPostingData extends CreateJobPostingData {}
 
+// A JSON merge patch: only the fields present change, and null clears a field
+export type PatchJobPostingData = {
+  [K in keyof UpdateJobPostingData]?: UpdateJobPostingData[K] | null;
+} & { confidential?: boolean | null };
+
 export interface JobsListResponse {
   jobs: JobPosting[];
   limit: number;
@@ -36,9 +41,16 @@ export const jobsApi = {
   getById: (id: string, token?: string) =>
     api.get<JobResponse>(`/api/v1/jobs/${id}`, token),
 
-  update: (id: string, data: UpdateJobPostingData, token?: string) =>
-    api.put<JobResponse>(`/api/v1/jobs/${id}`, data, token),
+  // version is the job's version when it was read; a newer version fails with 412
+  update: (id: string, data: UpdateJobPostingData, version: number, token?: string) =>
+    api.put<JobResponse>(`/api/v1/jobs/${id}`, data, token, ifMatch(version)),
+
+  patch: (id: string, data: PatchJobPostingData, version: number, token?: string) =>
+    api.patch<JobResponse>(`/api/v1/jobs/${id}`, data, token, {
+      ...ifMatch(version),
+      'Content-Type': 'application/merge-patch+json',
+    }),
 
-  delete: (id: string, token?: string) =>
-    api.delete<{ message: string }>(`/api/v1/jobs/${id}`, token),
+  delete: (id: string, version: number, token?: string) =>
+    api.delete<{ message: string }>(`/api/v1/jobs/${id}`, token, ifMatch(version)),
 };
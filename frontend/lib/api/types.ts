@@ -2,12 +2,71 @@ export interface User {
   id: string;
   email: string;
   name: string;
-  role: 'admin' | 'user';
+  role: string; // 'admin', 'recruiter', 'hiring_manager', 'interviewer', 'viewer' or a custom role
+  role_source: 'manual' | 'group';
+  permissions: string[];
   workspace_domain: string;
+  status: 'active' | 'deactivated' | 'invited';
+  deactivated_at?: string;
+  deleted_at?: string;
+  service_account: boolean;
   created_at: string;
   updated_at: string;
 }
 
+export interface APIKey {
+  id: string;
+  user_id: string;
+  name: string;
+  prefix: string;
+  scopes: string[];
+  expires_at?: string;
+  last_used_at?: string;
+  revoked_at?: string;
+  created_by: string;
+  created_at: string;
+}
+
+export interface Session {
+  id: string;
+  user_id: string;
+  user_agent: string;
+  ip_address: string;
+  expires_at: string;
+  last_used_at: string;
+  revoked_at?: string;
+  revoked_reason?: string;
+  created_at: string;
+  current: boolean;
+}
+
+export interface Invitation {
+  id: string;
+  user_id: string;
+  email: string;
+  name: string;
+  role: string;
+  status: 'pending' | 'accepted' | 'revoked' | 'expired';
+  invited_by: string;
+  invited_by_name: string;
+  expires_at: string;
+  accepted_at?: string;
+  revoked_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface GroupRoleMapping {
+  id: string;
+  provider: string; // 'google', an OIDC provider name, or '*' for any provider
+  group_name: string;
+  role: string;
+  priority: number;
+  created_at: string;
+  updated_at: string;
+  created_by?: string;
+}
+
 export interface JobPosting {
   id: string;
   title: string;
@@ -16,6 +75,8 @@ export interface JobPosting {
   location: string;
   salary_range: string;
   status: 'open' | 'closed' | 'draft';
+  confidential: boolean;
+  version: number;
   created_at: string;
   updated_at: string;
   created_by: string;
@@ -31,6 +92,7 @@ export interface Candidate {
   status: 'applied' | 'screened' | 'interviewing' | 'offered' | 'rejected';
   salary_expectation?: string;
   job_posting_id: string;
+  version: number;
   created_at: string;
   updated_at: string;
   created_by: string;
@@ -42,6 +104,7 @@ export interface Comment {
   user_id: string;
   user_name: string;
   content: string;
+  version: number;
   created_at: string;
   updated_at: string;
 }
@@ -51,6 +114,7 @@ export interface CandidateAttribute {
   candidate_id: string;
   attribute_key: string;
   attribute_value: string;
+  version: number;
   created_at: string;
   updated_at: string;
 }
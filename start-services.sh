@@ -11,6 +11,8 @@ echo "Starting Candidate Organizer services..."
 cleanup() {
     echo "Shutting down services..."
     kill $(jobs -p) 2>/dev/null || true
+    # Wait so the backend can finish in-flight requests before the dyno stops
+    wait
     exit
 }
 trap cleanup EXIT INT TERM
@@ -69,6 +71,7 @@ while true; do
         exit 1
     fi
 
-    # Check every 5 seconds
-    sleep 5
+    # Check every 5 seconds (in the background, so SIGTERM is handled immediately)
+    sleep 5 &
+    wait $!
 done